@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -16,12 +17,23 @@ type (
 		Kafka    Kafka
 		Grpc     Grpc
 		Paginate Paginate
+		Oidc     Oidc
+		Lockout  Lockout
+		Mail     Mail
+		Tracing  Tracing
+		Log      Log
+		Timeout  Timeout
+		Blob     Blob
+		Locale   Locale
 	}
 
 	App struct {
 		Name  string
 		Url   string
 		Stage string
+		// TrustedProxies are the cidr ranges of the proxies whose X-Forwarded-For is believed,
+		// without any the client ip is the address of the connection
+		TrustedProxies []string
 	}
 
 	Db struct {
@@ -32,6 +44,7 @@ type (
 		AccessSecretKey  string
 		RefreshSecretKey string
 		ApiSecretKey     string
+		ActionSecretKey  string
 		AccessDuration   int64
 		RefreshDuration  int64
 		ApiDuration      int64
@@ -49,11 +62,102 @@ type (
 		ItemUrl      string
 		InventoryUrl string
 		PaymentUrl   string
+		// TlsMode is one of disabled, tls or mtls
+		TlsMode       string
+		TlsCaFile     string
+		TlsCertFile   string
+		TlsKeyFile    string
+		TlsServerName string
 	}
 
 	Paginate struct {
 		ItemNextPageBasedUrl      string
 		InventoryNextPageBasedUrl string
+		// CursorSecret signs the start tokens of paginated listings
+		CursorSecret string
+	}
+
+	Oidc struct {
+		Providers []OidcProvider
+	}
+
+	// Lockout durations are in seconds
+	Lockout struct {
+		MaxAttempts   int
+		IpMaxAttempts int
+		Duration      int64
+		Window        int64
+		MaxDelay      int64
+	}
+
+	Mail struct {
+		Driver         string
+		Host           string
+		Port           string
+		Username       string
+		Password       string
+		From           string
+		Dir            string
+		ActionBasedUrl string
+	}
+
+	Tracing struct {
+		// Exporter is one of none, stdout or otlp
+		Exporter     string
+		OtlpEndpoint string
+		OtlpInsecure bool
+		SampleRatio  float64
+	}
+
+	// Timeout values are in seconds. Request bounds a whole http request, the others bound a
+	// single mongo query, grpc call or wait for a kafka reply inside it.
+	Timeout struct {
+		Request int64
+		Db      int64
+		Grpc    int64
+		Kafka   int64
+	}
+
+	// Blob stores the uploaded item images. Driver is local or s3, the urls of s3 objects are
+	// presigned for UrlExpire seconds unless PublicUrl is set.
+	Blob struct {
+		Driver       string
+		LocalDir     string
+		PublicUrl    string
+		S3Endpoint   string
+		S3Region     string
+		S3Bucket     string
+		S3AccessKey  string
+		S3SecretKey  string
+		UrlExpire    int64
+		MaxImageSize int64
+	}
+
+	// Locale is what item titles and descriptions can be translated to. Default is the
+	// locale of the plain title and description, and the fallback of every request.
+	Locale struct {
+		Default   string
+		Supported []string
+	}
+
+	Log struct {
+		Level string
+		// Format is json or text
+		Format string
+		// ModuleLevels overrides Level for a module, "grpccon=warn,queue=debug"
+		ModuleLevels map[string]string
+	}
+
+	OidcProvider struct {
+		Name         string
+		Issuer       string
+		ClientId     string
+		ClientSecret string
+		RedirectUrl  string
+		Scopes       []string
+		AuthUrl      string
+		TokenUrl     string
+		UserinfoUrl  string
 	}
 )
 
@@ -64,9 +168,10 @@ func LoadConfig(path string) Config {
 
 	return Config{
 		App: App{
-			Name:  os.Getenv("APP_NAME"),
-			Url:   os.Getenv("APP_URL"),
-			Stage: os.Getenv("APP_STAGE"),
+			Name:           os.Getenv("APP_NAME"),
+			Url:            os.Getenv("APP_URL"),
+			Stage:          os.Getenv("APP_STAGE"),
+			TrustedProxies: splitList(os.Getenv("APP_TRUSTED_PROXIES")),
 		},
 		Db: Db{
 			Url: os.Getenv("DB_URL"),
@@ -75,6 +180,7 @@ func LoadConfig(path string) Config {
 			AccessSecretKey:  os.Getenv("JWT_ACCESS_SECRET_KEY"),
 			RefreshSecretKey: os.Getenv("JWT_REFRESH_SECRET_KEY"),
 			ApiSecretKey:     os.Getenv("JWT_API_SECRET_KEY"),
+			ActionSecretKey:  os.Getenv("JWT_ACTION_SECRET_KEY"),
 			AccessDuration: func() int64 {
 				result, err := strconv.ParseInt(os.Getenv("JWT_ACCESS_DURATION"), 10, 64)
 				if err != nil {
@@ -89,6 +195,7 @@ func LoadConfig(path string) Config {
 				}
 				return result
 			}(),
+			ApiDuration: intOrDefault("JWT_API_DURATION", 3600),
 		},
 		Kafka: Kafka{
 			Url:    os.Getenv("KAFKA_URL"),
@@ -96,15 +203,139 @@ func LoadConfig(path string) Config {
 			Secret: os.Getenv("KAFKA_SECRET"),
 		},
 		Grpc: Grpc{
-			AuthUrl:      os.Getenv("GRPC_AUTH_URL"),
-			PlayerUrl:    os.Getenv("GRPC_PLAYER_URL"),
-			ItemUrl:      os.Getenv("GRPC_ITEM_URL"),
-			InventoryUrl: os.Getenv("GRPC_INVENTORY_URL"),
-			PaymentUrl:   os.Getenv("GRPC_PAYMENT_URL"),
+			AuthUrl:       os.Getenv("GRPC_AUTH_URL"),
+			PlayerUrl:     os.Getenv("GRPC_PLAYER_URL"),
+			ItemUrl:       os.Getenv("GRPC_ITEM_URL"),
+			InventoryUrl:  os.Getenv("GRPC_INVENTORY_URL"),
+			PaymentUrl:    os.Getenv("GRPC_PAYMENT_URL"),
+			TlsMode:       os.Getenv("GRPC_TLS_MODE"),
+			TlsCaFile:     os.Getenv("GRPC_TLS_CA_FILE"),
+			TlsCertFile:   os.Getenv("GRPC_TLS_CERT_FILE"),
+			TlsKeyFile:    os.Getenv("GRPC_TLS_KEY_FILE"),
+			TlsServerName: os.Getenv("GRPC_TLS_SERVER_NAME"),
 		},
 		Paginate: Paginate{
 			ItemNextPageBasedUrl:      os.Getenv("PAGINATE_ITEM_NEXT_PAGE_BASED_URL"),
 			InventoryNextPageBasedUrl: os.Getenv("PAGINATE_INVENTORY_NEXT_PAGE_BASED_URL"),
+			CursorSecret:              os.Getenv("PAGINATE_CURSOR_SECRET"),
+		},
+		Oidc: Oidc{
+			Providers: func() []OidcProvider {
+				providers := make([]OidcProvider, 0)
+				for _, name := range splitList(os.Getenv("OIDC_PROVIDERS")) {
+					prefix := "OIDC_" + strings.ToUpper(name) + "_"
+					providers = append(providers, OidcProvider{
+						Name:         name,
+						Issuer:       os.Getenv(prefix + "ISSUER"),
+						ClientId:     os.Getenv(prefix + "CLIENT_ID"),
+						ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+						RedirectUrl:  os.Getenv(prefix + "REDIRECT_URL"),
+						Scopes: func() []string {
+							scopes := splitList(os.Getenv(prefix + "SCOPES"))
+							if len(scopes) == 0 {
+								return []string{"openid", "email", "profile"}
+							}
+							return scopes
+						}(),
+						AuthUrl:     os.Getenv(prefix + "AUTH_URL"),
+						TokenUrl:    os.Getenv(prefix + "TOKEN_URL"),
+						UserinfoUrl: os.Getenv(prefix + "USERINFO_URL"),
+					})
+				}
+				return providers
+			}(),
+		},
+		Lockout: Lockout{
+			MaxAttempts:   int(intOrDefault("LOCKOUT_MAX_ATTEMPTS", 5)),
+			IpMaxAttempts: int(intOrDefault("LOCKOUT_IP_MAX_ATTEMPTS", 20)),
+			Duration:      intOrDefault("LOCKOUT_DURATION", 900),
+			Window:        intOrDefault("LOCKOUT_WINDOW", 86400),
+			MaxDelay:      intOrDefault("LOCKOUT_MAX_DELAY", 30),
 		},
+		Mail: Mail{
+			Driver:         os.Getenv("MAIL_DRIVER"),
+			Host:           os.Getenv("MAIL_HOST"),
+			Port:           os.Getenv("MAIL_PORT"),
+			Username:       os.Getenv("MAIL_USERNAME"),
+			Password:       os.Getenv("MAIL_PASSWORD"),
+			From:           os.Getenv("MAIL_FROM"),
+			Dir:            os.Getenv("MAIL_DIR"),
+			ActionBasedUrl: os.Getenv("MAIL_ACTION_BASED_URL"),
+		},
+		Tracing: Tracing{
+			Exporter:     os.Getenv("TRACING_EXPORTER"),
+			OtlpEndpoint: os.Getenv("TRACING_OTLP_ENDPOINT"),
+			OtlpInsecure: os.Getenv("TRACING_OTLP_INSECURE") == "true",
+			SampleRatio: func() float64 {
+				result, err := strconv.ParseFloat(os.Getenv("TRACING_SAMPLE_RATIO"), 64)
+				if err != nil {
+					return 1
+				}
+				return result
+			}(),
+		},
+		Timeout: Timeout{
+			Request: intOrDefault("TIMEOUT_REQUEST", 30),
+			Db:      intOrDefault("TIMEOUT_DB", 10),
+			Grpc:    intOrDefault("TIMEOUT_GRPC", 10),
+			Kafka:   intOrDefault("TIMEOUT_KAFKA", 10),
+		},
+		Blob: Blob{
+			Driver:       os.Getenv("BLOB_DRIVER"),
+			LocalDir:     os.Getenv("BLOB_LOCAL_DIR"),
+			PublicUrl:    os.Getenv("BLOB_PUBLIC_URL"),
+			S3Endpoint:   os.Getenv("BLOB_S3_ENDPOINT"),
+			S3Region:     os.Getenv("BLOB_S3_REGION"),
+			S3Bucket:     os.Getenv("BLOB_S3_BUCKET"),
+			S3AccessKey:  os.Getenv("BLOB_S3_ACCESS_KEY"),
+			S3SecretKey:  os.Getenv("BLOB_S3_SECRET_KEY"),
+			UrlExpire:    intOrDefault("BLOB_URL_EXPIRE", 3600),
+			MaxImageSize: intOrDefault("BLOB_MAX_IMAGE_SIZE", 5<<20),
+		},
+		Locale: Locale{
+			Default: func() string {
+				if value := strings.ToLower(os.Getenv("LOCALE_DEFAULT")); value != "" {
+					return value
+				}
+				return "en"
+			}(),
+			Supported: splitList(strings.ToLower(os.Getenv("LOCALE_SUPPORTED"))),
+		},
+		Log: Log{
+			Level:  os.Getenv("LOG_LEVEL"),
+			Format: os.Getenv("LOG_FORMAT"),
+			ModuleLevels: func() map[string]string {
+				result := make(map[string]string)
+				for _, pair := range strings.Split(os.Getenv("LOG_MODULE_LEVELS"), ",") {
+					module, level, ok := strings.Cut(strings.TrimSpace(pair), "=")
+					if !ok {
+						continue
+					}
+					result[strings.TrimSpace(module)] = strings.TrimSpace(level)
+				}
+				return result
+			}(),
+		},
+	}
+}
+
+func intOrDefault(key string, defaultValue int64) int64 {
+	if os.Getenv(key) == "" {
+		return defaultValue
+	}
+	result, err := strconv.ParseInt(os.Getenv(key), 10, 64)
+	if err != nil {
+		log.Fatalf("Error loading %s failed", key)
+	}
+	return result
+}
+
+func splitList(value string) []string {
+	results := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			results = append(results, v)
+		}
 	}
+	return results
 }
@@ -7,6 +7,8 @@ import (
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/database"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/tracing"
 	"github.com/bonxatiwat/bonx-shop-tutorial/server"
 )
 
@@ -23,6 +25,16 @@ func main() {
 		return os.Args[1]
 	}())
 
+	// Logger
+	logger.Init(&cfg.Log, cfg.App.Name)
+
+	// Tracing
+	shutdownTracing, err := tracing.Init(ctx, &cfg.Tracing, cfg.App.Name)
+	if err != nil {
+		log.Fatalf("Error: %s", err.Error())
+	}
+	defer shutdownTracing(ctx)
+
 	// Database connection
 	db := database.DbConn(ctx, &cfg)
 	defer db.Disconnect(ctx)
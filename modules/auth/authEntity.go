@@ -10,7 +10,7 @@ type (
 	Credential struct {
 		Id           primitive.ObjectID `bson:"_id,omitempty"`
 		PlayerId     string             `bson:"player_id"`
-		RoleCode     int                `bson:"role_code"`
+		Roles        []string           `bson:"roles"`
 		AccessToken  string             `bson:"access_token"`
 		RefreshToken string             `bson:"refresh_token"`
 		CreatedAt    time.Time          `bson:"created_at"`
@@ -23,6 +23,38 @@ type (
 		Code  int                `json:"code" bson:"code"`
 	}
 
+	Identity struct {
+		Id        primitive.ObjectID `bson:"_id,omitempty"`
+		Provider  string             `bson:"provider"`
+		Subject   string             `bson:"subject"`
+		PlayerId  string             `bson:"player_id"`
+		Email     string             `bson:"email"`
+		CreatedAt time.Time          `bson:"created_at"`
+		UpdatedAt time.Time          `bson:"updated_at"`
+	}
+
+	OidcSession struct {
+		Id           primitive.ObjectID `bson:"_id,omitempty"`
+		Provider     string             `bson:"provider"`
+		State        string             `bson:"state"`
+		Nonce        string             `bson:"nonce"`
+		CodeVerifier string             `bson:"code_verifier"`
+		ExpiredAt    time.Time          `bson:"expired_at"`
+		CreatedAt    time.Time          `bson:"created_at"`
+	}
+
+	// LoginAttempt counts failed logins for one key, either "account:<email>" or "ip:<address>".
+	// An attempt is counted before its password is checked and handed back when it did not fail.
+	LoginAttempt struct {
+		Id            primitive.ObjectID `bson:"_id,omitempty"`
+		Key           string             `bson:"key"`
+		FailedCount   int                `bson:"failed_count"`
+		LastFailedAt  time.Time          `bson:"last_failed_at"`
+		NextAttemptAt time.Time          `bson:"next_attempt_at"`
+		LockedUntil   time.Time          `bson:"locked_until"`
+		ExpiredAt     time.Time          `bson:"expired_at"`
+	}
+
 	UpdateRefreshTokenReq struct {
 		PlayerId     string    `bson:"player_id"`
 		AccessToken  string    `bson:"access_token"`
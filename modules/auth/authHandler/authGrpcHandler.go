@@ -27,6 +27,6 @@ func (g *authGrpcHandler) AccessTokenSearch(ctx context.Context, req *authPb.Acc
 	)
 }
 
-func (g *authGrpcHandler) RolesCount(ctx context.Context, req *authPb.RolesCountReq) (*authPb.RolesCountRes, error) {
-	return g.authUsecase.RolesCount(ctx)
+func (g *authGrpcHandler) RevokePlayerCredentials(ctx context.Context, req *authPb.RevokePlayerCredentialsReq) (*authPb.RevokePlayerCredentialsRes, error) {
+	return g.authUsecase.RevokePlayerCredentials(ctx, req.PlayerId)
 }
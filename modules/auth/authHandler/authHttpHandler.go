@@ -1,7 +1,7 @@
 package authHandler
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -16,8 +16,12 @@ import (
 type (
 	AuthHttpHandlerService interface {
 		Login(c echo.Context) error
+		LoginMfa(c echo.Context) error
+		UnlockLogin(c echo.Context) error
 		RefreshToken(c echo.Context) error
 		Logout(c echo.Context) error
+		OidcLogin(c echo.Context) error
+		OidcCallback(c echo.Context) error
 	}
 
 	authHttpHandler struct {
@@ -31,7 +35,7 @@ func NewAuthHttpHandler(cfg *config.Config, authUsecase authUsecase.AuthUsecaseS
 }
 
 func (h *authHttpHandler) Login(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
@@ -41,16 +45,62 @@ func (h *authHttpHandler) Login(c echo.Context) error {
 		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
 
+	req.Ip = c.RealIP()
+
 	res, err := h.authUsecase.Login(ctx, h.cfg, req)
 	if err != nil {
+		if errors.Is(err, auth.ErrLoginLocked) {
+			return response.ErrorResponse(c, http.StatusTooManyRequests, err.Error())
+		}
 		return response.ErrorResponse(c, http.StatusUnauthorized, err.Error())
 	}
 
 	return response.SuccessResponse(c, http.StatusOK, res)
 }
 
+func (h *authHttpHandler) LoginMfa(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(auth.MfaLoginReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.authUsecase.LoginMfa(ctx, h.cfg, req)
+	if err != nil {
+		if errors.Is(err, auth.ErrLoginLocked) {
+			return response.ErrorResponse(c, http.StatusTooManyRequests, err.Error())
+		}
+		return response.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *authHttpHandler) UnlockLogin(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(auth.UnlockLoginReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.authUsecase.UnlockLogin(ctx, h.cfg, c.Get("player_id").(string), req)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
 func (h *authHttpHandler) RefreshToken(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
@@ -69,7 +119,7 @@ func (h *authHttpHandler) RefreshToken(c echo.Context) error {
 }
 
 func (h *authHttpHandler) Logout(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
@@ -88,3 +138,39 @@ func (h *authHttpHandler) Logout(c echo.Context) error {
 		Message: fmt.Sprintf("Delete count: %d", res),
 	})
 }
+
+func (h *authHttpHandler) OidcLogin(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	authUrl, err := h.authUsecase.OidcLogin(ctx, h.cfg, c.Param("provider"))
+	if err != nil {
+		if errors.Is(err, auth.ErrLoginUnavailable) {
+			return response.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return c.Redirect(http.StatusFound, authUrl)
+}
+
+func (h *authHttpHandler) OidcCallback(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(auth.OidcCallbackReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.authUsecase.OidcCallback(ctx, h.cfg, c.Param("provider"), req)
+	if err != nil {
+		if errors.Is(err, auth.ErrLoginUnavailable) {
+			return response.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+		return response.ErrorResponse(c, http.StatusUnauthorized, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
@@ -1,15 +1,19 @@
 package auth
 
 import (
+	"errors"
 	"time"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
+	"google.golang.org/grpc/codes"
 )
 
 type (
 	PlayerLoginReq struct {
 		Email    string `json:"email" form:"email" validate:"required,email,max=255"`
 		Password string `json:"password" form:"password" validate:"required,max=32"`
+		Ip       string `json:"-" form:"-"`
 	}
 
 	RefreshTokenReq struct {
@@ -22,22 +26,74 @@ type (
 		RoleCode []int  `json:"role_id" validate:"required"`
 	}
 
+	MfaLoginReq struct {
+		ChallengeToken string `json:"challenge_token" form:"challenge_token" validate:"required,max=1000"`
+		Code           string `json:"code" form:"code" validate:"required,max=16"`
+	}
+
 	ProfileIntercepter struct {
 		*player.PlayerProfile
 		Credential *CredentialRes `json:"credential"`
+		Mfa        *MfaRes        `json:"mfa,omitempty"`
+	}
+
+	MfaRes struct {
+		// Required means the credential is withheld until the challenge is completed
+		Required       bool       `json:"required"`
+		ChallengeToken string     `json:"challenge_token,omitempty"`
+		ExpiredAt      *time.Time `json:"expired_at,omitempty"`
+		// EnrollmentRequired means the roles carry admin permissions that stay disabled until mfa is enabled
+		EnrollmentRequired bool `json:"enrollment_required,omitempty"`
 	}
 
 	CredentialRes struct {
 		Id           string    `json:"_id"`
 		PlayerId     string    `json:"player_id"`
-		RoleCode     int       `json:"role_code"`
+		Roles        []string  `json:"roles"`
+		Permissions  []string  `json:"permissions"`
 		AccessToken  string    `json:"access_token"`
 		RefreshToken string    `json:"refresh_token"`
 		CreatedAt    time.Time `json:"created_at"`
 		UpdatedAt    time.Time `json:"updated_at"`
 	}
 
+	OidcCallbackReq struct {
+		Code             string `query:"code" validate:"max=2048"`
+		State            string `query:"state" validate:"required,max=128"`
+		Error            string `query:"error" validate:"max=255"`
+		ErrorDescription string `query:"error_description" validate:"max=1024"`
+	}
+
+	UnlockLoginReq struct {
+		Email string `json:"email" form:"email" validate:"omitempty,email,max=255"`
+		Ip    string `json:"ip" form:"ip" validate:"omitempty,ip"`
+	}
+
+	UnlockLoginRes struct {
+		UnlockedCount int64 `json:"unlocked_count"`
+	}
+
+	LockoutEvent struct {
+		Action      string    `json:"action"`
+		Key         string    `json:"key"`
+		Ip          string    `json:"ip,omitempty"`
+		FailedCount int       `json:"failed_count,omitempty"`
+		LockedUntil time.Time `json:"locked_until"`
+		ActorId     string    `json:"actor_id,omitempty"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+
 	LogoutReq struct {
 		CredentialId string `json:"credential_id" form:"credential_id" validate:"required,max=64"`
 	}
 )
+
+var (
+	ErrInvalidCredential = errors.New("error: email or password is incorrect")
+	ErrLoginLocked       = errors.New("error: too many failed login attempts, try again later")
+	ErrLoginUnavailable  = errors.New("error: login is unavailable, try again later")
+
+	// The errors other services act on, their status does not depend on the message
+	ErrAccessTokenNotFound = grpccon.NewError(codes.NotFound, "NOT_FOUND", "error: access token not found")
+	ErrAccessTokenInvalid  = grpccon.NewError(codes.InvalidArgument, "INVALID_ARGUMENT", "error: access token is invalid")
+)
@@ -117,14 +117,16 @@ func (x *AccessTokenSearchRes) GetIsValid() bool {
 	return false
 }
 
-type RolesCountReq struct {
+type RevokePlayerCredentialsReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	PlayerId string `protobuf:"bytes,1,opt,name=playerId,proto3" json:"playerId,omitempty"`
 }
 
-func (x *RolesCountReq) Reset() {
-	*x = RolesCountReq{}
+func (x *RevokePlayerCredentialsReq) Reset() {
+	*x = RevokePlayerCredentialsReq{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_modules_auth_authPb_authPb_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -132,13 +134,13 @@ func (x *RolesCountReq) Reset() {
 	}
 }
 
-func (x *RolesCountReq) String() string {
+func (x *RevokePlayerCredentialsReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RolesCountReq) ProtoMessage() {}
+func (*RevokePlayerCredentialsReq) ProtoMessage() {}
 
-func (x *RolesCountReq) ProtoReflect() protoreflect.Message {
+func (x *RevokePlayerCredentialsReq) ProtoReflect() protoreflect.Message {
 	mi := &file_modules_auth_authPb_authPb_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -150,21 +152,28 @@ func (x *RolesCountReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RolesCountReq.ProtoReflect.Descriptor instead.
-func (*RolesCountReq) Descriptor() ([]byte, []int) {
+// Deprecated: Use RevokePlayerCredentialsReq.ProtoReflect.Descriptor instead.
+func (*RevokePlayerCredentialsReq) Descriptor() ([]byte, []int) {
 	return file_modules_auth_authPb_authPb_proto_rawDescGZIP(), []int{2}
 }
 
-type RolesCountRes struct {
+func (x *RevokePlayerCredentialsReq) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+type RevokePlayerCredentialsRes struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Count int64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	RevokedCount int64 `protobuf:"varint,1,opt,name=revokedCount,proto3" json:"revokedCount,omitempty"`
 }
 
-func (x *RolesCountRes) Reset() {
-	*x = RolesCountRes{}
+func (x *RevokePlayerCredentialsRes) Reset() {
+	*x = RevokePlayerCredentialsRes{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_modules_auth_authPb_authPb_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -172,13 +181,13 @@ func (x *RolesCountRes) Reset() {
 	}
 }
 
-func (x *RolesCountRes) String() string {
+func (x *RevokePlayerCredentialsRes) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RolesCountRes) ProtoMessage() {}
+func (*RevokePlayerCredentialsRes) ProtoMessage() {}
 
-func (x *RolesCountRes) ProtoReflect() protoreflect.Message {
+func (x *RevokePlayerCredentialsRes) ProtoReflect() protoreflect.Message {
 	mi := &file_modules_auth_authPb_authPb_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -190,14 +199,14 @@ func (x *RolesCountRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RolesCountRes.ProtoReflect.Descriptor instead.
-func (*RolesCountRes) Descriptor() ([]byte, []int) {
+// Deprecated: Use RevokePlayerCredentialsRes.ProtoReflect.Descriptor instead.
+func (*RevokePlayerCredentialsRes) Descriptor() ([]byte, []int) {
 	return file_modules_auth_authPb_authPb_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *RolesCountRes) GetCount() int64 {
+func (x *RevokePlayerCredentialsRes) GetRevokedCount() int64 {
 	if x != nil {
-		return x.Count
+		return x.RevokedCount
 	}
 	return 0
 }
@@ -213,22 +222,29 @@ var file_modules_auth_authPb_authPb_proto_rawDesc = []byte{
 	0x0b, 0x61, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x30, 0x0a, 0x14,
 	0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x53, 0x65, 0x61, 0x72, 0x63,
 	0x68, 0x52, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x73, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69, 0x73, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x22, 0x0f,
-	0x0a, 0x0d, 0x52, 0x6f, 0x6c, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x22,
-	0x25, 0x0a, 0x0d, 0x52, 0x6f, 0x6c, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x73,
-	0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x32, 0x82, 0x01, 0x0a, 0x0f, 0x41, 0x75, 0x74, 0x68, 0x47,
-	0x72, 0x70, 0x63, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x41, 0x0a, 0x11, 0x41, 0x63,
-	0x63, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x12,
-	0x15, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x53, 0x65, 0x61,
-	0x72, 0x63, 0x68, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54,
-	0x6f, 0x6b, 0x65, 0x6e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x73, 0x12, 0x2c, 0x0a,
-	0x0a, 0x52, 0x6f, 0x6c, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x0e, 0x2e, 0x52, 0x6f,
-	0x6c, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x1a, 0x0e, 0x2e, 0x52, 0x6f,
-	0x6c, 0x65, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x73, 0x42, 0x2a, 0x5a, 0x28, 0x67,
-	0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62, 0x6f, 0x6e, 0x78, 0x61, 0x74,
-	0x69, 0x77, 0x61, 0x74, 0x2f, 0x62, 0x6f, 0x6e, 0x78, 0x2d, 0x73, 0x68, 0x6f, 0x70, 0x2d, 0x74,
-	0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69, 0x73, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x22, 0x38,
+	0x0a, 0x1a, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x72,
+	0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x12, 0x1a, 0x0a, 0x08,
+	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x22, 0x40, 0x0a, 0x1a, 0x52, 0x65, 0x76, 0x6f,
+	0x6b, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x61, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x12, 0x22, 0x0a, 0x0c, 0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65,
+	0x64, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x72, 0x65,
+	0x76, 0x6f, 0x6b, 0x65, 0x64, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x32, 0xa9, 0x01, 0x0a, 0x0f, 0x41,
+	0x75, 0x74, 0x68, 0x47, 0x72, 0x70, 0x63, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x41,
+	0x0a, 0x11, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x53, 0x65, 0x61,
+	0x72, 0x63, 0x68, 0x12, 0x15, 0x2e, 0x41, 0x63, 0x63, 0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65,
+	0x6e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x71, 0x1a, 0x15, 0x2e, 0x41, 0x63, 0x63,
+	0x65, 0x73, 0x73, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65,
+	0x73, 0x12, 0x53, 0x0a, 0x17, 0x52, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65,
+	0x72, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x12, 0x1b, 0x2e, 0x52,
+	0x65, 0x76, 0x6f, 0x6b, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x72, 0x65, 0x64, 0x65,
+	0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x1b, 0x2e, 0x52, 0x65, 0x76, 0x6f,
+	0x6b, 0x65, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
+	0x61, 0x6c, 0x73, 0x52, 0x65, 0x73, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62,
+	0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62, 0x6f, 0x6e, 0x78, 0x61, 0x74, 0x69, 0x77, 0x61, 0x74, 0x2f,
+	0x62, 0x6f, 0x6e, 0x78, 0x2d, 0x73, 0x68, 0x6f, 0x70, 0x2d, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69,
+	0x61, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -245,16 +261,16 @@ func file_modules_auth_authPb_authPb_proto_rawDescGZIP() []byte {
 
 var file_modules_auth_authPb_authPb_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_modules_auth_authPb_authPb_proto_goTypes = []interface{}{
-	(*AccessTokenSearchReq)(nil), // 0: AccessTokenSearchReq
-	(*AccessTokenSearchRes)(nil), // 1: AccessTokenSearchRes
-	(*RolesCountReq)(nil),        // 2: RolesCountReq
-	(*RolesCountRes)(nil),        // 3: RolesCountRes
+	(*AccessTokenSearchReq)(nil),       // 0: AccessTokenSearchReq
+	(*AccessTokenSearchRes)(nil),       // 1: AccessTokenSearchRes
+	(*RevokePlayerCredentialsReq)(nil), // 2: RevokePlayerCredentialsReq
+	(*RevokePlayerCredentialsRes)(nil), // 3: RevokePlayerCredentialsRes
 }
 var file_modules_auth_authPb_authPb_proto_depIdxs = []int32{
 	0, // 0: AuthGrpcService.AccessTokenSearch:input_type -> AccessTokenSearchReq
-	2, // 1: AuthGrpcService.RolesCount:input_type -> RolesCountReq
+	2, // 1: AuthGrpcService.RevokePlayerCredentials:input_type -> RevokePlayerCredentialsReq
 	1, // 2: AuthGrpcService.AccessTokenSearch:output_type -> AccessTokenSearchRes
-	3, // 3: AuthGrpcService.RolesCount:output_type -> RolesCountRes
+	3, // 3: AuthGrpcService.RevokePlayerCredentials:output_type -> RevokePlayerCredentialsRes
 	2, // [2:4] is the sub-list for method output_type
 	0, // [0:2] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
@@ -293,7 +309,7 @@ func file_modules_auth_authPb_authPb_proto_init() {
 			}
 		}
 		file_modules_auth_authPb_authPb_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RolesCountReq); i {
+			switch v := v.(*RevokePlayerCredentialsReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -305,7 +321,7 @@ func file_modules_auth_authPb_authPb_proto_init() {
 			}
 		}
 		file_modules_auth_authPb_authPb_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RolesCountRes); i {
+			switch v := v.(*RevokePlayerCredentialsRes); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -0,0 +1,19 @@
+package bonx_shop_tutorial
+
+import "errors"
+
+// Validate methods are called by the grpccon validation interceptor before the handler runs.
+
+func (x *AccessTokenSearchReq) Validate() error {
+	if x.GetAccessToken() == "" {
+		return errors.New("error: access token is required")
+	}
+	return nil
+}
+
+func (x *RevokePlayerCredentialsReq) Validate() error {
+	if x.GetPlayerId() == "" {
+		return errors.New("error: player id is required")
+	}
+	return nil
+}
@@ -23,7 +23,7 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AuthGrpcServiceClient interface {
 	AccessTokenSearch(ctx context.Context, in *AccessTokenSearchReq, opts ...grpc.CallOption) (*AccessTokenSearchRes, error)
-	RolesCount(ctx context.Context, in *RolesCountReq, opts ...grpc.CallOption) (*RolesCountRes, error)
+	RevokePlayerCredentials(ctx context.Context, in *RevokePlayerCredentialsReq, opts ...grpc.CallOption) (*RevokePlayerCredentialsRes, error)
 }
 
 type authGrpcServiceClient struct {
@@ -43,9 +43,9 @@ func (c *authGrpcServiceClient) AccessTokenSearch(ctx context.Context, in *Acces
 	return out, nil
 }
 
-func (c *authGrpcServiceClient) RolesCount(ctx context.Context, in *RolesCountReq, opts ...grpc.CallOption) (*RolesCountRes, error) {
-	out := new(RolesCountRes)
-	err := c.cc.Invoke(ctx, "/AuthGrpcService/RolesCount", in, out, opts...)
+func (c *authGrpcServiceClient) RevokePlayerCredentials(ctx context.Context, in *RevokePlayerCredentialsReq, opts ...grpc.CallOption) (*RevokePlayerCredentialsRes, error) {
+	out := new(RevokePlayerCredentialsRes)
+	err := c.cc.Invoke(ctx, "/AuthGrpcService/RevokePlayerCredentials", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -57,7 +57,7 @@ func (c *authGrpcServiceClient) RolesCount(ctx context.Context, in *RolesCountRe
 // for forward compatibility
 type AuthGrpcServiceServer interface {
 	AccessTokenSearch(context.Context, *AccessTokenSearchReq) (*AccessTokenSearchRes, error)
-	RolesCount(context.Context, *RolesCountReq) (*RolesCountRes, error)
+	RevokePlayerCredentials(context.Context, *RevokePlayerCredentialsReq) (*RevokePlayerCredentialsRes, error)
 	mustEmbedUnimplementedAuthGrpcServiceServer()
 }
 
@@ -68,8 +68,8 @@ type UnimplementedAuthGrpcServiceServer struct {
 func (UnimplementedAuthGrpcServiceServer) AccessTokenSearch(context.Context, *AccessTokenSearchReq) (*AccessTokenSearchRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AccessTokenSearch not implemented")
 }
-func (UnimplementedAuthGrpcServiceServer) RolesCount(context.Context, *RolesCountReq) (*RolesCountRes, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method RolesCount not implemented")
+func (UnimplementedAuthGrpcServiceServer) RevokePlayerCredentials(context.Context, *RevokePlayerCredentialsReq) (*RevokePlayerCredentialsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokePlayerCredentials not implemented")
 }
 func (UnimplementedAuthGrpcServiceServer) mustEmbedUnimplementedAuthGrpcServiceServer() {}
 
@@ -102,20 +102,20 @@ func _AuthGrpcService_AccessTokenSearch_Handler(srv interface{}, ctx context.Con
 	return interceptor(ctx, in, info, handler)
 }
 
-func _AuthGrpcService_RolesCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(RolesCountReq)
+func _AuthGrpcService_RevokePlayerCredentials_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokePlayerCredentialsReq)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(AuthGrpcServiceServer).RolesCount(ctx, in)
+		return srv.(AuthGrpcServiceServer).RevokePlayerCredentials(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/AuthGrpcService/RolesCount",
+		FullMethod: "/AuthGrpcService/RevokePlayerCredentials",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(AuthGrpcServiceServer).RolesCount(ctx, req.(*RolesCountReq))
+		return srv.(AuthGrpcServiceServer).RevokePlayerCredentials(ctx, req.(*RevokePlayerCredentialsReq))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -132,8 +132,8 @@ var AuthGrpcService_ServiceDesc = grpc.ServiceDesc{
 			Handler:    _AuthGrpcService_AccessTokenSearch_Handler,
 		},
 		{
-			MethodName: "RolesCount",
-			Handler:    _AuthGrpcService_RolesCount_Handler,
+			MethodName: "RevokePlayerCredentials",
+			Handler:    _AuthGrpcService_RevokePlayerCredentials_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
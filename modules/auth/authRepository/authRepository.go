@@ -2,19 +2,27 @@ package authRepository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/auth"
 	playerPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/player/playerPb"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/deadline"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/oidc"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/queue"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
-	"github.com/labstack/gommon/log"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type (
@@ -27,15 +35,31 @@ type (
 		FindOnePlayerProfileToRefresh(pctx context.Context, grpcUrl string, req *playerPb.FindOnePlayerProfileToRefreshReq) (*playerPb.PlayerProfile, error)
 		UpdateOnePlayerCredential(pctx context.Context, credentialId string, req *auth.UpdateRefreshTokenReq) error
 		DeleteOnePlayerCredential(pctx context.Context, credentialId string) (int64, error)
+		DeleteManyPlayerCredentials(pctx context.Context, playerId string) (int64, error)
 		FindOneAccessToken(pctx context.Context, accessToken string) (*auth.Credential, error)
-		RolesCount(pctx context.Context) (int64, error)
+		ProvisionPlayer(pctx context.Context, grpcUrl string, req *playerPb.ProvisionPlayerReq) (*playerPb.PlayerProfile, error)
+		VerifyMfaCode(pctx context.Context, grpcUrl string, req *playerPb.VerifyMfaCodeReq) (*playerPb.VerifyMfaCodeRes, error)
+		MfaChallengeToken(cfg *config.Config, claims *jwtauth.Claims) string
+		InsertOneOidcSession(pctx context.Context, req *auth.OidcSession) error
+		FindAndDeleteOneOidcSession(pctx context.Context, provider, state string) (*auth.OidcSession, error)
+		FindOneIdentity(pctx context.Context, provider, subject string) (*auth.Identity, error)
+		InsertOneIdentity(pctx context.Context, req *auth.Identity) error
+		OidcProvider(pctx context.Context, cfg *config.Config, name string) (oidc.ProviderService, error)
+		IncreaseLoginAttempt(pctx context.Context, key string, maxAttempts int, expiredAt time.Time) (*auth.LoginAttempt, error)
+		DecreaseLoginAttempt(pctx context.Context, key string) error
+		UpdateOneLoginAttemptDelay(pctx context.Context, key string, nextAttemptAt, lockedUntil time.Time) error
+		DeleteManyLoginAttempts(pctx context.Context, keys []string) (int64, error)
+		LockoutEvent(pctx context.Context, cfg *config.Config, req *auth.LockoutEvent) error
 	}
 
 	authRepository struct {
-		db *mongo.Client
+		db            *mongo.Client
+		oidcProviders sync.Map
 	}
 )
 
+var authLog = logger.New("auth")
+
 func NewAuthRepository(db *mongo.Client) AuthRepositoryService {
 	return &authRepository{db: db}
 }
@@ -45,20 +69,24 @@ func (r *authRepository) authDbConn(pctx context.Context) *mongo.Database {
 }
 
 func (r *authRepository) CredentialSearch(pctx context.Context, grpcUrl string, req *playerPb.CredentialSearchReq) (*playerPb.PlayerProfile, error) {
-	ctx, cancel := context.WithTimeout(pctx, 30*time.Second)
+	ctx, cancel := deadline.Grpc(pctx)
 	defer cancel()
 
 	jwtauth.SetApiKeyInContext(&ctx)
 	conn, err := grpccon.NewGrpcClient(grpcUrl)
 	if err != nil {
-		log.Printf("Error: gRPC connection failed: %s", err.Error())
+		authLog.Error(ctx, "gRPC connection failed", "error", err)
 		return nil, errors.New("error: gRPC connection failed")
 	}
 
 	result, err := conn.Player().CredentialSearch(ctx, req)
 	if err != nil {
-		log.Printf("Error: CredentialSearch failed: %s", err.Error())
-		return nil, errors.New("error: email or password is incorrect")
+		authLog.Error(ctx, "CredentialSearch failed", "error", err)
+		// An unreachable player service must not count as a failed login attempt
+		if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded {
+			return nil, errors.New("error: player service is unavailable")
+		}
+		return nil, auth.ErrInvalidCredential
 	}
 
 	return result, nil
@@ -66,19 +94,19 @@ func (r *authRepository) CredentialSearch(pctx context.Context, grpcUrl string,
 }
 
 func (r *authRepository) FindOnePlayerProfileToRefresh(pctx context.Context, grpcUrl string, req *playerPb.FindOnePlayerProfileToRefreshReq) (*playerPb.PlayerProfile, error) {
-	ctx, cancel := context.WithTimeout(pctx, 30*time.Second)
+	ctx, cancel := deadline.Grpc(pctx)
 	defer cancel()
 
 	jwtauth.SetApiKeyInContext(&ctx)
 	conn, err := grpccon.NewGrpcClient(grpcUrl)
 	if err != nil {
-		log.Printf("Error: gRPC connection failed: %s", err.Error())
+		authLog.Error(ctx, "gRPC connection failed", "error", err)
 		return nil, errors.New("error: gRPC connection failed")
 	}
 
 	result, err := conn.Player().FindOnePlayerProfileToRefresh(ctx, req)
 	if err != nil {
-		log.Printf("Error: FindOnePlayerProfileToRefresh failed: %s", err.Error())
+		authLog.Error(ctx, "FindOnePlayerProfileToRefresh failed", "error", err)
 		return nil, errors.New("error: player profile not found")
 	}
 
@@ -87,7 +115,7 @@ func (r *authRepository) FindOnePlayerProfileToRefresh(pctx context.Context, grp
 }
 
 func (r *authRepository) InsertOnePlayerCredential(pctx context.Context, req *auth.Credential) (primitive.ObjectID, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.authDbConn(ctx)
@@ -95,7 +123,7 @@ func (r *authRepository) InsertOnePlayerCredential(pctx context.Context, req *au
 
 	result, err := col.InsertOne(ctx, req)
 	if err != nil {
-		log.Printf("Error: InsertOnePlayerCredential failed: %s", err.Error())
+		authLog.Error(ctx, "InsertOnePlayerCredential failed", "error", err)
 
 		return primitive.NilObjectID, errors.New("error: insert one player credential failed")
 	}
@@ -104,7 +132,7 @@ func (r *authRepository) InsertOnePlayerCredential(pctx context.Context, req *au
 }
 
 func (r *authRepository) FindOnePlayerCredential(pctx context.Context, credentialId string) (*auth.Credential, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.authDbConn(ctx)
@@ -113,7 +141,7 @@ func (r *authRepository) FindOnePlayerCredential(pctx context.Context, credentia
 	result := new(auth.Credential)
 
 	if err := col.FindOne(ctx, bson.M{"_id": utils.ConvertToObjectId(credentialId)}).Decode(result); err != nil {
-		log.Printf("Error: FindOnePlayerCredential failed: %s", err.Error())
+		authLog.Error(ctx, "FindOnePlayerCredential failed", "error", err)
 		return nil, errors.New("error: find one player credential failed")
 	}
 
@@ -122,20 +150,31 @@ func (r *authRepository) FindOnePlayerCredential(pctx context.Context, credentia
 
 func (r *authRepository) AccessToken(cfg *config.Config, claims *jwtauth.Claims) string {
 	return jwtauth.NewAccessToken(cfg.Jwt.AccessSecretKey, cfg.Jwt.AccessDuration, &jwtauth.Claims{
+		PlayerId:    claims.PlayerId,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Mfa:         claims.Mfa,
+	}).SignToken()
+}
+
+// MfaChallengeToken lives for five minutes, enough to open an authenticator app.
+func (r *authRepository) MfaChallengeToken(cfg *config.Config, claims *jwtauth.Claims) string {
+	return jwtauth.NewMfaChallengeToken(cfg.Jwt.AccessSecretKey, 300, &jwtauth.Claims{
 		PlayerId: claims.PlayerId,
-		RoleCode: int(claims.RoleCode),
 	}).SignToken()
 }
 
 func (r *authRepository) RefreshToken(cfg *config.Config, claims *jwtauth.Claims) string {
 	return jwtauth.NewRefreshToken(cfg.Jwt.RefreshSecretKey, cfg.Jwt.RefreshDuration, &jwtauth.Claims{
-		PlayerId: claims.PlayerId,
-		RoleCode: int(claims.RoleCode),
+		PlayerId:    claims.PlayerId,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		Mfa:         claims.Mfa,
 	}).SignToken()
 }
 
 func (r *authRepository) UpdateOnePlayerCredential(pctx context.Context, credentialId string, req *auth.UpdateRefreshTokenReq) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.authDbConn(ctx)
@@ -154,7 +193,7 @@ func (r *authRepository) UpdateOnePlayerCredential(pctx context.Context, credent
 		},
 	)
 	if err != nil {
-		log.Printf("Error: UpdateOnePlayerCredential failed: %s", err.Error())
+		authLog.Error(ctx, "UpdateOnePlayerCredential failed", "error", err)
 		return errors.New("error: player credential not found")
 	}
 
@@ -162,7 +201,7 @@ func (r *authRepository) UpdateOnePlayerCredential(pctx context.Context, credent
 }
 
 func (r *authRepository) DeleteOnePlayerCredential(pctx context.Context, credentialId string) (int64, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.authDbConn(ctx)
@@ -170,15 +209,30 @@ func (r *authRepository) DeleteOnePlayerCredential(pctx context.Context, credent
 
 	result, err := col.DeleteOne(ctx, bson.M{"_id": utils.ConvertToObjectId(credentialId)})
 	if err != nil {
-		log.Printf("Error: DeleteOnePlayerCredential failed: %s", err.Error())
+		authLog.Error(ctx, "DeleteOnePlayerCredential failed", "error", err)
 		return -1, errors.New("error: delete one player credential failed")
 	}
-	log.Printf("DeleteOnePlayerCredential result: %d", result)
+	authLog.Debug(ctx, "DeleteOnePlayerCredential", "deleted_count", result.DeletedCount)
+	return result.DeletedCount, nil
+}
+
+func (r *authRepository) DeleteManyPlayerCredentials(pctx context.Context, playerId string) (int64, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.authDbConn(ctx)
+	col := db.Collection("auth")
+
+	result, err := col.DeleteMany(ctx, bson.M{"player_id": playerId})
+	if err != nil {
+		authLog.Error(ctx, "DeleteManyPlayerCredentials failed", "error", err)
+		return -1, errors.New("error: delete player credentials failed")
+	}
 	return result.DeletedCount, nil
 }
 
 func (r *authRepository) FindOneAccessToken(pctx context.Context, accessToken string) (*auth.Credential, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.authDbConn(ctx)
@@ -187,24 +241,265 @@ func (r *authRepository) FindOneAccessToken(pctx context.Context, accessToken st
 	credential := new(auth.Credential)
 
 	if err := col.FindOne(ctx, bson.M{"access_token": accessToken}).Decode(credential); err != nil {
-		log.Printf("Error: FindOneAccessToken: %s", err.Error())
-		return nil, errors.New("error: access token not found")
+		authLog.Error(ctx, "FindOneAccessToken", "error", err)
+		return nil, auth.ErrAccessTokenNotFound
 	}
 
 	return credential, nil
 }
 
-func (r *authRepository) RolesCount(pctx context.Context) (int64, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+func (r *authRepository) ProvisionPlayer(pctx context.Context, grpcUrl string, req *playerPb.ProvisionPlayerReq) (*playerPb.PlayerProfile, error) {
+	ctx, cancel := deadline.Grpc(pctx)
+	defer cancel()
+
+	jwtauth.SetApiKeyInContext(&ctx)
+	conn, err := grpccon.NewGrpcClient(grpcUrl)
+	if err != nil {
+		authLog.Error(ctx, "gRPC connection failed", "error", err)
+		return nil, errors.New("error: gRPC connection failed")
+	}
+
+	result, err := conn.Player().ProvisionPlayer(ctx, req)
+	if err != nil {
+		authLog.Error(ctx, "ProvisionPlayer failed", "error", err)
+		return nil, errors.New("error: provision player failed")
+	}
+
+	return result, nil
+}
+
+func (r *authRepository) VerifyMfaCode(pctx context.Context, grpcUrl string, req *playerPb.VerifyMfaCodeReq) (*playerPb.VerifyMfaCodeRes, error) {
+	ctx, cancel := deadline.Grpc(pctx)
+	defer cancel()
+
+	jwtauth.SetApiKeyInContext(&ctx)
+	conn, err := grpccon.NewGrpcClient(grpcUrl)
+	if err != nil {
+		authLog.Error(ctx, "gRPC connection failed", "error", err)
+		return nil, errors.New("error: gRPC connection failed")
+	}
+
+	result, err := conn.Player().VerifyMfaCode(ctx, req)
+	if err != nil {
+		authLog.Error(ctx, "VerifyMfaCode failed", "error", err)
+		return nil, errors.New("error: mfa code is invalid")
+	}
+
+	return result, nil
+}
+
+func (r *authRepository) InsertOneOidcSession(pctx context.Context, req *auth.OidcSession) error {
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.authDbConn(ctx)
-	col := db.Collection("roles")
+	col := db.Collection("oidc_sessions")
+
+	if _, err := col.InsertOne(ctx, req); err != nil {
+		authLog.Error(ctx, "InsertOneOidcSession failed", "error", err)
+		return errors.New("error: insert one oidc session failed")
+	}
+
+	return nil
+}
 
-	count, err := col.CountDocuments(ctx, bson.M{})
+// FindAndDeleteOneOidcSession consumes the session, so every state can only be used once.
+func (r *authRepository) FindAndDeleteOneOidcSession(pctx context.Context, provider, state string) (*auth.OidcSession, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.authDbConn(ctx)
+	col := db.Collection("oidc_sessions")
+
+	result := new(auth.OidcSession)
+	if err := col.FindOneAndDelete(ctx, bson.M{"provider": provider, "state": state}).Decode(result); err != nil {
+		authLog.Error(ctx, "FindAndDeleteOneOidcSession failed", "error", err)
+		return nil, errors.New("error: oidc session not found")
+	}
+
+	return result, nil
+}
+
+// FindOneIdentity returns nil without an error when the identity is not linked yet.
+func (r *authRepository) FindOneIdentity(pctx context.Context, provider, subject string) (*auth.Identity, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.authDbConn(ctx)
+	col := db.Collection("identities")
+
+	result := new(auth.Identity)
+	if err := col.FindOne(ctx, bson.M{"provider": provider, "subject": subject}).Decode(result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		authLog.Error(ctx, "FindOneIdentity failed", "error", err)
+		return nil, errors.New("error: find identity failed")
+	}
+
+	return result, nil
+}
+
+func (r *authRepository) InsertOneIdentity(pctx context.Context, req *auth.Identity) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.authDbConn(ctx)
+	col := db.Collection("identities")
+
+	if _, err := col.InsertOne(ctx, req); err != nil {
+		authLog.Error(ctx, "InsertOneIdentity failed", "error", err)
+		return errors.New("error: insert one identity failed")
+	}
+
+	return nil
+}
+
+func (r *authRepository) OidcProvider(pctx context.Context, cfg *config.Config, name string) (oidc.ProviderService, error) {
+	if p, ok := r.oidcProviders.Load(name); ok {
+		return p.(oidc.ProviderService), nil
+	}
+
+	for i := range cfg.Oidc.Providers {
+		if cfg.Oidc.Providers[i].Name == name {
+			p, _ := r.oidcProviders.LoadOrStore(name, oidc.NewProvider(&cfg.Oidc.Providers[i]))
+			return p.(oidc.ProviderService), nil
+		}
+	}
+
+	authLog.Error(pctx, "OidcProvider is not configured", "provider", name)
+	return nil, errors.New("error: oidc provider not found")
+}
+
+// IncreaseLoginAttempt counts one more attempt and returns the updated counter. The key must be
+// below maxAttempts, not locked and not waiting out its delay, checked in the same update. A key
+// that is not matches nothing, and the upsert then collides with it on the unique key.
+func (r *authRepository) IncreaseLoginAttempt(pctx context.Context, key string, maxAttempts int, expiredAt time.Time) (*auth.LoginAttempt, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.authDbConn(ctx)
+	col := db.Collection("login_attempts")
+
+	now := utils.LocalTime()
+	filter := bson.M{
+		"key":             key,
+		"failed_count":    bson.M{"$lt": maxAttempts},
+		"locked_until":    bson.M{"$not": bson.M{"$gt": now}},
+		"next_attempt_at": bson.M{"$not": bson.M{"$gt": now}},
+	}
+	update := bson.M{
+		"$inc": bson.M{"failed_count": 1},
+		"$set": bson.M{"expired_at": expiredAt},
+	}
+
+	result := new(auth.LoginAttempt)
+	// The first attempts on a new key race to insert it, the loser collides once and matches on its second try
+	for try := 1; ; try++ {
+		err := col.FindOneAndUpdate(
+			ctx,
+			filter,
+			update,
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(result)
+		if err == nil {
+			return result, nil
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			if try < 2 {
+				continue
+			}
+			return nil, auth.ErrLoginLocked
+		}
+		authLog.Error(ctx, "IncreaseLoginAttempt failed", "error", err)
+		return nil, errors.New("error: increase login attempt failed")
+	}
+}
+
+// DecreaseLoginAttempt hands back an attempt that did not fail. A lock that started the count
+// over in the meantime keeps it at 0.
+func (r *authRepository) DecreaseLoginAttempt(pctx context.Context, key string) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.authDbConn(ctx)
+	col := db.Collection("login_attempts")
+
+	if _, err := col.UpdateOne(
+		ctx,
+		bson.M{"key": key, "failed_count": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"failed_count": -1}},
+	); err != nil {
+		authLog.Error(ctx, "DecreaseLoginAttempt failed", "error", err)
+		return errors.New("error: decrease login attempt failed")
+	}
+
+	return nil
+}
+
+// UpdateOneLoginAttemptDelay records a failure. Failures of a burst land in any order, so the
+// delay only ever moves forward and only a lock touches locked_until. A lock starts the count
+// over, once it runs out the key gets its attempts back.
+func (r *authRepository) UpdateOneLoginAttemptDelay(pctx context.Context, key string, nextAttemptAt, lockedUntil time.Time) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.authDbConn(ctx)
+	col := db.Collection("login_attempts")
+
+	set := bson.M{"last_failed_at": utils.LocalTime()}
+	if !lockedUntil.IsZero() {
+		set["locked_until"] = lockedUntil
+		set["failed_count"] = 0
+	}
+
+	if _, err := col.UpdateOne(
+		ctx,
+		bson.M{"key": key},
+		bson.M{"$max": bson.M{"next_attempt_at": nextAttemptAt}, "$set": set},
+	); err != nil {
+		authLog.Error(ctx, "UpdateOneLoginAttemptDelay failed", "error", err)
+		return errors.New("error: update login attempt failed")
+	}
+
+	return nil
+}
+
+func (r *authRepository) DeleteManyLoginAttempts(pctx context.Context, keys []string) (int64, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.authDbConn(ctx)
+	col := db.Collection("login_attempts")
+
+	result, err := col.DeleteMany(ctx, bson.M{"key": bson.M{"$in": keys}})
 	if err != nil {
-		log.Printf("Error: RolesCount failed: %s", err.Error())
-		return -1, errors.New("error: role count failed")
+		authLog.Error(ctx, "DeleteManyLoginAttempts failed", "error", err)
+		return -1, errors.New("error: delete many login attempts failed")
 	}
-	return count, nil
+
+	return result.DeletedCount, nil
+}
+
+func (r *authRepository) LockoutEvent(pctx context.Context, cfg *config.Config, req *auth.LockoutEvent) error {
+	reqInBytes, err := json.Marshal(req)
+	if err != nil {
+		authLog.Error(pctx, "LockoutEvent failed", "error", err)
+		return errors.New("error: lockout event failed")
+	}
+
+	if err := queue.PushMessageWithKeyToQueue(
+		pctx,
+		[]string{cfg.Kafka.Url},
+		cfg.Kafka.ApiKey,
+		cfg.Kafka.Secret,
+		"auth",
+		req.Action,
+		reqInBytes,
+	); err != nil {
+		authLog.Error(pctx, "LockoutEvent failed", "error", err)
+		return errors.New("error: lockout event failed")
+	}
+
+	return nil
 }
@@ -3,7 +3,7 @@ package authUsecase
 import (
 	"context"
 	"errors"
-	"log"
+	"math"
 	"strings"
 	"time"
 
@@ -14,57 +14,308 @@ import (
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player"
 	playerPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/player/playerPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/metrics"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/oidc"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/rbac"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
 )
 
 type (
 	AuthUsecaseService interface {
 		Login(pctx context.Context, cfg *config.Config, req *auth.PlayerLoginReq) (*auth.ProfileIntercepter, error)
+		LoginMfa(pctx context.Context, cfg *config.Config, req *auth.MfaLoginReq) (*auth.ProfileIntercepter, error)
+		UnlockLogin(pctx context.Context, cfg *config.Config, actorId string, req *auth.UnlockLoginReq) (*auth.UnlockLoginRes, error)
 		RefreshToken(pctx context.Context, cfg *config.Config, req *auth.RefreshTokenReq) (*auth.ProfileIntercepter, error)
 		Logout(pctx context.Context, credentialId string) (int64, error)
 		AccessTokenSearch(pctx context.Context, accessToken string) (*authPb.AccessTokenSearchRes, error)
-		RolesCount(pctx context.Context) (*authPb.RolesCountRes, error)
+		RevokePlayerCredentials(pctx context.Context, playerId string) (*authPb.RevokePlayerCredentialsRes, error)
+		OidcLogin(pctx context.Context, cfg *config.Config, provider string) (string, error)
+		OidcCallback(pctx context.Context, cfg *config.Config, provider string, req *auth.OidcCallbackReq) (*auth.ProfileIntercepter, error)
 	}
 
 	authUsecase struct {
 		authRepository authRepository.AuthRepositoryService
 	}
+
+	// loginCounter is a key a login attempt is counted on, with the failures it allows before it locks
+	loginCounter struct {
+		key         string
+		maxAttempts int
+	}
 )
 
+var authLog = logger.New("auth")
+
 func NewAuthUsecase(authRepository authRepository.AuthRepositoryService) AuthUsecaseService {
 	return &authUsecase{authRepository: authRepository}
 }
 
 func (u *authUsecase) Login(pctx context.Context, cfg *config.Config, req *auth.PlayerLoginReq) (*auth.ProfileIntercepter, error) {
-	log.Printf("Login: %v", req)
+	authLog.Info(pctx, "Login", "req", req)
+
+	accountKey := "account:" + strings.ToLower(strings.TrimSpace(req.Email))
+	ipKey := "ip:" + req.Ip
+
+	counters := []*loginCounter{{key: accountKey, maxAttempts: cfg.Lockout.MaxAttempts}}
+	if req.Ip != "" {
+		counters = append(counters, &loginCounter{key: ipKey, maxAttempts: cfg.Lockout.IpMaxAttempts})
+	}
+	attempts, err := u.claimLoginAttempts(pctx, cfg, counters)
+	if err != nil {
+		metrics.FailedLogins.WithLabelValues("locked").Inc()
+		return nil, err
+	}
+
 	profile, err := u.authRepository.CredentialSearch(pctx, cfg.Grpc.PlayerUrl, &playerPb.CredentialSearchReq{
 		Email:    req.Email,
 		Password: req.Password,
 	})
 	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredential) {
+			metrics.FailedLogins.WithLabelValues("invalid_credential").Inc()
+			for i, counter := range counters {
+				u.recordLoginFailure(pctx, cfg, counter, attempts[i], req.Ip)
+			}
+		} else {
+			// Not a wrong password, the attempts are handed back
+			u.releaseLoginAttempts(pctx, counters)
+		}
 		return nil, err
 	}
 
-	profile.Id = "player:" + profile.Id
+	// Only the account counter is reset, an ip keeps its failures across accounts but not this success
+	if _, err := u.authRepository.DeleteManyLoginAttempts(pctx, []string{accountKey}); err != nil {
+		authLog.Error(pctx, "Login: reset login attempts failed", "error", err)
+	}
+	u.releaseLoginAttempts(pctx, counters[1:])
+
+	return u.completeLogin(pctx, cfg, profile)
+}
+
+// claimLoginAttempts counts the attempt on every counter before the password is checked. The
+// limit is checked in the same update, so a burst of parallel guesses cannot all pass before
+// the first failure lands. A login rejected by one counter hands back what it took from the others.
+func (u *authUsecase) claimLoginAttempts(pctx context.Context, cfg *config.Config, counters []*loginCounter) ([]*auth.LoginAttempt, error) {
+	expiredAt := utils.LocalTime().Add(time.Duration(cfg.Lockout.Window) * time.Second)
+
+	attempts := make([]*auth.LoginAttempt, 0)
+	for i, counter := range counters {
+		attempt, err := u.authRepository.IncreaseLoginAttempt(pctx, counter.key, counter.maxAttempts, expiredAt)
+		if err != nil {
+			if errors.Is(err, auth.ErrLoginLocked) {
+				authLog.Warn(pctx, "Login is locked", "key", counter.key)
+			}
+			u.releaseLoginAttempts(pctx, counters[:i])
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, nil
+}
+
+func (u *authUsecase) releaseLoginAttempts(pctx context.Context, counters []*loginCounter) {
+	for _, counter := range counters {
+		if err := u.authRepository.DecreaseLoginAttempt(pctx, counter.key); err != nil {
+			authLog.Error(pctx, "releaseLoginAttempts failed", "key", counter.key, "error", err)
+		}
+	}
+}
+
+// recordLoginFailure turns a claimed attempt into a failure. From the second failure on every attempt
+// doubles the delay until the next one is allowed, and reaching maxAttempts locks the key for the
+// configured duration.
+func (u *authUsecase) recordLoginFailure(pctx context.Context, cfg *config.Config, counter *loginCounter, attempt *auth.LoginAttempt, ip string) {
+	now := utils.LocalTime()
+
+	delay := time.Duration(0)
+	if attempt.FailedCount > 1 {
+		delay = time.Duration(math.Min(math.Pow(2, float64(attempt.FailedCount-2)), float64(cfg.Lockout.MaxDelay))) * time.Second
+	}
+
+	lockedUntil := time.Time{}
+	if attempt.FailedCount >= counter.maxAttempts {
+		lockedUntil = now.Add(time.Duration(cfg.Lockout.Duration) * time.Second)
+	}
+
+	if err := u.authRepository.UpdateOneLoginAttemptDelay(pctx, counter.key, now.Add(delay), lockedUntil); err != nil {
+		return
+	}
+
+	if !lockedUntil.IsZero() {
+		authLog.Info(pctx, "Login locked after failed attempts", "key", counter.key, "locked_until", lockedUntil, "failed_count", attempt.FailedCount)
+		u.lockoutEvent(pctx, cfg, &auth.LockoutEvent{
+			Action:      "lockout",
+			Key:         counter.key,
+			Ip:          ip,
+			FailedCount: attempt.FailedCount,
+			LockedUntil: lockedUntil,
+			CreatedAt:   now,
+		})
+	}
+}
+
+// lockoutEvent publishes the audit event in the background so a slow broker does not hold up the login response.
+func (u *authUsecase) lockoutEvent(pctx context.Context, cfg *config.Config, req *auth.LockoutEvent) {
+	// The event outlives the login request, only the values of its context are kept
+	ctx := context.WithoutCancel(pctx)
+	go func() {
+		if err := u.authRepository.LockoutEvent(ctx, cfg, req); err != nil {
+			authLog.Error(ctx, "LockoutEvent failed", "error", err)
+		}
+	}()
+}
+
+func (u *authUsecase) UnlockLogin(pctx context.Context, cfg *config.Config, actorId string, req *auth.UnlockLoginReq) (*auth.UnlockLoginRes, error) {
+	keys := make([]string, 0)
+	if req.Email != "" {
+		keys = append(keys, "account:"+strings.ToLower(strings.TrimSpace(req.Email)))
+	}
+	if req.Ip != "" {
+		keys = append(keys, "ip:"+req.Ip)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("error: email or ip is required")
+	}
+
+	count, err := u.authRepository.DeleteManyLoginAttempts(pctx, keys)
+	if err != nil {
+		return nil, err
+	}
 
-	accessToken := u.authRepository.AccessToken(cfg, &jwtauth.Claims{
-		PlayerId: profile.Id,
-		RoleCode: int(profile.RoleCode),
+	for _, key := range keys {
+		u.lockoutEvent(pctx, cfg, &auth.LockoutEvent{
+			Action:    "unlock",
+			Key:       key,
+			ActorId:   actorId,
+			CreatedAt: utils.LocalTime(),
+		})
+	}
+
+	return &auth.UnlockLoginRes{
+		UnlockedCount: count,
+	}, nil
+}
+
+// completeLogin runs after the first factor. Players with mfa enabled get a challenge token
+// instead of a credential, everybody else is signed in straight away.
+func (u *authUsecase) completeLogin(pctx context.Context, cfg *config.Config, profile *playerPb.PlayerProfile) (*auth.ProfileIntercepter, error) {
+	if !profile.MfaEnabled {
+		return u.issueCredential(pctx, cfg, profile, false)
+	}
+
+	challengeToken := u.authRepository.MfaChallengeToken(cfg, &jwtauth.Claims{
+		PlayerId: "player:" + profile.Id,
 	})
+	claims, err := jwtauth.ParseToken(cfg.Jwt.AccessSecretKey, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	expiredAt := claims.ExpiresAt.Time
+
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+
+	return &auth.ProfileIntercepter{
+		PlayerProfile: &player.PlayerProfile{
+			Id:        "player:" + profile.Id,
+			Email:     profile.Email,
+			Username:  profile.Username,
+			CreatedAt: utils.ConvertStringTimeToTime(profile.CreatedAt).In(loc),
+			UpdatedAt: utils.ConvertStringTimeToTime(profile.UpdatedAt).In(loc),
+		},
+		Mfa: &auth.MfaRes{
+			Required:       true,
+			ChallengeToken: challengeToken,
+			ExpiredAt:      &expiredAt,
+		},
+	}, nil
+}
 
-	refreshToken := u.authRepository.RefreshToken(cfg, &jwtauth.Claims{
-		PlayerId: profile.Id,
-		RoleCode: int(profile.RoleCode),
+func (u *authUsecase) LoginMfa(pctx context.Context, cfg *config.Config, req *auth.MfaLoginReq) (*auth.ProfileIntercepter, error) {
+	claims, err := jwtauth.ParseToken(cfg.Jwt.AccessSecretKey, req.ChallengeToken)
+	if err != nil {
+		authLog.Error(pctx, "LoginMfa", "error", err)
+		return nil, err
+	}
+	if claims.Subject != "mfa-challenge" {
+		authLog.Error(pctx, "LoginMfa: unexpected token subject", "error", claims.Subject)
+		return nil, errors.New("error: challenge token is invalid")
+	}
+
+	playerId := strings.TrimPrefix(claims.PlayerId, "player:")
+
+	// Second factor guesses are throttled the same way as passwords
+	mfaKey := "mfa:" + claims.PlayerId
+	counters := []*loginCounter{{key: mfaKey, maxAttempts: cfg.Lockout.MaxAttempts}}
+	attempts, err := u.claimLoginAttempts(pctx, cfg, counters)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := u.authRepository.VerifyMfaCode(pctx, cfg.Grpc.PlayerUrl, &playerPb.VerifyMfaCodeReq{
+		PlayerId: playerId,
+		Code:     req.Code,
 	})
+	if err != nil {
+		u.releaseLoginAttempts(pctx, counters)
+		return nil, err
+	}
+	if !result.IsValid {
+		metrics.FailedLogins.WithLabelValues("invalid_mfa_code").Inc()
+		u.recordLoginFailure(pctx, cfg, counters[0], attempts[0], "")
+		return nil, errors.New("error: mfa code is invalid")
+	}
+
+	if _, err := u.authRepository.DeleteManyLoginAttempts(pctx, []string{mfaKey}); err != nil {
+		authLog.Error(pctx, "LoginMfa: reset login attempts failed", "error", err)
+	}
+
+	profile, err := u.authRepository.FindOnePlayerProfileToRefresh(pctx, cfg.Grpc.PlayerUrl, &playerPb.FindOnePlayerProfileToRefreshReq{
+		PlayerId: playerId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.issueCredential(pctx, cfg, profile, true)
+}
+
+// tokenPermissions keeps admin permissions out of tokens that were not issued through mfa.
+func tokenPermissions(roles []string, mfa bool) []string {
+	permissions := rbac.PermissionsOfRoles(roles)
+	if !mfa {
+		return rbac.WithoutAdminPermissions(permissions)
+	}
+	return permissions
+}
+
+// issueCredential signs a new access/refresh token pair for a verified player profile.
+func (u *authUsecase) issueCredential(pctx context.Context, cfg *config.Config, profile *playerPb.PlayerProfile, mfa bool) (*auth.ProfileIntercepter, error) {
+	profile.Id = "player:" + profile.Id
+
+	claims := &jwtauth.Claims{
+		PlayerId:    profile.Id,
+		Roles:       profile.Roles,
+		Permissions: tokenPermissions(profile.Roles, mfa),
+		Mfa:         mfa,
+	}
+
+	accessToken := u.authRepository.AccessToken(cfg, claims)
+
+	refreshToken := u.authRepository.RefreshToken(cfg, claims)
 
 	credentialId, err := u.authRepository.InsertOnePlayerCredential(pctx, &auth.Credential{
 		PlayerId:     profile.Id,
-		RoleCode:     int(profile.RoleCode),
+		Roles:        profile.Roles,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
 		CreatedAt:    utils.LocalTime(),
 		UpdatedAt:    utils.LocalTime(),
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	credential, err := u.authRepository.FindOnePlayerCredential(pctx, credentialId.Hex())
 	if err != nil {
@@ -73,7 +324,7 @@ func (u *authUsecase) Login(pctx context.Context, cfg *config.Config, req *auth.
 
 	loc, _ := time.LoadLocation("Asia/Bangkok")
 
-	return &auth.ProfileIntercepter{
+	res := &auth.ProfileIntercepter{
 		PlayerProfile: &player.PlayerProfile{
 			Id:        profile.Id,
 			Email:     profile.Email,
@@ -84,19 +335,30 @@ func (u *authUsecase) Login(pctx context.Context, cfg *config.Config, req *auth.
 		Credential: &auth.CredentialRes{
 			Id:           credential.Id.Hex(),
 			PlayerId:     credential.PlayerId,
-			RoleCode:     credential.RoleCode,
+			Roles:        credential.Roles,
+			Permissions:  claims.Permissions,
 			AccessToken:  credential.AccessToken,
 			RefreshToken: credential.RefreshToken,
 			CreatedAt:    credential.CreatedAt.In(loc),
 			UpdatedAt:    credential.UpdatedAt.In(loc),
 		},
-	}, nil
+	}
+
+	// Admin permissions are mandatory mfa, tell the player to enroll before they can use them
+	if !mfa && rbac.HasAdminPermission(rbac.PermissionsOfRoles(profile.Roles)) {
+		res.Mfa = &auth.MfaRes{
+			Required:           false,
+			EnrollmentRequired: true,
+		}
+	}
+
+	return res, nil
 }
 
 func (u *authUsecase) RefreshToken(pctx context.Context, cfg *config.Config, req *auth.RefreshTokenReq) (*auth.ProfileIntercepter, error) {
 	claims, err := jwtauth.ParseToken(cfg.Jwt.RefreshSecretKey, req.RefreshToken)
 	if err != nil {
-		log.Printf("Error: RefreshToken: %s", err.Error())
+		authLog.Error(pctx, "RefreshToken", "error", err)
 		return nil, errors.New(err.Error())
 	}
 
@@ -107,15 +369,17 @@ func (u *authUsecase) RefreshToken(pctx context.Context, cfg *config.Config, req
 		return nil, err
 	}
 
-	accessToken := jwtauth.NewAccessToken(cfg.Jwt.AccessSecretKey, cfg.Jwt.AccessDuration, &jwtauth.Claims{
-		PlayerId: profile.Id,
-		RoleCode: int(profile.RoleCode),
-	}).SignToken()
+	// A refreshed token keeps the mfa status of the login that created it
+	newClaims := &jwtauth.Claims{
+		PlayerId:    profile.Id,
+		Roles:       profile.Roles,
+		Permissions: tokenPermissions(profile.Roles, claims.Mfa && profile.MfaEnabled),
+		Mfa:         claims.Mfa && profile.MfaEnabled,
+	}
 
-	refreshToken := jwtauth.ReloadToken(cfg.Jwt.RefreshSecretKey, claims.ExpiresAt.Unix(), &jwtauth.Claims{
-		PlayerId: profile.Id,
-		RoleCode: int(profile.RoleCode),
-	})
+	accessToken := jwtauth.NewAccessToken(cfg.Jwt.AccessSecretKey, cfg.Jwt.AccessDuration, newClaims).SignToken()
+
+	refreshToken := jwtauth.ReloadToken(cfg.Jwt.RefreshSecretKey, claims.ExpiresAt.Unix(), newClaims)
 
 	if err := u.authRepository.UpdateOnePlayerCredential(pctx, req.CredentialId, &auth.UpdateRefreshTokenReq{
 		PlayerId:     profile.Id,
@@ -144,7 +408,8 @@ func (u *authUsecase) RefreshToken(pctx context.Context, cfg *config.Config, req
 		Credential: &auth.CredentialRes{
 			Id:           credential.Id.Hex(),
 			PlayerId:     credential.PlayerId,
-			RoleCode:     credential.RoleCode,
+			Roles:        credential.Roles,
+			Permissions:  newClaims.Permissions,
 			AccessToken:  credential.AccessToken,
 			RefreshToken: credential.RefreshToken,
 			CreatedAt:    credential.CreatedAt.In(loc),
@@ -168,7 +433,7 @@ func (u *authUsecase) AccessTokenSearch(pctx context.Context, accessToken string
 	if credential == nil {
 		return &authPb.AccessTokenSearchRes{
 			IsValid: false,
-		}, errors.New("error: access token is invalid")
+		}, auth.ErrAccessTokenInvalid
 	}
 
 	return &authPb.AccessTokenSearchRes{
@@ -176,13 +441,137 @@ func (u *authUsecase) AccessTokenSearch(pctx context.Context, accessToken string
 	}, nil
 }
 
-func (u *authUsecase) RolesCount(pctx context.Context) (*authPb.RolesCountRes, error) {
-	result, err := u.authRepository.RolesCount(pctx)
+// RevokePlayerCredentials deletes every credential of the player, their access and refresh
+// tokens stop working at once.
+func (u *authUsecase) RevokePlayerCredentials(pctx context.Context, playerId string) (*authPb.RevokePlayerCredentialsRes, error) {
+	// Credentials keep the prefixed id of the token claims
+	playerId = "player:" + strings.TrimPrefix(playerId, "player:")
+
+	result, err := u.authRepository.DeleteManyPlayerCredentials(pctx, playerId)
 	if err != nil {
 		return nil, err
 	}
+	authLog.Info(pctx, "Player credentials revoked", "player_id", playerId, "revoked_count", result)
 
-	return &authPb.RolesCountRes{
-		Count: result,
+	return &authPb.RevokePlayerCredentialsRes{
+		RevokedCount: result,
 	}, nil
 }
+
+func (u *authUsecase) OidcLogin(pctx context.Context, cfg *config.Config, provider string) (string, error) {
+	p, err := u.authRepository.OidcProvider(pctx, cfg, provider)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		authLog.Error(pctx, "OidcLogin: random state failed", "error", err)
+		return "", auth.ErrLoginUnavailable
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		authLog.Error(pctx, "OidcLogin: random nonce failed", "error", err)
+		return "", auth.ErrLoginUnavailable
+	}
+	codeVerifier, err := oidc.RandomString(48)
+	if err != nil {
+		authLog.Error(pctx, "OidcLogin: random code verifier failed", "error", err)
+		return "", auth.ErrLoginUnavailable
+	}
+
+	session := &auth.OidcSession{
+		Provider:     provider,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiredAt:    utils.LocalTime().Add(10 * time.Minute),
+		CreatedAt:    utils.LocalTime(),
+	}
+
+	authUrl, err := p.AuthCodeUrl(pctx, session.State, session.Nonce, session.CodeVerifier)
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.authRepository.InsertOneOidcSession(pctx, session); err != nil {
+		return "", err
+	}
+
+	return authUrl, nil
+}
+
+func (u *authUsecase) OidcCallback(pctx context.Context, cfg *config.Config, provider string, req *auth.OidcCallbackReq) (*auth.ProfileIntercepter, error) {
+	if req.Error != "" {
+		authLog.Error(pctx, "OidcCallback: provider returned an error", "provider", provider, "error", req.Error, "error_description", req.ErrorDescription)
+		return nil, errors.New("error: oidc login was rejected")
+	}
+
+	if req.Code == "" {
+		return nil, errors.New("error: oidc code is required")
+	}
+
+	p, err := u.authRepository.OidcProvider(pctx, cfg, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := u.authRepository.FindAndDeleteOneOidcSession(pctx, provider, req.State)
+	if err != nil {
+		return nil, err
+	}
+
+	if session.ExpiredAt.Before(utils.LocalTime()) {
+		authLog.Error(pctx, "OidcCallback: session is expired")
+		return nil, errors.New("error: oidc session is expired")
+	}
+
+	identity, err := p.Exchange(pctx, req.Code, session.CodeVerifier, session.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	// Known identity: sign in as the linked player. Only an identity that is not there is
+	// provisioned, a failed lookup must not create a second player.
+	linked, err := u.authRepository.FindOneIdentity(pctx, provider, identity.Subject)
+	if err != nil {
+		return nil, auth.ErrLoginUnavailable
+	}
+	if linked != nil {
+		profile, err := u.authRepository.FindOnePlayerProfileToRefresh(pctx, cfg.Grpc.PlayerUrl, &playerPb.FindOnePlayerProfileToRefreshReq{
+			PlayerId: strings.TrimPrefix(linked.PlayerId, "player:"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return u.completeLogin(pctx, cfg, profile)
+	}
+
+	if identity.Email == "" {
+		authLog.Error(pctx, "OidcCallback: provider did not return an email", "provider", provider)
+		return nil, errors.New("error: oidc email is required")
+	}
+
+	// New identity: link it to an existing player or provision a new one
+	profile, err := u.authRepository.ProvisionPlayer(pctx, cfg.Grpc.PlayerUrl, &playerPb.ProvisionPlayerReq{
+		Email:         identity.Email,
+		Username:      identity.Username,
+		EmailVerified: identity.EmailVerified,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.authRepository.InsertOneIdentity(pctx, &auth.Identity{
+		Provider:  provider,
+		Subject:   identity.Subject,
+		PlayerId:  "player:" + profile.Id,
+		Email:     identity.Email,
+		CreatedAt: utils.LocalTime(),
+		UpdatedAt: utils.LocalTime(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return u.completeLogin(pctx, cfg, profile)
+}
@@ -5,5 +5,8 @@ type (
 		Id       string `json:"_id" bson:"_id,omitempty"`
 		PlayerId string `json:"player_id" bson:"player_id"`
 		ItemId   string `json:"item_id" bson:"item_id"`
+		// PaidPrice is what the player paid for the item, a sell back is paid from it. Items
+		// added before it was recorded have none.
+		PaidPrice *float64 `json:"paid_price,omitempty" bson:"paid_price,omitempty"`
 	}
 )
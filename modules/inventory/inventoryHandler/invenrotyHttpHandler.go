@@ -1,7 +1,6 @@
 package inventoryHandler
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
@@ -28,7 +27,7 @@ func NewInventoryHttpHandler(cfg *config.Config, inventoryUsecase inventoryUseca
 }
 
 func (h *inventoryHttpHandler) FindPlayerItems(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
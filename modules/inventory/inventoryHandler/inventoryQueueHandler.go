@@ -2,15 +2,18 @@ package inventoryHandler
 
 import (
 	"context"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/inventory"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/inventory/inventoryUsecase"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/metrics"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/probe"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/queue"
 )
 
@@ -28,6 +31,8 @@ type (
 	}
 )
 
+var inventoryLog = logger.New("inventory")
+
 func NewInventoryQueueHandler(cfg *config.Config, inventoryUsecase inventoryUsecase.InventoryUsecaseService) InventoryQueueHandlerService {
 	return &inventoryQueueHandler{
 		cfg:              cfg,
@@ -48,10 +53,10 @@ func (h *inventoryQueueHandler) InventoryConsumer(pctx context.Context) (sarama.
 
 	consumer, err := worker.ConsumePartition("inventory", 0, offset)
 	if err != nil {
-		log.Println("Trying to set offset as 0")
+		inventoryLog.Warn(pctx, "Trying to set offset as 0")
 		consumer, err = worker.ConsumePartition("inventory", 0, 0)
 		if err != nil {
-			log.Println("Error: InventoryConsumer failed: ", err.Error())
+			inventoryLog.Error(pctx, "InventoryConsumer failed", "error", err)
 			return nil, err
 		}
 	}
@@ -62,38 +67,51 @@ func (h *inventoryQueueHandler) InventoryConsumer(pctx context.Context) (sarama.
 func (h *inventoryQueueHandler) AddPlayerItem() {
 	ctx := context.Background()
 
+	heartbeat := probe.NewHeartbeat("AddPlayerItem")
+	defer heartbeat.Stop()
+
 	consumer, err := h.InventoryConsumer(ctx)
 	if err != nil {
 		return
 	}
 	defer consumer.Close()
 
-	log.Println("Start AddPlayerItem ...")
+	inventoryLog.Info(ctx, "Start AddPlayerItem")
 
 	sigchan := make(chan os.Signal, 1)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 
+	ticker := time.NewTicker(probe.HeartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case err := <-consumer.Errors():
-			log.Println("Error: AddPlayerItem failed: ", err.Error())
+			inventoryLog.Error(ctx, "AddPlayerItem failed", "error", err)
 			continue
 		case msg := <-consumer.Messages():
+			metrics.ObserveConsumed(msg, consumer.HighWaterMarkOffset())
 			if string(msg.Key) == "buy" {
-				h.inventoryUsecase.UpsertOffset(ctx, msg.Offset+1)
+				msgCtx, span := queue.StartConsumeSpan(ctx, msg)
+
+				h.inventoryUsecase.UpsertOffset(msgCtx, msg.Offset+1)
 
 				req := new(inventory.UpdateInventoryReq)
 
 				if err := queue.DecodeMessage(req, msg.Value); err != nil {
+					span.End()
 					continue
 				}
 
-				h.inventoryUsecase.AddPlayerItemRes(ctx, h.cfg, req)
+				h.inventoryUsecase.AddPlayerItemRes(msgCtx, h.cfg, req)
 
-				log.Printf("AddPlayerItem | Topic(%s)| Offset(%d) Message(%s) \n", msg.Topic, msg.Offset, string(msg.Value))
+				inventoryLog.Debug(msgCtx, "AddPlayerItem", "topic", msg.Topic, "offset", msg.Offset, "message", string(msg.Value))
+				span.End()
 			}
+		case <-ticker.C:
+			heartbeat.Beat()
 		case <-sigchan:
-			log.Println("Stop DockedPlayerMoney...")
+			inventoryLog.Info(ctx, "Stop AddPlayerItem")
 			return
 		}
 	}
@@ -102,38 +120,51 @@ func (h *inventoryQueueHandler) AddPlayerItem() {
 func (h *inventoryQueueHandler) RollbackAddPlayerItem() {
 	ctx := context.Background()
 
+	heartbeat := probe.NewHeartbeat("RollbackAddPlayerItem")
+	defer heartbeat.Stop()
+
 	consumer, err := h.InventoryConsumer(ctx)
 	if err != nil {
 		return
 	}
 	defer consumer.Close()
 
-	log.Println("Start RollbackRemovePlayerItem ...")
+	inventoryLog.Info(ctx, "Start RollbackRemovePlayerItem")
 
 	sigchan := make(chan os.Signal, 1)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 
+	ticker := time.NewTicker(probe.HeartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case err := <-consumer.Errors():
-			log.Println("Error: RollbackRemovePlayerItem failed: ", err.Error())
+			inventoryLog.Error(ctx, "RollbackRemovePlayerItem failed", "error", err)
 			continue
 		case msg := <-consumer.Messages():
+			metrics.ObserveConsumed(msg, consumer.HighWaterMarkOffset())
 			if string(msg.Key) == "radd" {
-				h.inventoryUsecase.UpsertOffset(ctx, msg.Offset+1)
+				msgCtx, span := queue.StartConsumeSpan(ctx, msg)
+
+				h.inventoryUsecase.UpsertOffset(msgCtx, msg.Offset+1)
 
 				req := new(inventory.RollbackPlayerInventoryReq)
 
 				if err := queue.DecodeMessage(req, msg.Value); err != nil {
+					span.End()
 					continue
 				}
 
-				h.inventoryUsecase.RollbackRemovePlayerItem(ctx, h.cfg, req)
+				h.inventoryUsecase.RollbackRemovePlayerItem(msgCtx, h.cfg, req)
 
-				log.Printf("RollbackRemovePlayerItem | Topic(%s)| Offset(%d) Message(%s) \n", msg.Topic, msg.Offset, string(msg.Value))
+				inventoryLog.Debug(msgCtx, "RollbackRemovePlayerItem", "topic", msg.Topic, "offset", msg.Offset, "message", string(msg.Value))
+				span.End()
 			}
+		case <-ticker.C:
+			heartbeat.Beat()
 		case <-sigchan:
-			log.Println("Stop DockedPlayerMoney...")
+			inventoryLog.Info(ctx, "Stop RollbackAddPlayerItem")
 			return
 		}
 	}
@@ -142,38 +173,51 @@ func (h *inventoryQueueHandler) RollbackAddPlayerItem() {
 func (h *inventoryQueueHandler) RemovePlayerItem() {
 	ctx := context.Background()
 
+	heartbeat := probe.NewHeartbeat("RemovePlayerItem")
+	defer heartbeat.Stop()
+
 	consumer, err := h.InventoryConsumer(ctx)
 	if err != nil {
 		return
 	}
 	defer consumer.Close()
 
-	log.Println("Start RemovePlayerItem ...")
+	inventoryLog.Info(ctx, "Start RemovePlayerItem")
 
 	sigchan := make(chan os.Signal, 1)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 
+	ticker := time.NewTicker(probe.HeartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case err := <-consumer.Errors():
-			log.Println("Error: RemovePlayerItem failed: ", err.Error())
+			inventoryLog.Error(ctx, "RemovePlayerItem failed", "error", err)
 			continue
 		case msg := <-consumer.Messages():
+			metrics.ObserveConsumed(msg, consumer.HighWaterMarkOffset())
 			if string(msg.Key) == "sell" {
-				h.inventoryUsecase.UpsertOffset(ctx, msg.Offset+1)
+				msgCtx, span := queue.StartConsumeSpan(ctx, msg)
+
+				h.inventoryUsecase.UpsertOffset(msgCtx, msg.Offset+1)
 
 				req := new(inventory.UpdateInventoryReq)
 
 				if err := queue.DecodeMessage(req, msg.Value); err != nil {
+					span.End()
 					continue
 				}
 
-				h.inventoryUsecase.RemovePlayerItemRes(ctx, h.cfg, req)
+				h.inventoryUsecase.RemovePlayerItemRes(msgCtx, h.cfg, req)
 
-				log.Printf("RemovePlayerItem | Topic(%s)| Offset(%d) Message(%s) \n", msg.Topic, msg.Offset, string(msg.Value))
+				inventoryLog.Debug(msgCtx, "RemovePlayerItem", "topic", msg.Topic, "offset", msg.Offset, "message", string(msg.Value))
+				span.End()
 			}
+		case <-ticker.C:
+			heartbeat.Beat()
 		case <-sigchan:
-			log.Println("Stop DockedPlayerMoney...")
+			inventoryLog.Info(ctx, "Stop RemovePlayerItem")
 			return
 		}
 	}
@@ -182,38 +226,51 @@ func (h *inventoryQueueHandler) RemovePlayerItem() {
 func (h *inventoryQueueHandler) RollbackRemovePlayerItem() {
 	ctx := context.Background()
 
+	heartbeat := probe.NewHeartbeat("RollbackRemovePlayerItem")
+	defer heartbeat.Stop()
+
 	consumer, err := h.InventoryConsumer(ctx)
 	if err != nil {
 		return
 	}
 	defer consumer.Close()
 
-	log.Println("Start RollbackAddPlayerItem ...")
+	inventoryLog.Info(ctx, "Start RollbackAddPlayerItem")
 
 	sigchan := make(chan os.Signal, 1)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 
+	ticker := time.NewTicker(probe.HeartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case err := <-consumer.Errors():
-			log.Println("Error: RollbackAddPlayerItem failed: ", err.Error())
+			inventoryLog.Error(ctx, "RollbackAddPlayerItem failed", "error", err)
 			continue
 		case msg := <-consumer.Messages():
+			metrics.ObserveConsumed(msg, consumer.HighWaterMarkOffset())
 			if string(msg.Key) == "rremove" {
-				h.inventoryUsecase.UpsertOffset(ctx, msg.Offset+1)
+				msgCtx, span := queue.StartConsumeSpan(ctx, msg)
+
+				h.inventoryUsecase.UpsertOffset(msgCtx, msg.Offset+1)
 
 				req := new(inventory.RollbackPlayerInventoryReq)
 
 				if err := queue.DecodeMessage(req, msg.Value); err != nil {
+					span.End()
 					continue
 				}
 
-				h.inventoryUsecase.RollbackAddPlayerItem(ctx, h.cfg, req)
+				h.inventoryUsecase.RollbackAddPlayerItem(msgCtx, h.cfg, req)
 
-				log.Printf("RollbackAddPlayerItem | Topic(%s)| Offset(%d) Message(%s) \n", msg.Topic, msg.Offset, string(msg.Value))
+				inventoryLog.Debug(msgCtx, "RollbackAddPlayerItem", "topic", msg.Topic, "offset", msg.Offset, "message", string(msg.Value))
+				span.End()
 			}
+		case <-ticker.C:
+			heartbeat.Beat()
 		case <-sigchan:
-			log.Println("Stop DockedPlayerMoney...")
+			inventoryLog.Info(ctx, "Stop RollbackRemovePlayerItem")
 			return
 		}
 	}
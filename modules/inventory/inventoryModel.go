@@ -9,6 +9,8 @@ type (
 	UpdateInventoryReq struct {
 		PlayerId string `json:"player_id" validate:"required,max=64"`
 		ItemId   string `json:"item_id" validate:"required,max=64"`
+		// PaidPrice is what the player paid for an added item
+		PaidPrice float64 `json:"paid_price"`
 	}
 
 	ItemInInventory struct {
@@ -22,8 +24,9 @@ type (
 	}
 
 	RollbackPlayerInventoryReq struct {
-		InventoryId string `json:"inventory_id"`
-		PlayerId    string `json:"player_id"`
-		ItemId      string `json:"item_id"`
+		InventoryId string   `json:"inventory_id"`
+		PlayerId    string   `json:"player_id"`
+		ItemId      string   `json:"item_id"`
+		PaidPrice   *float64 `json:"paid_price,omitempty"`
 	}
 )
@@ -0,0 +1,12 @@
+package bonx_shop_tutorial
+
+import "errors"
+
+// Validate methods are called by the grpccon validation interceptor before the handler runs.
+
+func (x *IsAvaliableToSellReq) Validate() error {
+	if x.GetPlayerId() == "" || x.GetItemId() == "" {
+		return errors.New("error: player id and item id are required")
+	}
+	return nil
+}
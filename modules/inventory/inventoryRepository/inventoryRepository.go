@@ -4,16 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
-	"time"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/inventory"
 	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/payment"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/deadline"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/queue"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson"
@@ -34,7 +34,7 @@ type (
 		InsertOnePlayerItem(pctx context.Context, req *inventory.Inventory) (primitive.ObjectID, error)
 		DeleteOneInventory(pctx context.Context, inventoryId string) error
 		FindOnePlayerItem(pctx context.Context, playerId, itemId string) bool
-		DeleteOnePlayerItem(pctx context.Context, playerId, itemId string) error
+		DeleteOnePlayerItem(pctx context.Context, playerId, itemId string) (*inventory.Inventory, error)
 	}
 
 	inventoryRepository struct {
@@ -42,6 +42,8 @@ type (
 	}
 )
 
+var inventoryLog = logger.New("inventory")
+
 func NewInventoryRepository(db *mongo.Client) InventoryRepositoryService {
 	return &inventoryRepository{db: db}
 }
@@ -51,7 +53,7 @@ func (r *inventoryRepository) inventoryDbConn(ptcx context.Context) *mongo.Datab
 }
 
 func (r *inventoryRepository) GetOffset(pctx context.Context) (int64, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.inventoryDbConn(ctx)
@@ -59,14 +61,14 @@ func (r *inventoryRepository) GetOffset(pctx context.Context) (int64, error) {
 
 	result := new(models.KafkaOffset)
 	if err := col.FindOne(ctx, bson.M{}).Decode(result); err != nil {
-		log.Printf("Error: GetOffset failed: %s", err.Error())
+		inventoryLog.Error(ctx, "GetOffset failed", "error", err)
 		return -1, errors.New("error: GetOffset failed")
 	}
 	return result.Offset, nil
 }
 
 func (r *inventoryRepository) UpsertOffset(pctx context.Context, offset int64) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.inventoryDbConn(ctx)
@@ -74,38 +76,38 @@ func (r *inventoryRepository) UpsertOffset(pctx context.Context, offset int64) e
 
 	result, err := col.UpdateOne(ctx, bson.M{}, bson.M{"$set": bson.M{"offset": offset}}, options.Update().SetUpsert(true))
 	if err != nil {
-		log.Printf("Error: UpserOffset failed: %s", err.Error())
+		inventoryLog.Error(ctx, "UpserOffset failed", "error", err)
 		return errors.New("error: UpserOffset failed")
 	}
-	log.Printf("Info: UpserOffset result: %v", result)
+	inventoryLog.Debug(ctx, "UpsertOffset", "result", result)
 
 	return nil
 }
 
 func (r *inventoryRepository) FindItemsInIds(pctx context.Context, grpcUrl string, req *itemPb.FindItemsInIdsReq) (*itemPb.FindItemsInIdsRes, error) {
-	ctx, cancel := context.WithTimeout(pctx, 30*time.Second)
+	ctx, cancel := deadline.Grpc(pctx)
 	defer cancel()
 
 	jwtauth.SetApiKeyInContext(&ctx)
 	conn, err := grpccon.NewGrpcClient(grpcUrl)
 	if err != nil {
-		log.Printf("Error: gRPC connection failed: %s", err.Error())
+		inventoryLog.Error(ctx, "gRPC connection failed", "error", err)
 		return nil, errors.New("error: gRPC connection failed")
 	}
 
 	result, err := conn.Item().FindItemsInIds(ctx, req)
 	if err != nil {
-		log.Printf("Error: FindItemsInIds failed: %s", err.Error())
+		inventoryLog.Error(ctx, "FindItemsInIds failed", "error", err)
 		return nil, errors.New("error: email or password is incorrect")
 	}
 
 	if result == nil {
-		log.Printf("Error: FindItemsInIds failed: %s", err.Error())
+		inventoryLog.Error(ctx, "FindItemsInIds failed", "error", err)
 		return nil, errors.New("error: item not found")
 	}
 
 	if len(result.Items) == 0 {
-		log.Printf("Error: FindItemsInIds failed: %s", err.Error())
+		inventoryLog.Error(ctx, "FindItemsInIds failed", "error", err)
 		return nil, errors.New("error: item not found")
 	}
 
@@ -114,7 +116,7 @@ func (r *inventoryRepository) FindItemsInIds(pctx context.Context, grpcUrl strin
 }
 
 func (r *inventoryRepository) FindPlayerItems(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*inventory.Inventory, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.inventoryDbConn(ctx)
@@ -122,7 +124,7 @@ func (r *inventoryRepository) FindPlayerItems(pctx context.Context, filter primi
 
 	cursors, err := col.Find(ctx, filter, opts...)
 	if err != nil {
-		log.Printf("Error: FindPlayerItems failed: %s", err.Error())
+		inventoryLog.Error(ctx, "FindPlayerItems failed", "error", err)
 		return nil, errors.New("error: player items not found")
 	}
 
@@ -130,7 +132,7 @@ func (r *inventoryRepository) FindPlayerItems(pctx context.Context, filter primi
 	for cursors.Next(ctx) {
 		result := new(inventory.Inventory)
 		if err := cursors.Decode(result); err != nil {
-			log.Printf("Error: FindPlayerItems failed: %s", err.Error())
+			inventoryLog.Error(ctx, "FindPlayerItems failed", "error", err)
 			return nil, errors.New("error: player items not found")
 		}
 
@@ -141,7 +143,7 @@ func (r *inventoryRepository) FindPlayerItems(pctx context.Context, filter primi
 }
 
 func (r *inventoryRepository) CountPlayerItems(pctx context.Context, playerId string) (int64, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.inventoryDbConn(ctx)
@@ -149,7 +151,7 @@ func (r *inventoryRepository) CountPlayerItems(pctx context.Context, playerId st
 
 	count, err := col.CountDocuments(ctx, bson.M{"player_id": playerId})
 	if err != nil {
-		log.Printf("Error: CountPlayerItems failed: %s", err.Error())
+		inventoryLog.Error(ctx, "CountPlayerItems failed", "error", err)
 		return -1, errors.New("error: count player items failed")
 	}
 
@@ -157,7 +159,7 @@ func (r *inventoryRepository) CountPlayerItems(pctx context.Context, playerId st
 }
 
 func (r *inventoryRepository) InsertOnePlayerItem(pctx context.Context, req *inventory.Inventory) (primitive.ObjectID, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.inventoryDbConn(ctx)
@@ -165,7 +167,7 @@ func (r *inventoryRepository) InsertOnePlayerItem(pctx context.Context, req *inv
 
 	result, err := col.InsertOne(ctx, req)
 	if err != nil {
-		log.Printf("Error: InsertOnePlayerItem failed: %s", err.Error())
+		inventoryLog.Error(ctx, "InsertOnePlayerItem failed", "error", err)
 		return primitive.NilObjectID, errors.New("error: insert one player item failed")
 	}
 
@@ -173,17 +175,17 @@ func (r *inventoryRepository) InsertOnePlayerItem(pctx context.Context, req *inv
 }
 
 func (r *inventoryRepository) DeleteOneInventory(pctx context.Context, inventoryId string) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.inventoryDbConn(ctx)
 	col := db.Collection("players_inventory")
 	result, err := col.DeleteOne(ctx, bson.M{"_id": utils.ConvertToObjectId(inventoryId)})
 	if err != nil {
-		log.Printf("Error: DeleteOneInventory failed: %s", err.Error())
+		inventoryLog.Error(ctx, "DeleteOneInventory failed", "error", err)
 		return errors.New("error: delete one player item failed")
 	}
-	log.Printf("DeleteOneInventory result: %v", result)
+	inventoryLog.Debug(ctx, "DeleteOneInventory", "deleted_count", result.DeletedCount)
 
 	return nil
 }
@@ -191,11 +193,12 @@ func (r *inventoryRepository) DeleteOneInventory(pctx context.Context, inventory
 func (r *inventoryRepository) AddPlayerItemRes(pctx context.Context, cfg *config.Config, req *payment.PaymentTransferRes) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: AddPlayerItemRes failed: %s", err.Error())
+		inventoryLog.Error(pctx, "AddPlayerItemRes failed", "error", err)
 		return errors.New("error: docked player money res failed")
 	}
 
 	if err := queue.PushMessageWithKeyToQueue(
+		pctx,
 		[]string{cfg.Kafka.Url},
 		cfg.Kafka.ApiKey,
 		cfg.Kafka.Secret,
@@ -203,7 +206,7 @@ func (r *inventoryRepository) AddPlayerItemRes(pctx context.Context, cfg *config
 		"buy",
 		reqInBytes,
 	); err != nil {
-		log.Printf("Error: AddPlayerItemRes failed: %s", err.Error())
+		inventoryLog.Error(pctx, "AddPlayerItemRes failed", "error", err)
 		return errors.New("error: docked player money res failed")
 	}
 
@@ -211,7 +214,7 @@ func (r *inventoryRepository) AddPlayerItemRes(pctx context.Context, cfg *config
 }
 
 func (r *inventoryRepository) FindOnePlayerItem(pctx context.Context, playerId, itemId string) bool {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.inventoryDbConn(ctx)
@@ -219,38 +222,41 @@ func (r *inventoryRepository) FindOnePlayerItem(pctx context.Context, playerId,
 	result := new(inventory.Inventory)
 
 	if err := col.FindOne(ctx, bson.M{"player_id": playerId, "item_id": itemId}).Decode(result); err != nil {
-		log.Printf("Error: FindOnePlayerItem failed: %s", err.Error())
+		inventoryLog.Error(ctx, "FindOnePlayerItem failed", "error", err)
 		return false
 	}
 
 	return true
 }
 
-func (r *inventoryRepository) DeleteOnePlayerItem(pctx context.Context, playerId, itemId string) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+// DeleteOnePlayerItem removes one of the copies of an item the player has and returns it, the
+// price it was paid goes along with the sale.
+func (r *inventoryRepository) DeleteOnePlayerItem(pctx context.Context, playerId, itemId string) (*inventory.Inventory, error) {
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.inventoryDbConn(ctx)
 	col := db.Collection("players_inventory")
 
-	result, err := col.DeleteOne(ctx, bson.M{"player_id": playerId, "item_id": itemId})
-	if err != nil {
-		log.Printf("Error: DeleteOnePlayerItem failed: %s", err.Error())
-		return errors.New("error: delete one player item failed")
+	result := new(inventory.Inventory)
+	if err := col.FindOneAndDelete(ctx, bson.M{"player_id": playerId, "item_id": itemId}).Decode(result); err != nil {
+		inventoryLog.Error(ctx, "DeleteOnePlayerItem failed", "error", err)
+		return nil, errors.New("error: delete one player item failed")
 	}
-	log.Printf("Error: DeleteOnePlayerItem result: %v", result)
+	inventoryLog.Debug(ctx, "DeleteOnePlayerItem", "inventory_id", result.Id)
 
-	return nil
+	return result, nil
 }
 
 func (r *inventoryRepository) RemovePlayerItemRes(pctx context.Context, cfg *config.Config, req *payment.PaymentTransferRes) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: RemovePlayerItemRes failed: %s", err.Error())
+		inventoryLog.Error(pctx, "RemovePlayerItemRes failed", "error", err)
 		return errors.New("error: docked player money res failed")
 	}
 
 	if err := queue.PushMessageWithKeyToQueue(
+		pctx,
 		[]string{cfg.Kafka.Url},
 		cfg.Kafka.ApiKey,
 		cfg.Kafka.Secret,
@@ -258,7 +264,7 @@ func (r *inventoryRepository) RemovePlayerItemRes(pctx context.Context, cfg *con
 		"sell",
 		reqInBytes,
 	); err != nil {
-		log.Printf("Error: RemovePlayerItemRes failed: %s", err.Error())
+		inventoryLog.Error(pctx, "RemovePlayerItemRes failed", "error", err)
 		return errors.New("error: docked player money res failed")
 	}
 
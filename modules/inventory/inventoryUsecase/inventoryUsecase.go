@@ -2,7 +2,7 @@ package inventoryUsecase
 
 import (
 	"context"
-	"fmt"
+	"net/url"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/inventory"
@@ -11,7 +11,8 @@ import (
 	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/payment"
-	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/cursor"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/locale"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -45,39 +46,50 @@ func (u *inventoryUsecase) UpsertOffset(pctx context.Context, offset int64) erro
 }
 
 func (u *inventoryUsecase) FindPlayerItems(pctx context.Context, cfg *config.Config, playerId string, req *inventory.InventorySearchReq) (*models.PaginateRes, error) {
-	// Filter
-	filter := bson.D{}
+	baseUrl := cfg.Paginate.InventoryNextPageBasedUrl + "/" + playerId
+	query := url.Values{"player_id": {playerId}}
+
+	page, err := cursor.Open(pctx, cfg.Paginate.CursorSecret, req.Start, "_id", 1, query.Encode())
+	if err != nil {
+		return nil, err
+	}
 
-	if req.Start != "" {
-		filter = append(filter, bson.E{"_id", bson.D{{"$gt", utils.ConvertToObjectId(req.Start)}}})
+	// Filter
+	filter := bson.D{{"player_id", playerId}}
+	if cursorFilter, ok := page.Filter(); ok {
+		filter = append(filter, cursorFilter)
 	}
-	filter = append(filter, bson.E{"player_id", playerId})
 
 	// Option
 	opts := make([]*options.FindOptions, 0)
 
-	opts = append(opts, options.Find().SetSort(bson.D{{"_id", 1}}))
-	opts = append(opts, options.Find().SetLimit(int64(req.Limit)))
+	opts = append(opts, options.Find().SetSort(page.Sort()))
+	opts = append(opts, options.Find().SetLimit(page.Limit(req.Limit)))
 
 	// Find
 	inventoryData, err := u.inventoryRepository.FindPlayerItems(pctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
+	inventoryData, hasPrev, hasNext := cursor.Slice(page, inventoryData, req.Limit)
+
+	// Count
+	total, err := u.inventoryRepository.CountPlayerItems(pctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.PaginateRes{
+		Data:  make([]*inventory.ItemInInventory, 0),
+		Total: total,
+		Limit: req.Limit,
+		First: models.FirstPaginate{
+			Href: cursor.Href(baseUrl, nil, req.Limit, ""),
+		},
+	}
 
 	if len(inventoryData) == 0 {
-		return &models.PaginateRes{
-			Data:  make([]*inventory.ItemInInventory, 0),
-			Total: 0,
-			Limit: req.Limit,
-			First: models.FirstPaginate{
-				Href: fmt.Sprintf("%s/%s?limit=%d", cfg.Paginate.ItemNextPageBasedUrl, playerId, req.Limit),
-			},
-			Next: models.NextPaginate{
-				Start: "",
-				Href:  "",
-			},
-		}, nil
+		return res, nil
 	}
 
 	itemData, err := u.inventoryRepository.FindItemsInIds(pctx, cfg.Grpc.ItemUrl, &itemPb.FindItemsInIdsReq{
@@ -88,58 +100,83 @@ func (u *inventoryUsecase) FindPlayerItems(pctx context.Context, cfg *config.Con
 			}
 			return itemIds
 		}(),
+		Locale: locale.FromContext(pctx),
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	itemMaps := make(map[string]*item.ItemShowCase)
 	for _, v := range itemData.Items {
 		itemMaps[v.Id] = &item.ItemShowCase{
-			ItemId:   v.Id,
-			Title:    v.Title,
-			Price:    v.Price,
-			ImageUrl: v.ImageUrl,
-			Damage:   int(v.Damage),
+			ItemId:       v.Id,
+			Title:        v.Title,
+			Description:  v.Description,
+			Price:        v.Price,
+			ImageUrl:     v.ImageUrl,
+			ThumbnailUrl: v.ThumbnailUrl,
+			Damage:       int(v.Damage),
+			Category:     v.Category,
+			Rarity:       v.Rarity,
+			Slot:         v.Slot,
+			Attributes: item.ItemAttributes{
+				Defense:    int(v.GetAttributes().GetDefense()),
+				Durability: int(v.GetAttributes().GetDurability()),
+				Effects:    v.GetAttributes().GetEffects(),
+			},
 		}
 	}
 
 	results := make([]*inventory.ItemInInventory, 0)
 	for _, v := range inventoryData {
+		showCase, ok := itemMaps[v.ItemId]
+		if !ok {
+			showCase = &item.ItemShowCase{ItemId: v.ItemId}
+		}
 		results = append(results, &inventory.ItemInInventory{
 			InventoryId: v.Id,
 			PlayerId:    v.PlayerId,
 			ItemShowCase: &item.ItemShowCase{
-				ItemId:   v.ItemId,
-				Title:    itemMaps[v.ItemId].Title,
-				Price:    itemMaps[v.ItemId].Price,
-				Damage:   itemMaps[v.ItemId].Damage,
-				ImageUrl: itemMaps[v.ItemId].ImageUrl,
+				ItemId:       v.ItemId,
+				Title:        showCase.Title,
+				Description:  showCase.Description,
+				Price:        showCase.Price,
+				Damage:       showCase.Damage,
+				ImageUrl:     showCase.ImageUrl,
+				ThumbnailUrl: showCase.ThumbnailUrl,
+				Category:     showCase.Category,
+				Rarity:       showCase.Rarity,
+				Slot:         showCase.Slot,
+				Attributes:   showCase.Attributes,
 			},
 		})
 	}
+	res.Data = results
 
-	// Count
-	total, err := u.inventoryRepository.CountPlayerItems(pctx, playerId)
-	if err != nil {
-		return nil, err
+	if hasNext {
+		start := page.Token(pctx, cursor.Next, nil, results[len(results)-1].InventoryId)
+		res.Next = models.NextPaginate{
+			Start: start,
+			Href:  cursor.Href(baseUrl, nil, req.Limit, start),
+		}
 	}
 
-	return &models.PaginateRes{
-		Data:  results,
-		Total: total,
-		Limit: req.Limit,
-		First: models.FirstPaginate{
-			Href: fmt.Sprintf("%s/%s?limit=%d", cfg.Paginate.ItemNextPageBasedUrl, playerId, req.Limit),
-		},
-		Next: models.NextPaginate{
-			Start: results[len(results)-1].InventoryId,
-			Href:  fmt.Sprintf("%s/%s?limit=%d&start=%s", cfg.Paginate.ItemNextPageBasedUrl, playerId, req.Limit, results[len(results)-1].ItemId),
-		},
-	}, nil
+	if hasPrev {
+		start := page.Token(pctx, cursor.Prev, nil, results[0].InventoryId)
+		res.Prev = models.PrevPaginate{
+			Start: start,
+			Href:  cursor.Href(baseUrl, nil, req.Limit, start),
+		}
+	}
+
+	return res, nil
 }
 
 func (u *inventoryUsecase) AddPlayerItemRes(pctx context.Context, cfg *config.Config, req *inventory.UpdateInventoryReq) {
 	inventoryId, err := u.inventoryRepository.InsertOnePlayerItem(pctx, &inventory.Inventory{
-		PlayerId: req.PlayerId,
-		ItemId:   req.ItemId,
+		PlayerId:  req.PlayerId,
+		ItemId:    req.ItemId,
+		PaidPrice: &req.PaidPrice,
 	})
 	if err != nil {
 		u.inventoryRepository.AddPlayerItemRes(pctx, cfg, &payment.PaymentTransferRes{
@@ -175,7 +212,8 @@ func (u *inventoryUsecase) RemovePlayerItemRes(pctx context.Context, cfg *config
 		return
 	}
 
-	if err := u.inventoryRepository.DeleteOnePlayerItem(pctx, req.PlayerId, req.ItemId); err != nil {
+	removed, err := u.inventoryRepository.DeleteOnePlayerItem(pctx, req.PlayerId, req.ItemId)
+	if err != nil {
 		u.inventoryRepository.RemovePlayerItemRes(pctx, cfg, &payment.PaymentTransferRes{
 			InventoryId:   "",
 			TransactionId: "",
@@ -194,6 +232,7 @@ func (u *inventoryUsecase) RemovePlayerItemRes(pctx context.Context, cfg *config
 		ItemId:        req.ItemId,
 		Amount:        0,
 		Error:         "",
+		PaidPrice:     removed.PaidPrice,
 	})
 }
 
@@ -203,7 +242,8 @@ func (u *inventoryUsecase) RollbackAddPlayerItem(pctx context.Context, cfg *conf
 
 func (u *inventoryUsecase) RollbackRemovePlayerItem(pctx context.Context, cfg *config.Config, req *inventory.RollbackPlayerInventoryReq) {
 	u.inventoryRepository.InsertOnePlayerItem(pctx, &inventory.Inventory{
-		PlayerId: req.PlayerId,
-		ItemId:   req.ItemId,
+		PlayerId:  req.PlayerId,
+		ItemId:    req.ItemId,
+		PaidPrice: req.PaidPrice,
 	})
 }
@@ -0,0 +1,57 @@
+package item
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// BundleIdPrefix tells a bundle from an item where both are accepted, as in a purchase
+	BundleIdPrefix = "bundle:"
+
+	MaxBundleItems    = 10
+	MaxBundleQuantity = 10
+)
+
+// ProrateBundle shares a bundle price out over its units by what each costs alone, so every
+// granted item is recorded at the part of the price it was bought for. The shares are whole
+// cents that add up to price, the cents rounding leaves over go to the units closest to the
+// next cent. Units that all cost nothing alone share the price evenly.
+func ProrateBundle(price float64, unitPrices []float64) []float64 {
+	shares := make([]float64, len(unitPrices))
+	if len(unitPrices) == 0 {
+		return shares
+	}
+
+	total := 0.0
+	for _, unitPrice := range unitPrices {
+		total += unitPrice
+	}
+
+	cents := math.Round(price * 100)
+	left := cents
+	fractions := make([]float64, len(unitPrices))
+	for i, unitPrice := range unitPrices {
+		exact := cents / float64(len(unitPrices))
+		if total > 0 {
+			exact = cents * unitPrice / total
+		}
+		shares[i] = math.Floor(exact)
+		fractions[i] = exact - shares[i]
+		left -= shares[i]
+	}
+
+	order := make([]int, len(unitPrices))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return fractions[order[i]] > fractions[order[j]] })
+	for i := 0; i < int(left); i++ {
+		shares[order[i%len(order)]]++
+	}
+
+	for i := range shares {
+		shares[i] /= 100
+	}
+	return shares
+}
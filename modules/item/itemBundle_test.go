@@ -0,0 +1,40 @@
+package item
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestProrateBundle(t *testing.T) {
+	tests := []struct {
+		name       string
+		price      float64
+		unitPrices []float64
+		want       []float64
+	}{
+		{"no units", 10, nil, []float64{}},
+		{"even prices", 10, []float64{5, 5}, []float64{5, 5}},
+		{"by unit price", 9, []float64{10, 5}, []float64{6, 3}},
+		{"half cents", 7.5, []float64{4, 4, 2}, []float64{3, 3, 1.5}},
+		{"left over cent to the first", 10, []float64{1, 1, 1}, []float64{3.34, 3.33, 3.33}},
+		{"left over cent to the closest", 1, []float64{2, 1}, []float64{0.67, 0.33}},
+		{"free units split evenly", 1, []float64{0, 0, 0}, []float64{0.34, 0.33, 0.33}},
+		{"free bundle", 0, []float64{3, 2}, []float64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		got := ProrateBundle(tt.price, tt.unitPrices)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ProrateBundle(%v, %v) = %v, want %v", tt.name, tt.price, tt.unitPrices, got, tt.want)
+		}
+
+		cents := 0.0
+		for _, share := range got {
+			cents += math.Round(share * 100)
+		}
+		if len(got) > 0 && cents != math.Round(tt.price*100) {
+			t.Errorf("%s: shares add up to %v cents, want %v", tt.name, cents, math.Round(tt.price*100))
+		}
+	}
+}
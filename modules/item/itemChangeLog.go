@@ -0,0 +1,55 @@
+package item
+
+import (
+	"reflect"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	ChangeActionCreate  = "create"
+	ChangeActionEdit    = "edit"
+	ChangeActionEnable  = "enable"
+	ChangeActionDisable = "disable"
+	ChangeActionDelete  = "delete"
+	ChangeActionRestore = "restore"
+	ChangeActionImport  = "import"
+)
+
+// Diff lists the fields of an update that change the item, set is keyed by bson field name
+// like the $set of the update. Both sides go through bson, so values compare as stored.
+func Diff(before *Item, set map[string]any) []*ItemChange {
+	stored := toDocument(before)
+	after := toDocument(set)
+
+	fields := make([]string, 0, len(after))
+	for field := range after {
+		if field != "updated_at" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make([]*ItemChange, 0)
+	for _, field := range fields {
+		if !reflect.DeepEqual(stored[field], after[field]) {
+			changes = append(changes, &ItemChange{
+				Field:  field,
+				Before: stored[field],
+				After:  after[field],
+			})
+		}
+	}
+	return changes
+}
+
+func toDocument(value any) bson.M {
+	document := bson.M{}
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return document
+	}
+	bson.Unmarshal(data, &document)
+	return document
+}
@@ -7,14 +7,135 @@ import (
 )
 
 type (
+	// Item.ImageKey and ThumbnailKey point at an uploaded image in the blob storage, they
+	// take the place of ImageUrl when set. Stock is the number left for sale, nil when the
+	// supply is unlimited, and PurchaseLimit is how many one player may buy, 0 for no limit.
+	// Sku is optional and unique, it ties the item to a row of a bulk import. A deleted item
+	// has DeletedAt set and is disabled, it stays in the collection so it can be restored.
+	// Title and Description are in the default locale, Titles and Descriptions hold their
+	// translations keyed by locale.
 	Item struct {
+		Id            primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+		Sku           string             `json:"sku" bson:"sku,omitempty"`
+		Title         string             `json:"title" bson:"title"`
+		Titles        map[string]string  `json:"titles" bson:"titles,omitempty"`
+		Description   string             `json:"description" bson:"description,omitempty"`
+		Descriptions  map[string]string  `json:"descriptions" bson:"descriptions,omitempty"`
+		Price         float64            `json:"price" bson:"price"`
+		Damage        int                `json:"damage" bson:"damage"`
+		ImageUrl      string             `json:"image_url" bson:"image_url"`
+		ImageKey      string             `json:"image_key" bson:"image_key,omitempty"`
+		ThumbnailKey  string             `json:"thumbnail_key" bson:"thumbnail_key,omitempty"`
+		Category      string             `json:"category" bson:"category"`
+		Rarity        string             `json:"rarity" bson:"rarity"`
+		Slot          string             `json:"slot" bson:"slot"`
+		Attributes    ItemAttributes     `json:"attributes" bson:"attributes"`
+		SoldCount     int64              `json:"sold_count" bson:"sold_count"`
+		Stock         *int64             `json:"stock" bson:"stock,omitempty"`
+		PurchaseLimit int64              `json:"purchase_limit" bson:"purchase_limit,omitempty"`
+		UsageStatus   bool               `json:"usage_status" bson:"usage_status"`
+		DeletedAt     *time.Time         `json:"deleted_at" bson:"deleted_at,omitempty"`
+		CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+		UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	}
+
+	// ItemAttributes are the attributes a category allows, see itemSchema.go.
+	ItemAttributes struct {
+		Defense    int      `json:"defense,omitempty" bson:"defense,omitempty"`
+		Durability int      `json:"durability,omitempty" bson:"durability,omitempty"`
+		Effects    []string `json:"effects,omitempty" bson:"effects,omitempty"`
+	}
+
+	// ItemPriceHistory is written on every change of the price a player pays, sales
+	// included, so the price of an item at any time can be looked up.
+	ItemPriceHistory struct {
+		Id             primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+		ItemId         primitive.ObjectID `json:"item_id" bson:"item_id"`
+		Price          float64            `json:"price" bson:"price"`
+		EffectivePrice float64            `json:"effective_price" bson:"effective_price"`
+		// Reason is one of create, edit, import, schedule, sale_start or sale_end
+		Reason     string             `json:"reason" bson:"reason"`
+		ScheduleId primitive.ObjectID `json:"schedule_id" bson:"schedule_id,omitempty"`
+		ChangedBy  string             `json:"changed_by" bson:"changed_by,omitempty"`
+		CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	}
+
+	// ItemPriceSchedule is a future price change or a sale window. A price change is
+	// pending until it is applied, a sale goes from pending to active to done.
+	ItemPriceSchedule struct {
+		Id         primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+		ItemId     primitive.ObjectID `json:"item_id" bson:"item_id"`
+		Kind       string             `json:"kind" bson:"kind"`
+		Price      float64            `json:"price" bson:"price,omitempty"`
+		PercentOff float64            `json:"percent_off" bson:"percent_off,omitempty"`
+		StartAt    time.Time          `json:"start_at" bson:"start_at"`
+		EndAt      time.Time          `json:"end_at" bson:"end_at,omitempty"`
+		Status     string             `json:"status" bson:"status"`
+		CreatedBy  string             `json:"created_by" bson:"created_by,omitempty"`
+		CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+		UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+	}
+
+	// ItemPlayerPurchase counts what a player has bought of an item with a purchase limit.
+	ItemPlayerPurchase struct {
+		Id        primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+		ItemId    primitive.ObjectID `json:"item_id" bson:"item_id"`
+		PlayerId  string             `json:"player_id" bson:"player_id"`
+		Count     int64              `json:"count" bson:"count"`
+		UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	}
+
+	// ItemReservation holds the stock and purchase counts taken for one purchase, its id is
+	// made by the payment service. Status is reserving, reserved or released.
+	ItemReservation struct {
+		Id        string                  `json:"_id" bson:"_id"`
+		PlayerId  string                  `json:"player_id" bson:"player_id"`
+		Items     []*ItemReservationDatum `json:"items" bson:"items"`
+		Status    string                  `json:"status" bson:"status"`
+		CreatedAt time.Time               `json:"created_at" bson:"created_at"`
+		UpdatedAt time.Time               `json:"updated_at" bson:"updated_at"`
+	}
+
+	// ItemReservationDatum tells what was taken for an item, so only that is given back.
+	ItemReservationDatum struct {
+		ItemId     primitive.ObjectID `json:"item_id" bson:"item_id"`
+		Quantity   int64              `json:"quantity" bson:"quantity"`
+		StockTaken bool               `json:"stock_taken" bson:"stock_taken"`
+		LimitTaken bool               `json:"limit_taken" bson:"limit_taken"`
+	}
+
+	// ItemBundle sells several items at its own price, a component bought more than once is
+	// listed once with its quantity.
+	ItemBundle struct {
 		Id          primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
 		Title       string             `json:"title" bson:"title"`
 		Price       float64            `json:"price" bson:"price"`
-		Damage      int                `json:"damage" bson:"damage"`
 		ImageUrl    string             `json:"image_url" bson:"image_url"`
+		Items       []*ItemBundleDatum `json:"items" bson:"items"`
 		UsageStatus bool               `json:"usage_status" bson:"usage_status"`
 		CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
 		UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
 	}
+
+	ItemBundleDatum struct {
+		ItemId   primitive.ObjectID `json:"item_id" bson:"item_id"`
+		Quantity int64              `json:"quantity" bson:"quantity"`
+	}
+
+	// ItemChangeLog is written on every change an admin makes to an item, Changes holds each
+	// changed field before and after.
+	ItemChangeLog struct {
+		Id        primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+		ItemId    primitive.ObjectID `json:"item_id" bson:"item_id"`
+		Action    string             `json:"action" bson:"action"`
+		Changes   []*ItemChange      `json:"changes" bson:"changes"`
+		ChangedBy string             `json:"changed_by" bson:"changed_by,omitempty"`
+		CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	}
+
+	ItemChange struct {
+		Field  string `json:"field" bson:"field"`
+		Before any    `json:"before" bson:"before"`
+		After  any    `json:"after" bson:"after"`
+	}
 )
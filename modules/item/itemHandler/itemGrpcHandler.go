@@ -5,6 +5,7 @@ import (
 
 	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemUsecase"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/locale"
 )
 
 type (
@@ -18,6 +19,21 @@ func NewItemGrpcHandler(itemUsecase itemUsecase.ItemUsecaseService) *itemGrpcHan
 	return &itemGrpcHandler{itemUsecase: itemUsecase}
 }
 
+// FindItemsInIds negotiates the locale from the request like an http request does from
+// its Accept-Language header.
 func (g *itemGrpcHandler) FindItemsInIds(ctx context.Context, req *itemPb.FindItemsInIdsReq) (*itemPb.FindItemsInIdsRes, error) {
+	ctx = locale.WithLocale(ctx, locale.Negotiate(req.GetLocale()))
 	return g.itemUsecase.FindItemInIds(ctx, req)
 }
+
+func (g *itemGrpcHandler) IncreaseSoldCount(ctx context.Context, req *itemPb.IncreaseSoldCountReq) (*itemPb.IncreaseSoldCountRes, error) {
+	return g.itemUsecase.IncreaseSoldCount(ctx, req)
+}
+
+func (g *itemGrpcHandler) ReserveStock(ctx context.Context, req *itemPb.ReserveStockReq) (*itemPb.ReserveStockRes, error) {
+	return g.itemUsecase.ReserveStock(ctx, req)
+}
+
+func (g *itemGrpcHandler) ReleaseStock(ctx context.Context, req *itemPb.ReleaseStockReq) (*itemPb.ReleaseStockRes, error) {
+	return g.itemUsecase.ReleaseStock(ctx, req)
+}
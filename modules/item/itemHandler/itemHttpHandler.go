@@ -1,9 +1,13 @@
 package itemHandler
 
 import (
-	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strings"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
@@ -21,6 +25,19 @@ type (
 		FindManyItems(c echo.Context) error
 		EditItem(c echo.Context) error
 		EnableOrDisableItem(c echo.Context) error
+		DeleteItem(c echo.Context) error
+		RestoreItem(c echo.Context) error
+		FindItemHistory(c echo.Context) error
+		SchedulePrice(c echo.Context) error
+		FindPriceSchedules(c echo.Context) error
+		CancelPriceSchedule(c echo.Context) error
+		FindPriceHistory(c echo.Context) error
+		ImportItems(c echo.Context) error
+		ExportItems(c echo.Context) error
+		CreateBundle(c echo.Context) error
+		FindOneBundle(c echo.Context) error
+		FindManyBundles(c echo.Context) error
+		EnableOrDisableBundle(c echo.Context) error
 	}
 
 	itemHttpHandler struct {
@@ -29,12 +46,15 @@ type (
 	}
 )
 
+// exportFlushRows is how many items an export sends at a time
+const exportFlushRows = 100
+
 func NewItemHttpHandler(cfg *config.Config, itemUsecase itemUsecase.ItemUsecaseService) ItemHttpHandlerService {
 	return &itemHttpHandler{cfg: cfg, itemUsecase: itemUsecase}
 }
 
 func (h *itemHttpHandler) CreateItem(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
@@ -44,7 +64,16 @@ func (h *itemHttpHandler) CreateItem(c echo.Context) error {
 		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
 
-	res, err := h.itemUsecase.CreateItem(ctx, req)
+	image, err := h.itemImage(c, map[string]any{
+		"attributes":   &req.Attributes,
+		"titles":       &req.Titles,
+		"descriptions": &req.Descriptions,
+	})
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.itemUsecase.CreateItem(ctx, req, image)
 	if err != nil {
 		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
@@ -52,8 +81,57 @@ func (h *itemHttpHandler) CreateItem(c echo.Context) error {
 	return response.SuccessResponse(c, http.StatusCreated, res)
 }
 
+// itemImage reads the "image" file of a multipart request, nil for a json request. A form
+// has no nested fields, so objects like the attributes are sent as json strings and read
+// into jsonFields by name.
+func (h *itemHttpHandler) itemImage(c echo.Context, jsonFields map[string]any) (*item.ItemImageReq, error) {
+	if !strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		return nil, nil
+	}
+
+	for name, field := range jsonFields {
+		if value := c.FormValue(name); value != "" {
+			if err := json.Unmarshal([]byte(value), field); err != nil {
+				return nil, errors.New("error: " + name + " must be a json object")
+			}
+		}
+	}
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		if errors.Is(err, http.ErrMissingFile) {
+			return nil, nil
+		}
+		return nil, errors.New("error: image is invalid")
+	}
+
+	maxSize := h.cfg.Blob.MaxImageSize
+	if fileHeader.Size > maxSize {
+		return nil, fmt.Errorf("error: image is larger than %d bytes", maxSize)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, errors.New("error: image is invalid")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, errors.New("error: image is invalid")
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("error: image is larger than %d bytes", maxSize)
+	}
+
+	return &item.ItemImageReq{
+		Filename: fileHeader.Filename,
+		Data:     data,
+	}, nil
+}
+
 func (h *itemHttpHandler) FindOneItem(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
 
@@ -67,7 +145,7 @@ func (h *itemHttpHandler) FindOneItem(c echo.Context) error {
 }
 
 func (h *itemHttpHandler) FindManyItems(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
@@ -77,7 +155,7 @@ func (h *itemHttpHandler) FindManyItems(c echo.Context) error {
 		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
 
-	res, err := h.itemUsecase.FindManyItems(ctx, h.cfg.Paginate.ItemNextPageBasedUrl, req)
+	res, err := h.itemUsecase.FindManyItems(ctx, h.cfg, req)
 	if err != nil {
 		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
@@ -86,7 +164,7 @@ func (h *itemHttpHandler) FindManyItems(c echo.Context) error {
 }
 
 func (h *itemHttpHandler) EditItem(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
 
@@ -98,7 +176,16 @@ func (h *itemHttpHandler) EditItem(c echo.Context) error {
 		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
 
-	res, err := h.itemUsecase.EditItem(ctx, itemId, req)
+	image, err := h.itemImage(c, map[string]any{
+		"attributes":   &req.Attributes,
+		"titles":       &req.Titles,
+		"descriptions": &req.Descriptions,
+	})
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.itemUsecase.EditItem(ctx, itemId, req, image)
 	if err != nil {
 		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
 	}
@@ -108,7 +195,7 @@ func (h *itemHttpHandler) EditItem(c echo.Context) error {
 }
 
 func (h *itemHttpHandler) EnableOrDisableItem(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
 
@@ -121,3 +208,308 @@ func (h *itemHttpHandler) EnableOrDisableItem(c echo.Context) error {
 		"message": fmt.Sprintf("item_id: %s is successfully is activated to: %v", itemId, res),
 	})
 }
+
+func (h *itemHttpHandler) DeleteItem(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
+
+	if err := h.itemUsecase.DeleteItem(ctx, itemId); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, map[string]any{
+		"message": fmt.Sprintf("item_id: %s is deleted", itemId),
+	})
+}
+
+func (h *itemHttpHandler) RestoreItem(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
+
+	res, err := h.itemUsecase.RestoreItem(ctx, itemId)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *itemHttpHandler) FindItemHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(item.ItemHistoryReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.itemUsecase.FindItemHistory(ctx, h.cfg, itemId, req)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *itemHttpHandler) SchedulePrice(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(item.ItemPriceScheduleReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.itemUsecase.SchedulePrice(ctx, itemId, req)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusCreated, res)
+}
+
+func (h *itemHttpHandler) FindPriceSchedules(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
+
+	res, err := h.itemUsecase.FindPriceSchedules(ctx, itemId)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *itemHttpHandler) CancelPriceSchedule(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
+	scheduleId := c.Param("schedule_id")
+
+	if err := h.itemUsecase.CancelPriceSchedule(ctx, itemId, scheduleId); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, map[string]any{
+		"message": fmt.Sprintf("schedule_id: %s is cancelled", scheduleId),
+	})
+}
+
+func (h *itemHttpHandler) FindPriceHistory(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	itemId := strings.TrimPrefix(c.Param("item_id"), "item:")
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(item.ItemPriceHistoryReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.itemUsecase.FindPriceHistory(ctx, h.cfg, itemId, req)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+// ImportItems takes a csv or json file, either as the body or as the "file" field of a
+// multipart form. With dry_run=true the rows are only checked.
+func (h *itemHttpHandler) ImportItems(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	rows, err := importRows(c)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.itemUsecase.ImportItems(ctx, rows, c.QueryParam("dry_run") == "true")
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+// importRows picks the format from the file name of an upload or from the content type.
+func importRows(c echo.Context) ([]*item.ItemImportRow, error) {
+	contentType := c.Request().Header.Get(echo.HeaderContentType)
+
+	if strings.HasPrefix(contentType, echo.MIMEMultipartForm) {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("error: file is required")
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, errors.New("error: file is invalid")
+		}
+		defer file.Close()
+
+		switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+		case ".csv":
+			return item.ParseImportCsv(file)
+		case ".json":
+			return item.ParseImportJson(file)
+		}
+		return nil, errors.New("error: file must be .csv or .json")
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return item.ParseImportCsv(c.Request().Body)
+	case strings.HasPrefix(contentType, echo.MIMEApplicationJSON):
+		return item.ParseImportJson(c.Request().Body)
+	}
+	return nil, errors.New("error: content type must be text/csv, application/json or multipart/form-data")
+}
+
+// ExportItems streams the whole catalogue as csv (default) or as a json array. The status
+// goes out with the first item, an error after that can only cut the stream short.
+func (h *itemHttpHandler) ExportItems(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		return response.ErrorResponse(c, http.StatusBadRequest, "error: format must be csv or json")
+	}
+
+	res := c.Response()
+	writer := csv.NewWriter(res)
+	encoder := json.NewEncoder(res)
+	count := 0
+
+	start := func() {
+		if format == "csv" {
+			res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		} else {
+			res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		}
+		res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="items.`+format+`"`)
+		res.WriteHeader(http.StatusOK)
+
+		if format == "csv" {
+			writer.Write(item.ExportColumns)
+		} else {
+			res.Write([]byte("["))
+		}
+	}
+
+	err := h.itemUsecase.ExportItems(ctx, func(row *item.ItemExportRow) error {
+		if count == 0 {
+			start()
+		} else if format == "json" {
+			res.Write([]byte(","))
+		}
+
+		var err error
+		if format == "csv" {
+			err = writer.Write(row.CsvRecord())
+		} else {
+			err = encoder.Encode(row)
+		}
+		if err != nil {
+			return err
+		}
+
+		count++
+		if count%exportFlushRows == 0 {
+			writer.Flush()
+			res.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !res.Committed {
+			return response.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		}
+		itemLog.Error(ctx, "ExportItems failed", "exported", count, "error", err)
+		return nil
+	}
+
+	if count == 0 {
+		start()
+	}
+	if format == "csv" {
+		writer.Flush()
+	} else {
+		res.Write([]byte("]"))
+	}
+
+	return nil
+}
+
+func (h *itemHttpHandler) CreateBundle(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(item.CreateBundleReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.itemUsecase.CreateBundle(ctx, req)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusCreated, res)
+}
+
+func (h *itemHttpHandler) FindOneBundle(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	bundleId := strings.TrimPrefix(c.Param("bundle_id"), item.BundleIdPrefix)
+
+	res, err := h.itemUsecase.FindOneBundle(ctx, bundleId)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *itemHttpHandler) FindManyBundles(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	res, err := h.itemUsecase.FindManyBundles(ctx)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *itemHttpHandler) EnableOrDisableBundle(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	bundleId := strings.TrimPrefix(c.Param("bundle_id"), item.BundleIdPrefix)
+
+	res, err := h.itemUsecase.EnableOrDisableBundle(ctx, bundleId)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, map[string]any{
+		"message": fmt.Sprintf("bundle_id: %s is successfully is activated to: %v", bundleId, res),
+	})
+}
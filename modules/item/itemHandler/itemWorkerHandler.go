@@ -0,0 +1,57 @@
+package itemHandler
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemUsecase"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/probe"
+)
+
+type (
+	ItemWorkerHandlerService interface {
+		ApplyPriceSchedules()
+	}
+
+	itemWorkerHandler struct {
+		itemUsecase itemUsecase.ItemUsecaseService
+	}
+)
+
+var itemLog = logger.New("item")
+
+func NewItemWorkerHandler(itemUsecase itemUsecase.ItemUsecaseService) ItemWorkerHandlerService {
+	return &itemWorkerHandler{itemUsecase: itemUsecase}
+}
+
+// ApplyPriceSchedules checks for due price changes and sales on every heartbeat, so a
+// schedule is applied at most a heartbeat interval late.
+func (h *itemWorkerHandler) ApplyPriceSchedules() {
+	ctx := context.Background()
+
+	heartbeat := probe.NewHeartbeat("ApplyPriceSchedules")
+	defer heartbeat.Stop()
+
+	itemLog.Info(ctx, "Start ApplyPriceSchedules")
+
+	sigchan := make(chan os.Signal, 1)
+	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(probe.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			h.itemUsecase.ApplyPriceSchedules(ctx)
+			heartbeat.Beat()
+		case <-sigchan:
+			itemLog.Info(ctx, "Stop ApplyPriceSchedules")
+			return
+		}
+	}
+}
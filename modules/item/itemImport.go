@@ -0,0 +1,199 @@
+package item
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	ImportActionCreate = "create"
+	ImportActionUpdate = "update"
+	ImportActionSkip   = "skip"
+
+	// MaxImportRows bounds one import, a bigger catalogue is sent in parts
+	MaxImportRows = 1000
+)
+
+// ExportColumns is the header of a csv export, an import reads the same columns by name
+// and ignores the ones it does not know.
+var ExportColumns = []string{
+	"item_id", "sku", "title", "price", "damage", "image_url", "category", "rarity", "slot",
+	"attributes", "stock", "purchase_limit", "usage_status", "description", "titles", "descriptions",
+}
+
+// ParseImportCsv reads a csv with a header row. A value that cannot be read is noted on its
+// row, only a file that is not csv at all fails as a whole.
+func ParseImportCsv(r io.Reader) ([]*ItemImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("error: csv header is missing")
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("error: csv has no title column")
+	}
+
+	rows := make([]*ItemImportRow, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return nil, errors.New("error: csv is invalid at line " + strconv.Itoa(parseErr.Line))
+			}
+			return nil, errors.New("error: csv is invalid")
+		}
+		if len(rows) == MaxImportRows {
+			return nil, errors.New("error: import has more than " + strconv.Itoa(MaxImportRows) + " rows")
+		}
+
+		rows = append(rows, csvRow(len(rows)+1, columns, record))
+	}
+
+	return rows, nil
+}
+
+func csvRow(number int, columns map[string]int, record []string) *ItemImportRow {
+	row := &ItemImportRow{Row: number}
+	value := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	invalid := func(name string) {
+		row.Errors = append(row.Errors, name+" is invalid")
+	}
+
+	row.Sku = value("sku")
+	row.Title = value("title")
+	row.Description = value("description")
+	row.ImageUrl = value("image_url")
+	row.Category = value("category")
+	row.Rarity = value("rarity")
+	row.Slot = value("slot")
+
+	if v := value("price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			invalid("price")
+		}
+		row.Price = price
+	}
+	if v := value("damage"); v != "" {
+		damage, err := strconv.Atoi(v)
+		if err != nil {
+			invalid("damage")
+		}
+		row.Damage = damage
+	}
+	if v := value("attributes"); v != "" {
+		if err := json.Unmarshal([]byte(v), &row.Attributes); err != nil {
+			row.Errors = append(row.Errors, "attributes must be a json object")
+		}
+	}
+	if v := value("titles"); v != "" {
+		if err := json.Unmarshal([]byte(v), &row.Titles); err != nil {
+			row.Errors = append(row.Errors, "titles must be a json object")
+		}
+	}
+	if v := value("descriptions"); v != "" {
+		if err := json.Unmarshal([]byte(v), &row.Descriptions); err != nil {
+			row.Errors = append(row.Errors, "descriptions must be a json object")
+		}
+	}
+	if v := value("stock"); v != "" {
+		stock, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			invalid("stock")
+		}
+		row.Stock = &stock
+	}
+	if v := value("purchase_limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			invalid("purchase_limit")
+		}
+		row.PurchaseLimit = limit
+	}
+
+	return row
+}
+
+// ParseImportJson reads a json array of items. Like a csv row, an item that does not fit
+// the fields is noted on its row.
+func ParseImportJson(r io.Reader) ([]*ItemImportRow, error) {
+	raws := make([]json.RawMessage, 0)
+	if err := json.NewDecoder(r).Decode(&raws); err != nil {
+		return nil, errors.New("error: json must be an array of items")
+	}
+	if len(raws) > MaxImportRows {
+		return nil, errors.New("error: import has more than " + strconv.Itoa(MaxImportRows) + " rows")
+	}
+
+	rows := make([]*ItemImportRow, 0)
+	for i, raw := range raws {
+		row := new(ItemImportRow)
+		if err := json.Unmarshal(raw, row); err != nil {
+			row = &ItemImportRow{Errors: []string{"item is invalid"}}
+		}
+		row.Row = i + 1
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// CsvRecord is the row in the order of ExportColumns.
+func (r *ItemExportRow) CsvRecord() []string {
+	attributes, _ := json.Marshal(r.Attributes)
+	titles, descriptions := "", ""
+	if len(r.Titles) > 0 {
+		data, _ := json.Marshal(r.Titles)
+		titles = string(data)
+	}
+	if len(r.Descriptions) > 0 {
+		data, _ := json.Marshal(r.Descriptions)
+		descriptions = string(data)
+	}
+
+	stock := ""
+	if r.Stock != nil {
+		stock = strconv.FormatInt(*r.Stock, 10)
+	}
+
+	return []string{
+		r.ItemId,
+		r.Sku,
+		r.Title,
+		strconv.FormatFloat(r.Price, 'f', -1, 64),
+		strconv.Itoa(r.Damage),
+		r.ImageUrl,
+		r.Category,
+		r.Rarity,
+		r.Slot,
+		string(attributes),
+		stock,
+		strconv.FormatInt(r.PurchaseLimit, 10),
+		strconv.FormatBool(r.UsageStatus),
+		r.Description,
+		titles,
+		descriptions,
+	}
+}
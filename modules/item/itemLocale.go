@@ -0,0 +1,51 @@
+package item
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/locale"
+)
+
+const (
+	MaxTitleLength       = 64
+	MaxDescriptionLength = 1024
+)
+
+// ParseTranslations checks the translations of a title or a description. Every key is a
+// supported locale other than the default one, whose text is the plain field itself. A
+// blank translation is dropped, nil is returned when none is left.
+func ParseTranslations(field string, translations map[string]string, maxLength int) (map[string]string, error) {
+	results := make(map[string]string)
+	for l, text := range translations {
+		l = strings.ToLower(strings.TrimSpace(l))
+		text = strings.TrimSpace(text)
+
+		if l == locale.Default() {
+			return nil, errors.New("error: " + field + " cannot have the default locale " + l + ", it is the plain field")
+		}
+		if !locale.IsSupported(l) {
+			return nil, errors.New("error: " + field + " has an unsupported locale " + l)
+		}
+		if utf8.RuneCountInString(text) > maxLength {
+			return nil, errors.New("error: " + field + " in " + l + " is at most " + strconv.Itoa(maxLength) + " characters")
+		}
+		if text != "" {
+			results[l] = text
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+	return results, nil
+}
+
+// Localize puts the title and description of l in place of the default ones, a text that
+// is not translated to l stays in the default locale.
+func (s *ItemShowCase) Localize(l string) {
+	s.Title = locale.Translate(l, s.Title, s.Titles)
+	s.Description = locale.Translate(l, s.Description, s.Descriptions)
+}
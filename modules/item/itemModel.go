@@ -1,36 +1,279 @@
 package item
 
-import "github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
+import (
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
+	"google.golang.org/grpc/codes"
+)
 
 type (
 	CreateItemReq struct {
-		Title    string  `json:"title" validate:"required,max=64"`
-		Price    float64 `json:"price" validate:"required"`
-		ImageUrl string  `json:"image_url" validate:"required,max=255"`
-		Damage   int     `json:"damage" validate:"required,max=255"`
+		// Sku is the id of the item in an outside catalogue, an import updates the item by it
+		Sku        string         `json:"sku" form:"sku" validate:"max=64"`
+		Title      string         `json:"title" form:"title" validate:"required,max=64"`
+		Price      float64        `json:"price" form:"price" validate:"required"`
+		ImageUrl   string         `json:"image_url" form:"image_url" validate:"max=255"`
+		Damage     int            `json:"damage" form:"damage" validate:"required,max=255"`
+		Category   string         `json:"category" form:"category" validate:"omitempty,oneof=weapon armour consumable cosmetic"`
+		Rarity     string         `json:"rarity" form:"rarity" validate:"omitempty,oneof=common uncommon rare epic legendary"`
+		Slot       string         `json:"slot" form:"slot" validate:"max=32"`
+		Attributes map[string]any `json:"attributes"`
+		// Title and Description are in the default locale, Titles and Descriptions translate
+		// them, {"th": "..."}
+		Description  string            `json:"description" form:"description" validate:"max=1024"`
+		Titles       map[string]string `json:"titles"`
+		Descriptions map[string]string `json:"descriptions"`
+		// Stock is left out for an unlimited supply, PurchaseLimit is 0 for no limit
+		Stock         *int64 `json:"stock" form:"stock" validate:"omitempty,min=0"`
+		PurchaseLimit int64  `json:"purchase_limit" form:"purchase_limit" validate:"min=0"`
 	}
 
 	ItemShowCase struct {
-		ItemId   string  `json:"item_id"`
-		Title    string  `json:"title"`
-		Price    float64 `json:"price"`
-		Damage   int     `json:"damage"`
-		ImageUrl string  `json:"image_url"`
+		ItemId        string         `json:"item_id"`
+		Sku           string         `json:"sku,omitempty"`
+		Title         string         `json:"title"`
+		Description   string         `json:"description,omitempty"`
+		Price         float64        `json:"price"`
+		Damage        int            `json:"damage"`
+		ImageUrl      string         `json:"image_url"`
+		ThumbnailUrl  string         `json:"thumbnail_url,omitempty"`
+		ImageKey      string         `json:"-"`
+		ThumbnailKey  string         `json:"-"`
+		Category      string         `json:"category"`
+		Rarity        string         `json:"rarity"`
+		Slot          string         `json:"slot"`
+		Attributes    ItemAttributes `json:"attributes"`
+		SoldCount     int64          `json:"sold_count"`
+		Sale          *ItemSale      `json:"sale,omitempty"`
+		Stock         *int64         `json:"stock,omitempty"`
+		PurchaseLimit int64          `json:"purchase_limit,omitempty"`
+		DeletedAt     *time.Time     `json:"-"`
+
+		// Titles and Descriptions are the translations Title and Description are localized from
+		Titles       map[string]string `json:"-"`
+		Descriptions map[string]string `json:"-"`
+	}
+
+	// ItemSale is the sale running on an item, Price is what a player pays during it.
+	ItemSale struct {
+		PercentOff float64   `json:"percent_off"`
+		Price      float64   `json:"price"`
+		EndAt      time.Time `json:"end_at"`
 	}
 
 	ItemSearchReq struct {
-		Title string `query:"title validate:"max=64"`
+		// Q is a full text search on the title, Title is a plain substring match
+		Q         string  `query:"q" validate:"max=64"`
+		Title     string  `query:"title" validate:"max=64"`
+		Category  string  `query:"category" validate:"max=32"`
+		Rarity    string  `query:"rarity" validate:"max=32"`
+		Slot      string  `query:"slot" validate:"max=32"`
+		MinPrice  float64 `query:"min_price" validate:"min=0"`
+		MaxPrice  float64 `query:"max_price" validate:"min=0"`
+		MinDamage int     `query:"min_damage" validate:"min=0"`
+		MaxDamage int     `query:"max_damage" validate:"min=0"`
+		// Sort is one of newest, price_asc, price_desc, damage_asc, damage_desc or popularity,
+		// oldest first when empty
+		Sort string `query:"sort" validate:"max=32"`
 		models.PaginateReq
 	}
 
+	// ItemFacets count the items matching a search by category and by rarity. Each facet
+	// ignores its own filter, so the other values can still be offered to the player.
+	ItemFacets struct {
+		Category []*FacetCount `json:"category" bson:"category"`
+		Rarity   []*FacetCount `json:"rarity" bson:"rarity"`
+	}
+
+	FacetCount struct {
+		Value string `json:"value" bson:"_id"`
+		Count int64  `json:"count" bson:"count"`
+	}
+
 	ItemUpdateReq struct {
-		Title    string  `json:"title" validate:"required,max=64"`
-		Price    float64 `json:"price" validate:"required"`
-		ImageUrl string  `json:"image_url" validate:"required,max=255"`
-		Damage   int     `json:"damage" validate:"required,max=255"`
+		Title      string         `json:"title" form:"title" validate:"required,max=64"`
+		Price      float64        `json:"price" form:"price" validate:"required"`
+		ImageUrl   string         `json:"image_url" form:"image_url" validate:"max=255"`
+		Damage     int            `json:"damage" form:"damage" validate:"required,max=255"`
+		Category   string         `json:"category" form:"category" validate:"omitempty,oneof=weapon armour consumable cosmetic"`
+		Rarity     string         `json:"rarity" form:"rarity" validate:"omitempty,oneof=common uncommon rare epic legendary"`
+		Slot       string         `json:"slot" form:"slot" validate:"max=32"`
+		Attributes map[string]any `json:"attributes"`
+		// Description is kept when left out, Titles and Descriptions replace all the
+		// translations when sent and {} removes them
+		Description  string            `json:"description" form:"description" validate:"max=1024"`
+		Titles       map[string]string `json:"titles"`
+		Descriptions map[string]string `json:"descriptions"`
+		// Stock and PurchaseLimit are kept when left out, a stock of -1 makes the supply
+		// unlimited again and a PurchaseLimit of 0 removes the limit
+		Stock         *int64 `json:"stock" form:"stock" validate:"omitempty,min=-1"`
+		PurchaseLimit *int64 `json:"purchase_limit" form:"purchase_limit" validate:"omitempty,min=0"`
+	}
+
+	// ItemImageReq is an image uploaded with a multipart create or edit request.
+	ItemImageReq struct {
+		Filename string
+		Data     []byte
 	}
 
 	EnableOrDisableItemReq struct {
 		UsageStauts bool `json:"staus"`
 	}
+
+	// ItemPriceScheduleReq schedules a new price from StartAt, or a sale of PercentOff
+	// between StartAt and EndAt.
+	ItemPriceScheduleReq struct {
+		Kind       string    `json:"kind" validate:"required,oneof=price sale"`
+		Price      float64   `json:"price" validate:"min=0"`
+		PercentOff float64   `json:"percent_off" validate:"min=0,max=100"`
+		StartAt    time.Time `json:"start_at" validate:"required"`
+		EndAt      time.Time `json:"end_at"`
+	}
+
+	ItemPriceScheduleRes struct {
+		ScheduleId string     `json:"schedule_id"`
+		ItemId     string     `json:"item_id"`
+		Kind       string     `json:"kind"`
+		Price      float64    `json:"price,omitempty"`
+		PercentOff float64    `json:"percent_off,omitempty"`
+		StartAt    time.Time  `json:"start_at"`
+		EndAt      *time.Time `json:"end_at,omitempty"`
+		Status     string     `json:"status"`
+		CreatedBy  string     `json:"created_by"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	ItemPriceHistoryReq struct {
+		models.PaginateReq
+	}
+
+	ItemPriceHistoryRes struct {
+		Price          float64   `json:"price"`
+		EffectivePrice float64   `json:"effective_price"`
+		Reason         string    `json:"reason"`
+		ScheduleId     string    `json:"schedule_id,omitempty"`
+		ChangedBy      string    `json:"changed_by,omitempty"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+
+	// ItemImportRow is one item of a csv or json import, Row counts from 1 and Errors holds
+	// what could not be read.
+	ItemImportRow struct {
+		Row           int            `json:"-"`
+		Sku           string         `json:"sku"`
+		Title         string         `json:"title"`
+		Description   string         `json:"description"`
+		Price         float64        `json:"price"`
+		Damage        int            `json:"damage"`
+		ImageUrl      string         `json:"image_url"`
+		Category      string         `json:"category"`
+		Rarity        string         `json:"rarity"`
+		Slot          string         `json:"slot"`
+		Attributes    map[string]any `json:"attributes"`
+		Stock         *int64         `json:"stock"`
+		PurchaseLimit int64          `json:"purchase_limit"`
+		Errors        []string       `json:"-"`
+
+		// Titles and Descriptions are json objects in a csv, like Attributes
+		Titles       map[string]string `json:"titles"`
+		Descriptions map[string]string `json:"descriptions"`
+	}
+
+	// ItemImportRes reports every row of an import. A dry run changes nothing, Created and
+	// Updated then count what the import would do.
+	ItemImportRes struct {
+		DryRun  bool                `json:"dry_run"`
+		Total   int                 `json:"total"`
+		Created int                 `json:"created"`
+		Updated int                 `json:"updated"`
+		Failed  int                 `json:"failed"`
+		Rows    []*ItemImportRowRes `json:"rows"`
+	}
+
+	ItemImportRowRes struct {
+		Row    int      `json:"row"`
+		Sku    string   `json:"sku,omitempty"`
+		Title  string   `json:"title"`
+		ItemId string   `json:"item_id,omitempty"`
+		Action string   `json:"action"`
+		Errors []string `json:"errors,omitempty"`
+	}
+
+	// ItemExportRow is an item as exported, its columns are the import columns plus the id
+	// and the usage status, which an import ignores.
+	ItemExportRow struct {
+		ItemId        string         `json:"item_id"`
+		Sku           string         `json:"sku"`
+		Title         string         `json:"title"`
+		Description   string         `json:"description"`
+		Price         float64        `json:"price"`
+		Damage        int            `json:"damage"`
+		ImageUrl      string         `json:"image_url"`
+		Category      string         `json:"category"`
+		Rarity        string         `json:"rarity"`
+		Slot          string         `json:"slot"`
+		Attributes    ItemAttributes `json:"attributes"`
+		Stock         *int64         `json:"stock"`
+		PurchaseLimit int64          `json:"purchase_limit"`
+		UsageStatus   bool           `json:"usage_status"`
+
+		// Titles and Descriptions are json objects in a csv, like Attributes
+		Titles       map[string]string `json:"titles"`
+		Descriptions map[string]string `json:"descriptions"`
+	}
+
+	CreateBundleReq struct {
+		Title    string                 `json:"title" validate:"required,max=64"`
+		Price    float64                `json:"price" validate:"required"`
+		ImageUrl string                 `json:"image_url" validate:"required,max=255"`
+		Items    []*CreateBundleItemReq `json:"items" validate:"required"`
+	}
+
+	CreateBundleItemReq struct {
+		ItemId   string `json:"item_id" validate:"required,max=64"`
+		Quantity int64  `json:"quantity" validate:"min=1"`
+	}
+
+	// BundleShowCase shows the bundle price next to ItemsPrice, what the items cost alone.
+	BundleShowCase struct {
+		BundleId    string                `json:"bundle_id"`
+		Title       string                `json:"title"`
+		Price       float64               `json:"price"`
+		ItemsPrice  float64               `json:"items_price"`
+		ImageUrl    string                `json:"image_url"`
+		Items       []*BundleItemShowCase `json:"items"`
+		UsageStatus bool                  `json:"usage_status"`
+	}
+
+	BundleItemShowCase struct {
+		ItemId       string  `json:"item_id"`
+		Title        string  `json:"title"`
+		Price        float64 `json:"price"`
+		ImageUrl     string  `json:"image_url"`
+		ThumbnailUrl string  `json:"thumbnail_url,omitempty"`
+		Quantity     int64   `json:"quantity"`
+	}
+
+	ItemHistoryReq struct {
+		models.PaginateReq
+	}
+
+	ItemHistoryRes struct {
+		Action    string        `json:"action"`
+		Changes   []*ItemChange `json:"changes"`
+		ChangedBy string        `json:"changed_by,omitempty"`
+		CreatedAt time.Time     `json:"created_at"`
+	}
+)
+
+// The errors other services act on, their status does not depend on the message.
+var (
+	ErrItemNotFound         = grpccon.NewError(codes.NotFound, "NOT_FOUND", "error: item not found")
+	ErrBundleNotFound       = grpccon.NewError(codes.NotFound, "NOT_FOUND", "error: bundle not found")
+	ErrOutOfStock           = grpccon.NewError(codes.FailedPrecondition, "OUT_OF_STOCK", "error: item is out of stock")
+	ErrPurchaseLimitReached = grpccon.NewError(codes.FailedPrecondition, "PURCHASE_LIMIT_REACHED", "error: purchase limit reached")
+	ErrReservationExists    = grpccon.NewError(codes.AlreadyExists, "ALREADY_EXISTS", "error: reservation already exist")
+	ErrReservationReleased  = grpccon.NewError(codes.FailedPrecondition, "FAILED_PRECONDITION", "error: reservation is already released")
 )
@@ -0,0 +1,36 @@
+package bonx_shop_tutorial
+
+import "errors"
+
+// Validate methods are called by the grpccon validation interceptor before the handler runs.
+
+func (x *FindItemsInIdsReq) Validate() error {
+	if len(x.GetIds()) == 0 {
+		return errors.New("error: ids are required")
+	}
+	return nil
+}
+
+func (x *IncreaseSoldCountReq) Validate() error {
+	if len(x.GetIds()) == 0 {
+		return errors.New("error: ids are required")
+	}
+	return nil
+}
+
+func (x *ReserveStockReq) Validate() error {
+	if x.GetReservationId() == "" || x.GetPlayerId() == "" {
+		return errors.New("error: reservation_id and player_id are required")
+	}
+	if len(x.GetIds()) == 0 {
+		return errors.New("error: ids are required")
+	}
+	return nil
+}
+
+func (x *ReleaseStockReq) Validate() error {
+	if x.GetReservationId() == "" {
+		return errors.New("error: reservation_id is required")
+	}
+	return nil
+}
@@ -23,6 +23,9 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ItemGrpcServiceClient interface {
 	FindItemsInIds(ctx context.Context, in *FindItemsInIdsReq, opts ...grpc.CallOption) (*FindItemsInIdsRes, error)
+	IncreaseSoldCount(ctx context.Context, in *IncreaseSoldCountReq, opts ...grpc.CallOption) (*IncreaseSoldCountRes, error)
+	ReserveStock(ctx context.Context, in *ReserveStockReq, opts ...grpc.CallOption) (*ReserveStockRes, error)
+	ReleaseStock(ctx context.Context, in *ReleaseStockReq, opts ...grpc.CallOption) (*ReleaseStockRes, error)
 }
 
 type itemGrpcServiceClient struct {
@@ -42,11 +45,41 @@ func (c *itemGrpcServiceClient) FindItemsInIds(ctx context.Context, in *FindItem
 	return out, nil
 }
 
+func (c *itemGrpcServiceClient) IncreaseSoldCount(ctx context.Context, in *IncreaseSoldCountReq, opts ...grpc.CallOption) (*IncreaseSoldCountRes, error) {
+	out := new(IncreaseSoldCountRes)
+	err := c.cc.Invoke(ctx, "/ItemGrpcService/IncreaseSoldCount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *itemGrpcServiceClient) ReserveStock(ctx context.Context, in *ReserveStockReq, opts ...grpc.CallOption) (*ReserveStockRes, error) {
+	out := new(ReserveStockRes)
+	err := c.cc.Invoke(ctx, "/ItemGrpcService/ReserveStock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *itemGrpcServiceClient) ReleaseStock(ctx context.Context, in *ReleaseStockReq, opts ...grpc.CallOption) (*ReleaseStockRes, error) {
+	out := new(ReleaseStockRes)
+	err := c.cc.Invoke(ctx, "/ItemGrpcService/ReleaseStock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ItemGrpcServiceServer is the server API for ItemGrpcService service.
 // All implementations must embed UnimplementedItemGrpcServiceServer
 // for forward compatibility
 type ItemGrpcServiceServer interface {
 	FindItemsInIds(context.Context, *FindItemsInIdsReq) (*FindItemsInIdsRes, error)
+	IncreaseSoldCount(context.Context, *IncreaseSoldCountReq) (*IncreaseSoldCountRes, error)
+	ReserveStock(context.Context, *ReserveStockReq) (*ReserveStockRes, error)
+	ReleaseStock(context.Context, *ReleaseStockReq) (*ReleaseStockRes, error)
 	mustEmbedUnimplementedItemGrpcServiceServer()
 }
 
@@ -57,6 +90,15 @@ type UnimplementedItemGrpcServiceServer struct {
 func (UnimplementedItemGrpcServiceServer) FindItemsInIds(context.Context, *FindItemsInIdsReq) (*FindItemsInIdsRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FindItemsInIds not implemented")
 }
+func (UnimplementedItemGrpcServiceServer) IncreaseSoldCount(context.Context, *IncreaseSoldCountReq) (*IncreaseSoldCountRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IncreaseSoldCount not implemented")
+}
+func (UnimplementedItemGrpcServiceServer) ReserveStock(context.Context, *ReserveStockReq) (*ReserveStockRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveStock not implemented")
+}
+func (UnimplementedItemGrpcServiceServer) ReleaseStock(context.Context, *ReleaseStockReq) (*ReleaseStockRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseStock not implemented")
+}
 func (UnimplementedItemGrpcServiceServer) mustEmbedUnimplementedItemGrpcServiceServer() {}
 
 // UnsafeItemGrpcServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +130,60 @@ func _ItemGrpcService_FindItemsInIds_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ItemGrpcService_IncreaseSoldCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncreaseSoldCountReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ItemGrpcServiceServer).IncreaseSoldCount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ItemGrpcService/IncreaseSoldCount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ItemGrpcServiceServer).IncreaseSoldCount(ctx, req.(*IncreaseSoldCountReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ItemGrpcService_ReserveStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveStockReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ItemGrpcServiceServer).ReserveStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ItemGrpcService/ReserveStock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ItemGrpcServiceServer).ReserveStock(ctx, req.(*ReserveStockReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ItemGrpcService_ReleaseStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseStockReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ItemGrpcServiceServer).ReleaseStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ItemGrpcService/ReleaseStock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ItemGrpcServiceServer).ReleaseStock(ctx, req.(*ReleaseStockReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ItemGrpcService_ServiceDesc is the grpc.ServiceDesc for ItemGrpcService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,6 +195,18 @@ var ItemGrpcService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "FindItemsInIds",
 			Handler:    _ItemGrpcService_FindItemsInIds_Handler,
 		},
+		{
+			MethodName: "IncreaseSoldCount",
+			Handler:    _ItemGrpcService_IncreaseSoldCount_Handler,
+		},
+		{
+			MethodName: "ReserveStock",
+			Handler:    _ItemGrpcService_ReserveStock_Handler,
+		},
+		{
+			MethodName: "ReleaseStock",
+			Handler:    _ItemGrpcService_ReleaseStock_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "modules/item/itemPb/itemPb.proto",
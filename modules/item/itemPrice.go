@@ -0,0 +1,31 @@
+package item
+
+import "math"
+
+const (
+	ScheduleKindPrice = "price"
+	ScheduleKindSale  = "sale"
+
+	ScheduleStatusPending   = "pending"
+	ScheduleStatusActive    = "active"
+	ScheduleStatusDone      = "done"
+	ScheduleStatusCancelled = "cancelled"
+
+	PriceReasonCreate    = "create"
+	PriceReasonEdit      = "edit"
+	PriceReasonSchedule  = "schedule"
+	PriceReasonSaleStart = "sale_start"
+	PriceReasonSaleEnd   = "sale_end"
+	PriceReasonImport    = "import"
+
+	// SellBackRate is the share of the price a player paid that they get for selling an item back
+	SellBackRate = 0.5
+)
+
+// EffectivePrice is the price with percentOff taken off, rounded to cents.
+func EffectivePrice(price, percentOff float64) float64 {
+	if percentOff <= 0 {
+		return price
+	}
+	return math.Round(price*(100-percentOff)) / 100
+}
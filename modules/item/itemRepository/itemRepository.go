@@ -3,10 +3,10 @@ package itemRepository
 import (
 	"context"
 	"errors"
-	"log"
-	"time"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/deadline"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -21,8 +21,31 @@ type (
 		FindOneItem(pctx context.Context, itemId string) (*item.Item, error)
 		FindManyItems(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemShowCase, error)
 		CountItems(pctx context.Context, filter primitive.D) (int64, error)
+		StreamItems(pctx context.Context, fn func(result *item.Item) error) error
 		UpdateOneItem(pctx context.Context, itemId string, req primitive.M) error
 		EnableOrDisableItem(pctx context.Context, itemId string, isActive bool) error
+		FindItemFacets(pctx context.Context, pipeline mongo.Pipeline) (*item.ItemFacets, error)
+		IncreaseSoldCount(pctx context.Context, soldCounts map[primitive.ObjectID]int64) (int64, error)
+		InsertOnePriceHistory(pctx context.Context, req *item.ItemPriceHistory) error
+		FindPriceHistory(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemPriceHistory, error)
+		CountPriceHistory(pctx context.Context, filter primitive.D) (int64, error)
+		InsertOneChangeLog(pctx context.Context, req *item.ItemChangeLog) error
+		FindChangeLogs(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemChangeLog, error)
+		CountChangeLogs(pctx context.Context, filter primitive.D) (int64, error)
+		InsertOnePriceSchedule(pctx context.Context, req *item.ItemPriceSchedule) (primitive.ObjectID, error)
+		FindPriceSchedules(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemPriceSchedule, error)
+		ClaimPriceSchedule(pctx context.Context, filter primitive.D, status string) (*item.ItemPriceSchedule, error)
+		InsertOneBundle(pctx context.Context, req *item.ItemBundle) (primitive.ObjectID, error)
+		FindOneBundle(pctx context.Context, bundleId string) (*item.ItemBundle, error)
+		FindManyBundles(pctx context.Context, filter primitive.D) ([]*item.ItemBundle, error)
+		EnableOrDisableBundle(pctx context.Context, bundleId string, isActive bool) error
+		InsertOneReservation(pctx context.Context, req *item.ItemReservation) error
+		UpdateReservation(pctx context.Context, reservationId, status string, req primitive.M) (bool, error)
+		ReleaseReservation(pctx context.Context, reservationId string) (*item.ItemReservation, error)
+		TakeStock(pctx context.Context, itemId primitive.ObjectID, quantity int64) (bool, error)
+		ReturnStock(pctx context.Context, itemId primitive.ObjectID, quantity int64) error
+		TakePurchaseLimit(pctx context.Context, itemId primitive.ObjectID, playerId string, quantity, limit int64) (bool, error)
+		ReturnPurchaseLimit(pctx context.Context, itemId primitive.ObjectID, playerId string, quantity int64) error
 	}
 
 	itemRepository struct {
@@ -30,6 +53,8 @@ type (
 	}
 )
 
+var itemLog = logger.New("item")
+
 func NewItemRepository(db *mongo.Client) ItemRepositoryService {
 	return &itemRepository{db: db}
 }
@@ -39,7 +64,7 @@ func (r *itemRepository) itemDbConn(pctx context.Context) *mongo.Database {
 }
 
 func (r *itemRepository) IsUniqueItem(pctx context.Context, title string) bool {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.itemDbConn(ctx)
@@ -50,14 +75,14 @@ func (r *itemRepository) IsUniqueItem(pctx context.Context, title string) bool {
 		ctx,
 		bson.M{"title": title},
 	).Decode(result); err != nil {
-		log.Printf("Error: IsUniqueItem: %s", err.Error())
+		itemLog.Error(ctx, "IsUniqueItem", "error", err)
 		return true
 	}
 	return false
 }
 
 func (r *itemRepository) InsertOneItem(pctx context.Context, req *item.Item) (primitive.ObjectID, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.itemDbConn(ctx)
@@ -65,7 +90,7 @@ func (r *itemRepository) InsertOneItem(pctx context.Context, req *item.Item) (pr
 
 	itemId, err := col.InsertOne(ctx, req)
 	if err != nil {
-		log.Printf("Error: InsertOneItem: %s", err)
+		itemLog.Error(ctx, "InsertOneItem", "error", err)
 		return primitive.NilObjectID, errors.New("error: insert one item failed")
 	}
 
@@ -73,7 +98,7 @@ func (r *itemRepository) InsertOneItem(pctx context.Context, req *item.Item) (pr
 }
 
 func (r *itemRepository) FindOneItem(pctx context.Context, itemId string) (*item.Item, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.itemDbConn(ctx)
@@ -81,15 +106,15 @@ func (r *itemRepository) FindOneItem(pctx context.Context, itemId string) (*item
 
 	result := new(item.Item)
 	if err := col.FindOne(ctx, bson.M{"_id": utils.ConvertToObjectId(itemId)}).Decode(result); err != nil {
-		log.Printf("Error: FindOneItem failed: %s", err.Error())
-		return nil, errors.New("error: item not found")
+		itemLog.Error(ctx, "FindOneItem failed", "error", err)
+		return nil, item.ErrItemNotFound
 	}
 
 	return result, nil
 }
 
 func (r *itemRepository) FindManyItems(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemShowCase, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.itemDbConn(ctx)
@@ -97,7 +122,7 @@ func (r *itemRepository) FindManyItems(pctx context.Context, filter primitive.D,
 
 	cursors, err := col.Find(ctx, filter, opts...)
 	if err != nil {
-		log.Printf("Error: FindManyItems failed: %s", err.Error())
+		itemLog.Error(ctx, "FindManyItems failed", "error", err)
 		return make([]*item.ItemShowCase, 0), errors.New("error: find many items failed")
 	}
 
@@ -105,15 +130,29 @@ func (r *itemRepository) FindManyItems(pctx context.Context, filter primitive.D,
 	for cursors.Next(ctx) {
 		result := new(item.Item)
 		if err := cursors.Decode(result); err != nil {
-			log.Printf("Error: FindManyItems failed: %s", err.Error())
+			itemLog.Error(ctx, "FindManyItems failed", "error", err)
 			return make([]*item.ItemShowCase, 0), errors.New("error: find many items failed")
 		}
 		results = append(results, &item.ItemShowCase{
-			ItemId:   "item:" + result.Id.Hex(),
-			Title:    result.Title,
-			Price:    result.Price,
-			Damage:   result.Damage,
-			ImageUrl: result.ImageUrl,
+			ItemId:        "item:" + result.Id.Hex(),
+			Sku:           result.Sku,
+			Title:         result.Title,
+			Description:   result.Description,
+			Titles:        result.Titles,
+			Descriptions:  result.Descriptions,
+			Price:         result.Price,
+			Damage:        result.Damage,
+			ImageUrl:      result.ImageUrl,
+			ImageKey:      result.ImageKey,
+			ThumbnailKey:  result.ThumbnailKey,
+			Category:      result.Category,
+			Rarity:        result.Rarity,
+			Slot:          result.Slot,
+			Attributes:    result.Attributes,
+			SoldCount:     result.SoldCount,
+			Stock:         result.Stock,
+			PurchaseLimit: result.PurchaseLimit,
+			DeletedAt:     result.DeletedAt,
 		})
 	}
 
@@ -121,7 +160,7 @@ func (r *itemRepository) FindManyItems(pctx context.Context, filter primitive.D,
 }
 
 func (r *itemRepository) CountItems(pctx context.Context, filter primitive.D) (int64, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.itemDbConn(ctx)
@@ -129,15 +168,46 @@ func (r *itemRepository) CountItems(pctx context.Context, filter primitive.D) (i
 
 	count, err := col.CountDocuments(ctx, filter)
 	if err != nil {
-		log.Printf("Error: CountItems failed: %s", err.Error())
+		itemLog.Error(ctx, "CountItems failed", "error", err)
 		return -1, errors.New("error: count items failed")
 	}
 
 	return count, nil
 }
 
+// StreamItems calls fn with every item that is not deleted, in id order. The cursor is bound to pctx alone, an
+// export runs for as long as the client keeps reading.
+func (r *itemRepository) StreamItems(pctx context.Context, fn func(result *item.Item) error) error {
+	db := r.itemDbConn(pctx)
+	col := db.Collection("items")
+
+	cursors, err := col.Find(pctx, bson.M{"deleted_at": nil}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		itemLog.Error(pctx, "StreamItems failed", "error", err)
+		return errors.New("error: stream items failed")
+	}
+	defer cursors.Close(pctx)
+
+	for cursors.Next(pctx) {
+		result := new(item.Item)
+		if err := cursors.Decode(result); err != nil {
+			itemLog.Error(pctx, "StreamItems failed", "error", err)
+			return errors.New("error: stream items failed")
+		}
+		if err := fn(result); err != nil {
+			return err
+		}
+	}
+	if err := cursors.Err(); err != nil {
+		itemLog.Error(pctx, "StreamItems failed", "error", err)
+		return errors.New("error: stream items failed")
+	}
+
+	return nil
+}
+
 func (r *itemRepository) UpdateOneItem(pctx context.Context, itemId string, req primitive.M) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.itemDbConn(ctx)
@@ -145,16 +215,16 @@ func (r *itemRepository) UpdateOneItem(pctx context.Context, itemId string, req
 
 	result, err := col.UpdateOne(ctx, bson.M{"_id": utils.ConvertToObjectId(itemId)}, bson.M{"$set": req})
 	if err != nil {
-		log.Printf("Error: UpdateOneItem failed: %s", err.Error())
+		itemLog.Error(ctx, "UpdateOneItem failed", "error", err)
 		return errors.New("error: update one item failed")
 	}
-	log.Printf("UpdateOneItem result: %v", result.ModifiedCount)
+	itemLog.Debug(ctx, "UpdateOneItem", "modified_count", result.ModifiedCount)
 
 	return nil
 }
 
 func (r *itemRepository) EnableOrDisableItem(pctx context.Context, itemId string, isActive bool) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.itemDbConn(ctx)
@@ -162,10 +232,470 @@ func (r *itemRepository) EnableOrDisableItem(pctx context.Context, itemId string
 
 	result, err := col.UpdateOne(ctx, bson.M{"_id": utils.ConvertToObjectId(itemId)}, bson.M{"$set": bson.M{"usage_status": isActive}})
 	if err != nil {
-		log.Printf("Error: EnableOrDisableItem failed: %v", err.Error())
+		itemLog.Error(ctx, "EnableOrDisableItem failed", "error", err)
 		return errors.New("error: enable or disable item failed")
 	}
-	log.Printf("EnableOrDisable result: %v", result.ModifiedCount)
+	itemLog.Debug(ctx, "EnableOrDisableItem", "modified_count", result.ModifiedCount)
+
+	return nil
+}
+
+func (r *itemRepository) FindItemFacets(pctx context.Context, pipeline mongo.Pipeline) (*item.ItemFacets, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("items")
+
+	cursors, err := col.Aggregate(ctx, pipeline)
+	if err != nil {
+		itemLog.Error(ctx, "FindItemFacets failed", "error", err)
+		return nil, errors.New("error: find item facets failed")
+	}
+
+	results := make([]*item.ItemFacets, 0)
+	if err := cursors.All(ctx, &results); err != nil {
+		itemLog.Error(ctx, "FindItemFacets failed", "error", err)
+		return nil, errors.New("error: find item facets failed")
+	}
+
+	if len(results) == 0 {
+		return &item.ItemFacets{
+			Category: make([]*item.FacetCount, 0),
+			Rarity:   make([]*item.FacetCount, 0),
+		}, nil
+	}
+	return results[0], nil
+}
+
+func (r *itemRepository) IncreaseSoldCount(pctx context.Context, soldCounts map[primitive.ObjectID]int64) (int64, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("items")
+
+	models := make([]mongo.WriteModel, 0)
+	for itemId, count := range soldCounts {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": itemId}).
+			SetUpdate(bson.M{"$inc": bson.M{"sold_count": count}}))
+	}
+
+	result, err := col.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		itemLog.Error(ctx, "IncreaseSoldCount failed", "error", err)
+		return -1, errors.New("error: increase sold count failed")
+	}
+	itemLog.Debug(ctx, "IncreaseSoldCount", "modified_count", result.ModifiedCount)
+
+	return result.ModifiedCount, nil
+}
+
+func (r *itemRepository) InsertOnePriceHistory(pctx context.Context, req *item.ItemPriceHistory) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_price_history")
+
+	if _, err := col.InsertOne(ctx, req); err != nil {
+		itemLog.Error(ctx, "InsertOnePriceHistory failed", "error", err)
+		return errors.New("error: insert one price history failed")
+	}
+
+	return nil
+}
+
+func (r *itemRepository) FindPriceHistory(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemPriceHistory, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_price_history")
+
+	cursors, err := col.Find(ctx, filter, opts...)
+	if err != nil {
+		itemLog.Error(ctx, "FindPriceHistory failed", "error", err)
+		return nil, errors.New("error: find price history failed")
+	}
+
+	results := make([]*item.ItemPriceHistory, 0)
+	if err := cursors.All(ctx, &results); err != nil {
+		itemLog.Error(ctx, "FindPriceHistory failed", "error", err)
+		return nil, errors.New("error: find price history failed")
+	}
+
+	return results, nil
+}
+
+func (r *itemRepository) CountPriceHistory(pctx context.Context, filter primitive.D) (int64, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_price_history")
+
+	count, err := col.CountDocuments(ctx, filter)
+	if err != nil {
+		itemLog.Error(ctx, "CountPriceHistory failed", "error", err)
+		return -1, errors.New("error: count price history failed")
+	}
+
+	return count, nil
+}
+
+func (r *itemRepository) InsertOneChangeLog(pctx context.Context, req *item.ItemChangeLog) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_change_logs")
+
+	if _, err := col.InsertOne(ctx, req); err != nil {
+		itemLog.Error(ctx, "InsertOneChangeLog failed", "error", err)
+		return errors.New("error: insert one change log failed")
+	}
+
+	return nil
+}
+
+func (r *itemRepository) FindChangeLogs(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemChangeLog, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_change_logs")
+
+	cursors, err := col.Find(ctx, filter, opts...)
+	if err != nil {
+		itemLog.Error(ctx, "FindChangeLogs failed", "error", err)
+		return nil, errors.New("error: find change logs failed")
+	}
+
+	results := make([]*item.ItemChangeLog, 0)
+	if err := cursors.All(ctx, &results); err != nil {
+		itemLog.Error(ctx, "FindChangeLogs failed", "error", err)
+		return nil, errors.New("error: find change logs failed")
+	}
+
+	return results, nil
+}
+
+func (r *itemRepository) CountChangeLogs(pctx context.Context, filter primitive.D) (int64, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_change_logs")
+
+	count, err := col.CountDocuments(ctx, filter)
+	if err != nil {
+		itemLog.Error(ctx, "CountChangeLogs failed", "error", err)
+		return -1, errors.New("error: count change logs failed")
+	}
+
+	return count, nil
+}
+
+func (r *itemRepository) InsertOnePriceSchedule(pctx context.Context, req *item.ItemPriceSchedule) (primitive.ObjectID, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_price_schedules")
+
+	scheduleId, err := col.InsertOne(ctx, req)
+	if err != nil {
+		itemLog.Error(ctx, "InsertOnePriceSchedule failed", "error", err)
+		return primitive.NilObjectID, errors.New("error: insert one price schedule failed")
+	}
+
+	return scheduleId.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *itemRepository) FindPriceSchedules(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemPriceSchedule, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_price_schedules")
+
+	cursors, err := col.Find(ctx, filter, opts...)
+	if err != nil {
+		itemLog.Error(ctx, "FindPriceSchedules failed", "error", err)
+		return nil, errors.New("error: find price schedules failed")
+	}
+
+	results := make([]*item.ItemPriceSchedule, 0)
+	if err := cursors.All(ctx, &results); err != nil {
+		itemLog.Error(ctx, "FindPriceSchedules failed", "error", err)
+		return nil, errors.New("error: find price schedules failed")
+	}
+
+	return results, nil
+}
+
+// ClaimPriceSchedule moves one schedule matching filter to status and returns it as it was,
+// nil when none matches. Only one replica of the item service can claim a schedule.
+func (r *itemRepository) ClaimPriceSchedule(pctx context.Context, filter primitive.D, status string) (*item.ItemPriceSchedule, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_price_schedules")
+
+	result := new(item.ItemPriceSchedule)
+	if err := col.FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{"status": status, "updated_at": utils.LocalTime()}},
+		options.FindOneAndUpdate().SetSort(bson.M{"start_at": 1}),
+	).Decode(result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		itemLog.Error(ctx, "ClaimPriceSchedule failed", "error", err)
+		return nil, errors.New("error: claim price schedule failed")
+	}
+
+	return result, nil
+}
+
+// InsertOneReservation claims the reservation id, a second reserve or a release that came
+// first makes it fail.
+func (r *itemRepository) InsertOneReservation(pctx context.Context, req *item.ItemReservation) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_reservations")
+
+	if _, err := col.InsertOne(ctx, req); err != nil {
+		itemLog.Error(ctx, "InsertOneReservation failed", "error", err)
+		if mongo.IsDuplicateKeyError(err) {
+			return item.ErrReservationExists
+		}
+		return errors.New("error: insert one reservation failed")
+	}
+
+	return nil
+}
+
+// UpdateReservation sets req on a reservation that is still in status, it reports false
+// when the status has moved on.
+func (r *itemRepository) UpdateReservation(pctx context.Context, reservationId, status string, req primitive.M) (bool, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_reservations")
+
+	req["updated_at"] = utils.LocalTime()
+	result, err := col.UpdateOne(ctx, bson.M{"_id": reservationId, "status": status}, bson.M{"$set": req})
+	if err != nil {
+		itemLog.Error(ctx, "UpdateReservation failed", "error", err)
+		return false, errors.New("error: update reservation failed")
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// ReleaseReservation marks a reservation released and returns it as it was. An unknown id
+// is stored as released, so a reserve that arrives after its release is refused.
+func (r *itemRepository) ReleaseReservation(pctx context.Context, reservationId string) (*item.ItemReservation, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_reservations")
+
+	now := utils.LocalTime()
+	result := new(item.ItemReservation)
+	if err := col.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": reservationId},
+		bson.M{
+			"$set":         bson.M{"status": item.ReservationStatusReleased, "updated_at": now},
+			"$setOnInsert": bson.M{"items": bson.A{}, "created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true),
+	).Decode(result); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		itemLog.Error(ctx, "ReleaseReservation failed", "error", err)
+		return nil, errors.New("error: release reservation failed")
+	}
+
+	return result, nil
+}
+
+// TakeStock takes quantity from the stock of a limited item, it reports false when not
+// enough is left. Items with an unlimited supply are not matched.
+func (r *itemRepository) TakeStock(pctx context.Context, itemId primitive.ObjectID, quantity int64) (bool, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("items")
+
+	result, err := col.UpdateOne(
+		ctx,
+		bson.M{"_id": itemId, "stock": bson.M{"$gte": quantity}},
+		bson.M{"$inc": bson.M{"stock": -quantity}},
+	)
+	if err != nil {
+		itemLog.Error(ctx, "TakeStock failed", "error", err)
+		return false, errors.New("error: take stock failed")
+	}
+
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *itemRepository) ReturnStock(pctx context.Context, itemId primitive.ObjectID, quantity int64) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("items")
+
+	// The supply may have been made unlimited in the meantime
+	if _, err := col.UpdateOne(
+		ctx,
+		bson.M{"_id": itemId, "stock": bson.M{"$type": "number"}},
+		bson.M{"$inc": bson.M{"stock": quantity}},
+	); err != nil {
+		itemLog.Error(ctx, "ReturnStock failed", "error", err)
+		return errors.New("error: return stock failed")
+	}
+
+	return nil
+}
+
+// TakePurchaseLimit counts quantity against the limit of a player, it reports false when
+// the player would go over it. The count of a player who is over the limit does not match
+// the filter, the upsert then runs into the unique index instead of adding a second count.
+func (r *itemRepository) TakePurchaseLimit(pctx context.Context, itemId primitive.ObjectID, playerId string, quantity, limit int64) (bool, error) {
+	if quantity > limit {
+		return false, nil
+	}
+
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_player_purchases")
+
+	if _, err := col.UpdateOne(
+		ctx,
+		bson.M{"item_id": itemId, "player_id": playerId, "count": bson.M{"$lte": limit - quantity}},
+		bson.M{
+			"$inc": bson.M{"count": quantity},
+			"$set": bson.M{"updated_at": utils.LocalTime()},
+		},
+		options.Update().SetUpsert(true),
+	); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		itemLog.Error(ctx, "TakePurchaseLimit failed", "error", err)
+		return false, errors.New("error: take purchase limit failed")
+	}
+
+	return true, nil
+}
+
+func (r *itemRepository) ReturnPurchaseLimit(pctx context.Context, itemId primitive.ObjectID, playerId string, quantity int64) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_player_purchases")
+
+	if _, err := col.UpdateOne(
+		ctx,
+		bson.M{"item_id": itemId, "player_id": playerId},
+		bson.M{"$inc": bson.M{"count": -quantity}},
+	); err != nil {
+		itemLog.Error(ctx, "ReturnPurchaseLimit failed", "error", err)
+		return errors.New("error: return purchase limit failed")
+	}
+
+	return nil
+}
+
+func (r *itemRepository) InsertOneBundle(pctx context.Context, req *item.ItemBundle) (primitive.ObjectID, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_bundles")
+
+	bundleId, err := col.InsertOne(ctx, req)
+	if err != nil {
+		itemLog.Error(ctx, "InsertOneBundle failed", "error", err)
+		return primitive.NilObjectID, errors.New("error: insert one bundle failed")
+	}
+
+	return bundleId.InsertedID.(primitive.ObjectID), nil
+}
+
+func (r *itemRepository) FindOneBundle(pctx context.Context, bundleId string) (*item.ItemBundle, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_bundles")
+
+	result := new(item.ItemBundle)
+	if err := col.FindOne(ctx, bson.M{"_id": utils.ConvertToObjectId(bundleId)}).Decode(result); err != nil {
+		itemLog.Error(ctx, "FindOneBundle failed", "error", err)
+		return nil, item.ErrBundleNotFound
+	}
+
+	return result, nil
+}
+
+func (r *itemRepository) FindManyBundles(pctx context.Context, filter primitive.D) ([]*item.ItemBundle, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_bundles")
+
+	cursors, err := col.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		itemLog.Error(ctx, "FindManyBundles failed", "error", err)
+		return nil, errors.New("error: find many bundles failed")
+	}
+
+	results := make([]*item.ItemBundle, 0)
+	if err := cursors.All(ctx, &results); err != nil {
+		itemLog.Error(ctx, "FindManyBundles failed", "error", err)
+		return nil, errors.New("error: find many bundles failed")
+	}
+
+	return results, nil
+}
+
+func (r *itemRepository) EnableOrDisableBundle(pctx context.Context, bundleId string, isActive bool) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.itemDbConn(ctx)
+	col := db.Collection("item_bundles")
+
+	result, err := col.UpdateOne(
+		ctx,
+		bson.M{"_id": utils.ConvertToObjectId(bundleId)},
+		bson.M{"$set": bson.M{"usage_status": isActive, "updated_at": utils.LocalTime()}},
+	)
+	if err != nil {
+		itemLog.Error(ctx, "EnableOrDisableBundle failed", "error", err)
+		return errors.New("error: enable or disable bundle failed")
+	}
+	itemLog.Debug(ctx, "EnableOrDisableBundle", "modified_count", result.ModifiedCount)
 
 	return nil
 }
@@ -0,0 +1,167 @@
+package item
+
+import (
+	"errors"
+	"math"
+	"regexp"
+)
+
+const (
+	CategoryWeapon     = "weapon"
+	CategoryArmour     = "armour"
+	CategoryConsumable = "consumable"
+	CategoryCosmetic   = "cosmetic"
+
+	RarityCommon    = "common"
+	RarityUncommon  = "uncommon"
+	RarityRare      = "rare"
+	RarityEpic      = "epic"
+	RarityLegendary = "legendary"
+
+	AttributeDefense    = "defense"
+	AttributeDurability = "durability"
+	AttributeEffects    = "effects"
+
+	maxAttributeValue = 10000
+	maxEffects        = 8
+)
+
+var (
+	// categorySlots lists the slots an item of the category can be equipped in, the first
+	// one is the default. Consumables are used, not equipped, so they have no slot.
+	categorySlots = map[string][]string{
+		CategoryWeapon:     {"main_hand", "off_hand", "two_hand"},
+		CategoryArmour:     {"head", "chest", "hands", "legs", "feet"},
+		CategoryConsumable: {},
+		CategoryCosmetic:   {"head", "body", "back"},
+	}
+
+	// categoryAttributes lists the attributes an item of the category may carry.
+	categoryAttributes = map[string][]string{
+		CategoryWeapon:     {AttributeDurability, AttributeEffects},
+		CategoryArmour:     {AttributeDefense, AttributeDurability, AttributeEffects},
+		CategoryConsumable: {AttributeEffects},
+		CategoryCosmetic:   {},
+	}
+
+	rarities = []string{RarityCommon, RarityUncommon, RarityRare, RarityEpic, RarityLegendary}
+
+	effectPattern = regexp.MustCompile(`^[a-z][a-z_]{0,31}$`)
+)
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func IsCategory(category string) bool {
+	_, ok := categorySlots[category]
+	return ok
+}
+
+func IsRarity(rarity string) bool {
+	return contains(rarities, rarity)
+}
+
+// IsSlot reports whether slot is a slot of any category, used to validate search filters.
+func IsSlot(slot string) bool {
+	for _, slots := range categorySlots {
+		if contains(slots, slot) {
+			return true
+		}
+	}
+	return false
+}
+
+// ItemType is the category, rarity and slot of an item, empty fields are filled with the
+// defaults of the category by Normalize.
+type ItemType struct {
+	Category string
+	Rarity   string
+	Slot     string
+}
+
+func (t *ItemType) Normalize() error {
+	if t.Category == "" {
+		t.Category = CategoryWeapon
+	}
+	if !IsCategory(t.Category) {
+		return errors.New("error: category is invalid")
+	}
+
+	if t.Rarity == "" {
+		t.Rarity = RarityCommon
+	}
+	if !IsRarity(t.Rarity) {
+		return errors.New("error: rarity is invalid")
+	}
+
+	slots := categorySlots[t.Category]
+	if len(slots) == 0 {
+		if t.Slot != "" {
+			return errors.New("error: " + t.Category + " has no slot")
+		}
+		return nil
+	}
+	if t.Slot == "" {
+		t.Slot = slots[0]
+	}
+	if !contains(slots, t.Slot) {
+		return errors.New("error: slot is invalid for " + t.Category)
+	}
+	return nil
+}
+
+// ParseAttributes checks the attribute map sent by a client against the category and turns
+// it into ItemAttributes. Unknown attributes and attributes of another category are rejected.
+func ParseAttributes(category string, attributes map[string]any) (ItemAttributes, error) {
+	result := ItemAttributes{}
+
+	for key, value := range attributes {
+		if !contains(categoryAttributes[category], key) {
+			return ItemAttributes{}, errors.New("error: attribute " + key + " is not allowed for " + category)
+		}
+
+		switch key {
+		case AttributeDefense, AttributeDurability:
+			number, ok := value.(float64)
+			if !ok || number != math.Trunc(number) || number < 0 || number > maxAttributeValue {
+				return ItemAttributes{}, errors.New("error: attribute " + key + " must be a whole number between 0 and 10000")
+			}
+			if key == AttributeDefense {
+				result.Defense = int(number)
+			} else {
+				result.Durability = int(number)
+			}
+		case AttributeEffects:
+			effects, ok := value.([]any)
+			if !ok || len(effects) > maxEffects {
+				return ItemAttributes{}, errors.New("error: attribute effects must be a list of at most 8 effects")
+			}
+			for _, effect := range effects {
+				name, ok := effect.(string)
+				if !ok || !effectPattern.MatchString(name) {
+					return ItemAttributes{}, errors.New("error: effect must be a lower case name")
+				}
+				if !contains(result.Effects, name) {
+					result.Effects = append(result.Effects, name)
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// Allows reports whether every attribute already set fits the category, checked when only
+// the category of an item is changed.
+func (a ItemAttributes) Allows(category string) bool {
+	allowed := categoryAttributes[category]
+	return (a.Defense == 0 || contains(allowed, AttributeDefense)) &&
+		(a.Durability == 0 || contains(allowed, AttributeDurability)) &&
+		(len(a.Effects) == 0 || contains(allowed, AttributeEffects))
+}
@@ -0,0 +1,7 @@
+package item
+
+const (
+	ReservationStatusReserving = "reserving"
+	ReservationStatusReserved  = "reserved"
+	ReservationStatusReleased  = "released"
+)
@@ -0,0 +1,263 @@
+package itemUsecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
+	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateBundle checks that every component is an enabled item, a bundle of a single unit
+// would only be the item at another price.
+func (u *itemUsecase) CreateBundle(pctx context.Context, req *item.CreateBundleReq) (*item.BundleShowCase, error) {
+	if req.Title == "" || len(req.Title) > 64 {
+		return nil, errors.New("error: title is required and at most 64 characters")
+	}
+	if req.Price <= 0 {
+		return nil, errors.New("error: price must be more than 0")
+	}
+	if req.ImageUrl == "" || len(req.ImageUrl) > 255 {
+		return nil, errors.New("error: image_url is required and at most 255 characters")
+	}
+	if len(req.Items) == 0 || len(req.Items) > item.MaxBundleItems {
+		return nil, errors.New("error: a bundle has 1 to " + strconv.Itoa(item.MaxBundleItems) + " items")
+	}
+
+	bundleItems := make([]*item.ItemBundleDatum, 0)
+	objectIds := make([]primitive.ObjectID, 0)
+	seen := make(map[primitive.ObjectID]bool)
+	units := int64(0)
+	for _, reqItem := range req.Items {
+		if reqItem.Quantity < 1 || reqItem.Quantity > item.MaxBundleQuantity {
+			return nil, errors.New("error: quantity must be between 1 and " + strconv.Itoa(item.MaxBundleQuantity))
+		}
+		objectId, err := primitive.ObjectIDFromHex(strings.TrimPrefix(reqItem.ItemId, "item:"))
+		if err != nil {
+			return nil, errors.New("error: item_id " + reqItem.ItemId + " is invalid")
+		}
+		if seen[objectId] {
+			return nil, errors.New("error: item_id " + reqItem.ItemId + " is in the bundle more than once")
+		}
+		seen[objectId] = true
+
+		objectIds = append(objectIds, objectId)
+		bundleItems = append(bundleItems, &item.ItemBundleDatum{ItemId: objectId, Quantity: reqItem.Quantity})
+		units += reqItem.Quantity
+	}
+	if units < 2 {
+		return nil, errors.New("error: a bundle has at least 2 items")
+	}
+
+	results, err := u.itemRepository.FindManyItems(pctx, bson.D{
+		{"_id", bson.D{{"$in", objectIds}}},
+		{"usage_status", true},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(objectIds) {
+		return nil, item.ErrItemNotFound.With("error: bundle item not found")
+	}
+
+	sameTitles, err := u.itemRepository.FindManyBundles(pctx, bson.D{{"title", req.Title}})
+	if err != nil {
+		return nil, err
+	}
+	if len(sameTitles) > 0 {
+		return nil, errors.New("error: this title is already exist")
+	}
+
+	bundleId, err := u.itemRepository.InsertOneBundle(pctx, &item.ItemBundle{
+		Title:       req.Title,
+		Price:       req.Price,
+		ImageUrl:    req.ImageUrl,
+		Items:       bundleItems,
+		UsageStatus: true,
+		CreatedAt:   utils.LocalTime(),
+		UpdatedAt:   utils.LocalTime(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.FindOneBundle(pctx, bundleId.Hex())
+}
+
+func (u *itemUsecase) FindOneBundle(pctx context.Context, bundleId string) (*item.BundleShowCase, error) {
+	result, err := u.itemRepository.FindOneBundle(pctx, bundleId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := u.bundleShowCases(pctx, result)
+	if err != nil {
+		return nil, err
+	}
+
+	return res[0], nil
+}
+
+// FindManyBundles lists the enabled bundles, there are few enough to go without paging.
+func (u *itemUsecase) FindManyBundles(pctx context.Context) ([]*item.BundleShowCase, error) {
+	results, err := u.itemRepository.FindManyBundles(pctx, bson.D{{"usage_status", true}})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.bundleShowCases(pctx, results...)
+}
+
+func (u *itemUsecase) EnableOrDisableBundle(pctx context.Context, bundleId string) (bool, error) {
+	result, err := u.itemRepository.FindOneBundle(pctx, bundleId)
+	if err != nil {
+		return false, err
+	}
+
+	if err := u.itemRepository.EnableOrDisableBundle(pctx, bundleId, !result.UsageStatus); err != nil {
+		return false, err
+	}
+
+	return !result.UsageStatus, nil
+}
+
+// bundleShowCases looks the components up at once. ItemsPrice is what the items cost alone
+// today, sales included.
+func (u *itemUsecase) bundleShowCases(pctx context.Context, bundles ...*item.ItemBundle) ([]*item.BundleShowCase, error) {
+	objectIds := make([]primitive.ObjectID, 0)
+	for _, bundle := range bundles {
+		for _, bundleItem := range bundle.Items {
+			objectIds = append(objectIds, bundleItem.ItemId)
+		}
+	}
+
+	components, err := u.itemRepository.FindManyItems(pctx, bson.D{{"_id", bson.D{{"$in", objectIds}}}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	u.withImageUrls(pctx, components...)
+	u.withSales(pctx, components...)
+	u.withLocale(pctx, components...)
+
+	componentMaps := make(map[string]*item.ItemShowCase)
+	for _, component := range components {
+		componentMaps[component.ItemId] = component
+	}
+
+	res := make([]*item.BundleShowCase, 0)
+	for _, bundle := range bundles {
+		showCase := &item.BundleShowCase{
+			BundleId:    item.BundleIdPrefix + bundle.Id.Hex(),
+			Title:       bundle.Title,
+			Price:       bundle.Price,
+			ImageUrl:    bundle.ImageUrl,
+			Items:       make([]*item.BundleItemShowCase, 0),
+			UsageStatus: bundle.UsageStatus,
+		}
+
+		for _, bundleItem := range bundle.Items {
+			component, ok := componentMaps["item:"+bundleItem.ItemId.Hex()]
+			if !ok {
+				continue
+			}
+
+			price := component.Price
+			if component.Sale != nil {
+				price = component.Sale.Price
+			}
+			showCase.ItemsPrice += price * float64(bundleItem.Quantity)
+
+			showCase.Items = append(showCase.Items, &item.BundleItemShowCase{
+				ItemId:       component.ItemId,
+				Title:        component.Title,
+				Price:        price,
+				ImageUrl:     component.ImageUrl,
+				ThumbnailUrl: component.ThumbnailUrl,
+				Quantity:     bundleItem.Quantity,
+			})
+		}
+		showCase.ItemsPrice = math.Round(showCase.ItemsPrice*100) / 100
+
+		res = append(res, showCase)
+	}
+
+	return res, nil
+}
+
+// findBundlesInIds returns the enabled bundles of ids whose components are all enabled, a
+// bundle missing an item cannot be bought.
+func (u *itemUsecase) findBundlesInIds(pctx context.Context, bundleIds []primitive.ObjectID) ([]*itemPb.Item, error) {
+	res := make([]*itemPb.Item, 0)
+	if len(bundleIds) == 0 {
+		return res, nil
+	}
+
+	bundles, err := u.itemRepository.FindManyBundles(pctx, bson.D{
+		{"_id", bson.D{{"$in", bundleIds}}},
+		{"usage_status", true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objectIds := make([]primitive.ObjectID, 0)
+	for _, bundle := range bundles {
+		for _, bundleItem := range bundle.Items {
+			objectIds = append(objectIds, bundleItem.ItemId)
+		}
+	}
+	components, err := u.itemRepository.FindManyItems(pctx, bson.D{
+		{"_id", bson.D{{"$in", objectIds}}},
+		{"usage_status", true},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	u.withSales(pctx, components...)
+
+	enabled := make(map[string]bool)
+	prices := make(map[string]float64)
+	for _, component := range components {
+		enabled[component.ItemId] = true
+		prices[component.ItemId] = component.Price
+		if component.Sale != nil {
+			prices[component.ItemId] = component.Sale.Price
+		}
+	}
+
+	for _, bundle := range bundles {
+		bundleItems := make([]*itemPb.BundleItem, 0)
+		for _, bundleItem := range bundle.Items {
+			bundleItems = append(bundleItems, &itemPb.BundleItem{
+				Id:       "item:" + bundleItem.ItemId.Hex(),
+				Quantity: bundleItem.Quantity,
+				Price:    prices["item:"+bundleItem.ItemId.Hex()],
+			})
+		}
+
+		complete := true
+		for _, bundleItem := range bundleItems {
+			complete = complete && enabled[bundleItem.Id]
+		}
+		if !complete {
+			itemLog.Warn(pctx, "Bundle has a disabled item", "bundle_id", bundle.Id.Hex())
+			continue
+		}
+
+		res = append(res, &itemPb.Item{
+			Id:          item.BundleIdPrefix + bundle.Id.Hex(),
+			Title:       bundle.Title,
+			Price:       bundle.Price,
+			ImageUrl:    bundle.ImageUrl,
+			BundleItems: bundleItems,
+		})
+	}
+
+	return res, nil
+}
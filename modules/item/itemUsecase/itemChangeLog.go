@@ -0,0 +1,161 @@
+package itemUsecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/cursor"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DeleteItem hides the item everywhere by disabling it, the document and its history stay
+// so it can be restored.
+func (u *itemUsecase) DeleteItem(pctx context.Context, itemId string) error {
+	result, err := u.itemRepository.FindOneItem(pctx, itemId)
+	if err != nil {
+		return err
+	}
+	if result.DeletedAt != nil {
+		return errors.New("error: item is already deleted")
+	}
+
+	updateReq := bson.M{
+		"usage_status": false,
+		"deleted_at":   utils.LocalTime(),
+		"updated_at":   utils.LocalTime(),
+	}
+	changes := item.Diff(result, updateReq)
+
+	if err := u.itemRepository.UpdateOneItem(pctx, itemId, updateReq); err != nil {
+		return err
+	}
+	u.recordChange(pctx, result.Id, item.ChangeActionDelete, changes)
+
+	return nil
+}
+
+// RestoreItem brings a deleted item back disabled, so it can be checked before it is sold
+// again.
+func (u *itemUsecase) RestoreItem(pctx context.Context, itemId string) (*item.ItemShowCase, error) {
+	result, err := u.itemRepository.FindOneItem(pctx, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if result.DeletedAt == nil {
+		return nil, errors.New("error: item is not deleted")
+	}
+
+	updateReq := bson.M{
+		"deleted_at": nil,
+		"updated_at": utils.LocalTime(),
+	}
+	changes := item.Diff(result, updateReq)
+
+	if err := u.itemRepository.UpdateOneItem(pctx, itemId, updateReq); err != nil {
+		return nil, err
+	}
+	u.recordChange(pctx, result.Id, item.ChangeActionRestore, changes)
+
+	return u.FindOneItem(pctx, itemId)
+}
+
+// recordChange writes the change log of an item. Like the price history it is written after
+// the change, a failure is logged and does not undo the change.
+func (u *itemUsecase) recordChange(pctx context.Context, itemId primitive.ObjectID, action string, changes []*item.ItemChange) {
+	if changes == nil {
+		changes = make([]*item.ItemChange, 0)
+	}
+
+	if err := u.itemRepository.InsertOneChangeLog(context.WithoutCancel(pctx), &item.ItemChangeLog{
+		ItemId:    itemId,
+		Action:    action,
+		Changes:   changes,
+		ChangedBy: logger.PlayerId(pctx),
+		CreatedAt: utils.LocalTime(),
+	}); err != nil {
+		itemLog.Error(pctx, "Record change failed", "item_id", itemId.Hex(), "action", action, "error", err)
+	}
+}
+
+// FindItemHistory pages through the change log of an item, newest first. Deleted items
+// keep their history.
+func (u *itemUsecase) FindItemHistory(pctx context.Context, cfg *config.Config, itemId string, req *item.ItemHistoryReq) (*models.PaginateRes, error) {
+	baseUrl := cfg.Paginate.ItemNextPageBasedUrl + "/" + itemId + "/history"
+	query := url.Values{"item_id": {itemId}}
+
+	page, err := cursor.Open(pctx, cfg.Paginate.CursorSecret, req.Start, "_id", -1, query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter
+	filter := bson.D{{"item_id", utils.ConvertToObjectId(itemId)}}
+	countFilter := append(bson.D{}, filter...)
+	if cursorFilter, ok := page.Filter(); ok {
+		filter = append(filter, cursorFilter)
+	}
+
+	// Option
+	opts := make([]*options.FindOptions, 0)
+
+	opts = append(opts, options.Find().SetSort(page.Sort()))
+	opts = append(opts, options.Find().SetLimit(page.Limit(req.Limit)))
+
+	// Find
+	results, err := u.itemRepository.FindChangeLogs(pctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results, hasPrev, hasNext := cursor.Slice(page, results, req.Limit)
+
+	// Count
+	total, err := u.itemRepository.CountChangeLogs(pctx, countFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*item.ItemHistoryRes, 0)
+	for _, result := range results {
+		data = append(data, &item.ItemHistoryRes{
+			Action:    result.Action,
+			Changes:   result.Changes,
+			ChangedBy: result.ChangedBy,
+			CreatedAt: result.CreatedAt,
+		})
+	}
+
+	res := &models.PaginateRes{
+		Data:  data,
+		Total: total,
+		Limit: req.Limit,
+		First: models.FirstPaginate{
+			Href: cursor.Href(baseUrl, nil, req.Limit, ""),
+		},
+	}
+
+	if hasNext {
+		start := page.Token(pctx, cursor.Next, nil, results[len(results)-1].Id.Hex())
+		res.Next = models.NextPaginate{
+			Start: start,
+			Href:  cursor.Href(baseUrl, nil, req.Limit, start),
+		}
+	}
+
+	if hasPrev && len(results) > 0 {
+		start := page.Token(pctx, cursor.Prev, nil, results[0].Id.Hex())
+		res.Prev = models.PrevPaginate{
+			Start: start,
+			Href:  cursor.Href(baseUrl, nil, req.Limit, start),
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,253 @@
+package itemUsecase
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ImportItems creates the rows whose sku is not known yet and updates the others. A row that
+// fails is skipped and reported, the rows around it still go in.
+func (u *itemUsecase) ImportItems(pctx context.Context, rows []*item.ItemImportRow, dryRun bool) (*item.ItemImportRes, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("error: import has no rows")
+	}
+	if len(rows) > item.MaxImportRows {
+		return nil, errors.New("error: import has more than " + strconv.Itoa(item.MaxImportRows) + " rows")
+	}
+
+	skus := make([]string, 0)
+	for _, row := range rows {
+		if row.Sku != "" {
+			skus = append(skus, row.Sku)
+		}
+	}
+	existing := make(map[string]*item.ItemShowCase)
+	if len(skus) > 0 {
+		results, err := u.itemRepository.FindManyItems(pctx, bson.D{{"sku", bson.D{{"$in", skus}}}}, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			existing[result.Sku] = result
+		}
+	}
+
+	res := &item.ItemImportRes{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]*item.ItemImportRowRes, 0),
+	}
+	seenSkus := make(map[string]bool)
+	seenTitles := make(map[string]bool)
+
+	for _, row := range rows {
+		rowRes := &item.ItemImportRowRes{
+			Row:    row.Row,
+			Sku:    row.Sku,
+			Title:  row.Title,
+			Action: item.ImportActionCreate,
+		}
+
+		var found *item.ItemShowCase
+		if row.Sku != "" {
+			found = existing[row.Sku]
+		}
+		if found != nil {
+			rowRes.ItemId = found.ItemId
+			rowRes.Action = item.ImportActionUpdate
+		}
+
+		newItem, errs := importItem(row)
+		if row.Sku != "" && seenSkus[row.Sku] {
+			errs = append(errs, "sku is in the import more than once")
+		}
+		if row.Title != "" && seenTitles[row.Title] {
+			errs = append(errs, "title is in the import more than once")
+		}
+		seenSkus[row.Sku] = true
+		seenTitles[row.Title] = true
+
+		if found == nil && row.ImageUrl == "" {
+			errs = append(errs, "image_url is required for a new item")
+		}
+		if found != nil && found.DeletedAt != nil {
+			errs = append(errs, "item is deleted, restore it first")
+		}
+		if row.Title != "" && (found == nil || found.Title != row.Title) && !u.itemRepository.IsUniqueItem(pctx, row.Title) {
+			errs = append(errs, "title is already exist")
+		}
+
+		if len(errs) == 0 && !dryRun {
+			itemId, err := u.applyImport(pctx, newItem, found)
+			if err != nil {
+				errs = append(errs, strings.TrimPrefix(err.Error(), "error: "))
+			}
+			rowRes.ItemId = itemId
+		}
+
+		switch {
+		case len(errs) > 0:
+			rowRes.Action = item.ImportActionSkip
+			rowRes.Errors = errs
+			res.Failed++
+		case rowRes.Action == item.ImportActionCreate:
+			res.Created++
+		default:
+			res.Updated++
+		}
+		res.Rows = append(res.Rows, rowRes)
+	}
+
+	return res, nil
+}
+
+// importItem checks a row like CreateItem checks a request, every problem is reported
+// instead of only the first.
+func importItem(row *item.ItemImportRow) (*item.Item, []string) {
+	errs := append(make([]string, 0), row.Errors...)
+
+	if row.Title == "" || len(row.Title) > 64 {
+		errs = append(errs, "title is required and at most 64 characters")
+	}
+	if len(row.Sku) > 64 {
+		errs = append(errs, "sku is at most 64 characters")
+	}
+	if row.Price <= 0 {
+		errs = append(errs, "price must be more than 0")
+	}
+	if row.Damage < 1 || row.Damage > 255 {
+		errs = append(errs, "damage must be between 1 and 255")
+	}
+	if len(row.ImageUrl) > 255 {
+		errs = append(errs, "image_url is at most 255 characters")
+	}
+	if (row.Stock != nil && *row.Stock < 0) || row.PurchaseLimit < 0 {
+		errs = append(errs, "stock and purchase_limit must not be negative")
+	}
+
+	titles, descriptions, err := parseLocalized(row.Description, row.Titles, row.Descriptions)
+	if err != nil {
+		errs = append(errs, strings.TrimPrefix(err.Error(), "error: "))
+	}
+
+	itemType := &item.ItemType{Category: row.Category, Rarity: row.Rarity, Slot: row.Slot}
+	var attributes item.ItemAttributes
+	err = itemType.Normalize()
+	if err == nil {
+		attributes, err = item.ParseAttributes(itemType.Category, row.Attributes)
+	}
+	if err != nil {
+		errs = append(errs, strings.TrimPrefix(err.Error(), "error: "))
+	}
+
+	return &item.Item{
+		Sku:           row.Sku,
+		Title:         row.Title,
+		Titles:        titles,
+		Description:   strings.TrimSpace(row.Description),
+		Descriptions:  descriptions,
+		Price:         row.Price,
+		Damage:        row.Damage,
+		ImageUrl:      row.ImageUrl,
+		Category:      itemType.Category,
+		Rarity:        itemType.Rarity,
+		Slot:          itemType.Slot,
+		Attributes:    attributes,
+		Stock:         row.Stock,
+		PurchaseLimit: row.PurchaseLimit,
+	}, errs
+}
+
+// applyImport writes one checked row. An update replaces every field but keeps the image
+// when the row has no image_url, and leaves the usage status and sold count alone.
+func (u *itemUsecase) applyImport(pctx context.Context, newItem *item.Item, found *item.ItemShowCase) (string, error) {
+	if found == nil {
+		newItem.UsageStatus = true
+		newItem.CreatedAt = utils.LocalTime()
+		newItem.UpdatedAt = utils.LocalTime()
+
+		itemId, err := u.itemRepository.InsertOneItem(pctx, newItem)
+		if err != nil {
+			return "", err
+		}
+		u.recordPrice(pctx, itemId, newItem.Price, item.PriceReasonImport, primitive.NilObjectID)
+		u.recordChange(pctx, itemId, item.ChangeActionImport, nil)
+
+		return "item:" + itemId.Hex(), nil
+	}
+
+	itemId := strings.TrimPrefix(found.ItemId, "item:")
+	result, err := u.itemRepository.FindOneItem(pctx, itemId)
+	if err != nil {
+		return found.ItemId, err
+	}
+
+	updateReq := bson.M{
+		"title":          newItem.Title,
+		"titles":         newItem.Titles,
+		"description":    newItem.Description,
+		"descriptions":   newItem.Descriptions,
+		"price":          newItem.Price,
+		"damage":         newItem.Damage,
+		"category":       newItem.Category,
+		"rarity":         newItem.Rarity,
+		"slot":           newItem.Slot,
+		"attributes":     newItem.Attributes,
+		"stock":          newItem.Stock,
+		"purchase_limit": newItem.PurchaseLimit,
+		"updated_at":     utils.LocalTime(),
+	}
+	if newItem.ImageUrl != "" {
+		updateReq["image_url"] = newItem.ImageUrl
+		updateReq["image_key"] = ""
+		updateReq["thumbnail_key"] = ""
+	}
+
+	changes := item.Diff(result, updateReq)
+
+	if err := u.itemRepository.UpdateOneItem(pctx, itemId, updateReq); err != nil {
+		return found.ItemId, err
+	}
+	if len(changes) > 0 {
+		u.recordChange(pctx, result.Id, item.ChangeActionImport, changes)
+	}
+	if newItem.ImageUrl != "" {
+		u.deleteImage(pctx, found.ImageKey, found.ThumbnailKey)
+	}
+	if newItem.Price != found.Price {
+		u.recordPrice(pctx, utils.ConvertToObjectId(itemId), newItem.Price, item.PriceReasonImport, primitive.NilObjectID)
+	}
+
+	return found.ItemId, nil
+}
+
+// ExportItems hands every item to fn as it is read, the catalogue is never held in memory.
+func (u *itemUsecase) ExportItems(pctx context.Context, fn func(row *item.ItemExportRow) error) error {
+	return u.itemRepository.StreamItems(pctx, func(result *item.Item) error {
+		return fn(&item.ItemExportRow{
+			ItemId:        "item:" + result.Id.Hex(),
+			Sku:           result.Sku,
+			Title:         result.Title,
+			Description:   result.Description,
+			Price:         result.Price,
+			Damage:        result.Damage,
+			ImageUrl:      result.ImageUrl,
+			Category:      result.Category,
+			Rarity:        result.Rarity,
+			Slot:          result.Slot,
+			Attributes:    result.Attributes,
+			Stock:         result.Stock,
+			PurchaseLimit: result.PurchaseLimit,
+			UsageStatus:   result.UsageStatus,
+			Titles:        result.Titles,
+			Descriptions:  result.Descriptions,
+		})
+	})
+}
@@ -0,0 +1,367 @@
+package itemUsecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/cursor"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// scheduleGrace lets a schedule start "now" although the request took a moment to arrive.
+const scheduleGrace = time.Minute
+
+// openSchedule matches the schedules that are not finished or cancelled.
+var openSchedule = bson.E{"status", bson.D{{"$in", bson.A{item.ScheduleStatusPending, item.ScheduleStatusActive}}}}
+
+func (u *itemUsecase) SchedulePrice(pctx context.Context, itemId string, req *item.ItemPriceScheduleReq) (*item.ItemPriceScheduleRes, error) {
+	result, err := u.itemRepository.FindOneItem(pctx, itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := utils.LocalTime()
+	if req.StartAt.IsZero() || req.StartAt.Before(now.Add(-scheduleGrace)) {
+		return nil, errors.New("error: start_at must not be in the past")
+	}
+
+	switch req.Kind {
+	case item.ScheduleKindPrice:
+		if req.Price <= 0 || req.PercentOff != 0 || !req.EndAt.IsZero() {
+			return nil, errors.New("error: a price change needs a price and no percent_off or end_at")
+		}
+	case item.ScheduleKindSale:
+		if req.PercentOff <= 0 || req.PercentOff >= 100 || req.Price != 0 {
+			return nil, errors.New("error: a sale needs a percent_off between 0 and 100 and no price")
+		}
+		if !req.EndAt.After(req.StartAt) {
+			return nil, errors.New("error: end_at must be after start_at")
+		}
+
+		// One sale at a time keeps the effective price easy to explain to players
+		overlaps, err := u.itemRepository.FindPriceSchedules(pctx, bson.D{
+			{"item_id", result.Id},
+			{"kind", item.ScheduleKindSale},
+			openSchedule,
+			{"start_at", bson.D{{"$lt", req.EndAt}}},
+			{"end_at", bson.D{{"$gt", req.StartAt}}},
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(overlaps) > 0 {
+			return nil, errors.New("error: sale overlaps another sale")
+		}
+	default:
+		return nil, errors.New("error: kind must be price or sale")
+	}
+
+	schedule := &item.ItemPriceSchedule{
+		ItemId:     result.Id,
+		Kind:       req.Kind,
+		Price:      req.Price,
+		PercentOff: req.PercentOff,
+		StartAt:    req.StartAt,
+		EndAt:      req.EndAt,
+		Status:     item.ScheduleStatusPending,
+		CreatedBy:  logger.PlayerId(pctx),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	schedule.Id, err = u.itemRepository.InsertOnePriceSchedule(pctx, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	itemLog.Info(pctx, "Price scheduled", "item_id", itemId, "schedule_id", schedule.Id.Hex(), "kind", req.Kind)
+
+	return priceScheduleRes(schedule), nil
+}
+
+func (u *itemUsecase) FindPriceSchedules(pctx context.Context, itemId string) ([]*item.ItemPriceScheduleRes, error) {
+	results, err := u.itemRepository.FindPriceSchedules(pctx, bson.D{
+		{"item_id", utils.ConvertToObjectId(itemId)},
+	}, []*options.FindOptions{options.Find().SetSort(bson.D{{"start_at", -1}})})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*item.ItemPriceScheduleRes, 0)
+	for _, result := range results {
+		res = append(res, priceScheduleRes(result))
+	}
+	return res, nil
+}
+
+// CancelPriceSchedule drops a pending schedule or ends a running sale at once.
+func (u *itemUsecase) CancelPriceSchedule(pctx context.Context, itemId, scheduleId string) error {
+	schedule, err := u.itemRepository.ClaimPriceSchedule(pctx, bson.D{
+		{"_id", utils.ConvertToObjectId(scheduleId)},
+		{"item_id", utils.ConvertToObjectId(itemId)},
+		openSchedule,
+	}, item.ScheduleStatusCancelled)
+	if err != nil {
+		return err
+	}
+	if schedule == nil {
+		return errors.New("error: price schedule not found or already finished")
+	}
+
+	if schedule.Kind == item.ScheduleKindSale && schedule.Status == item.ScheduleStatusActive {
+		u.recordSchedulePrice(pctx, schedule, item.PriceReasonSaleEnd)
+	}
+
+	itemLog.Info(pctx, "Price schedule cancelled", "item_id", itemId, "schedule_id", scheduleId)
+
+	return nil
+}
+
+// ApplyPriceSchedules applies the price changes that are due and starts and ends sales.
+// The effective price does not wait for it, it only moves the base price and the history.
+func (u *itemUsecase) ApplyPriceSchedules(pctx context.Context) {
+	now := utils.LocalTime()
+
+	// Price changes
+	for {
+		schedule, err := u.itemRepository.ClaimPriceSchedule(pctx, bson.D{
+			{"kind", item.ScheduleKindPrice},
+			{"status", item.ScheduleStatusPending},
+			{"start_at", bson.D{{"$lte", now}}},
+		}, item.ScheduleStatusDone)
+		if err != nil || schedule == nil {
+			break
+		}
+
+		if err := u.itemRepository.UpdateOneItem(pctx, schedule.ItemId.Hex(), bson.M{
+			"price":      schedule.Price,
+			"updated_at": now,
+		}); err != nil {
+			itemLog.Error(pctx, "ApplyPriceSchedules failed", "schedule_id", schedule.Id.Hex(), "error", err)
+			continue
+		}
+		u.recordPrice(pctx, schedule.ItemId, schedule.Price, item.PriceReasonSchedule, schedule.Id)
+
+		itemLog.Info(pctx, "Scheduled price applied", "item_id", schedule.ItemId.Hex(), "schedule_id", schedule.Id.Hex(), "price", schedule.Price)
+	}
+
+	// Sales that are over, a sale the service slept through is ended without starting
+	for {
+		schedule, err := u.itemRepository.ClaimPriceSchedule(pctx, bson.D{
+			{"kind", item.ScheduleKindSale},
+			openSchedule,
+			{"end_at", bson.D{{"$lte", now}}},
+		}, item.ScheduleStatusDone)
+		if err != nil || schedule == nil {
+			break
+		}
+		u.recordSchedulePrice(pctx, schedule, item.PriceReasonSaleEnd)
+	}
+
+	// Sales that begin
+	for {
+		schedule, err := u.itemRepository.ClaimPriceSchedule(pctx, bson.D{
+			{"kind", item.ScheduleKindSale},
+			{"status", item.ScheduleStatusPending},
+			{"start_at", bson.D{{"$lte", now}}},
+			{"end_at", bson.D{{"$gt", now}}},
+		}, item.ScheduleStatusActive)
+		if err != nil || schedule == nil {
+			break
+		}
+		u.recordSchedulePrice(pctx, schedule, item.PriceReasonSaleStart)
+	}
+}
+
+// recordSchedulePrice writes the price of the item of a sale that starts or ends.
+func (u *itemUsecase) recordSchedulePrice(pctx context.Context, schedule *item.ItemPriceSchedule, reason string) {
+	result, err := u.itemRepository.FindOneItem(pctx, schedule.ItemId.Hex())
+	if err != nil {
+		return
+	}
+	u.recordPrice(pctx, schedule.ItemId, result.Price, reason, schedule.Id)
+}
+
+// recordPrice writes a line of price history with the price a player pays right now. It is
+// best effort, the change it records has already been made.
+func (u *itemUsecase) recordPrice(pctx context.Context, itemId primitive.ObjectID, price float64, reason string, scheduleId primitive.ObjectID) {
+	effectivePrice := price
+	sales, err := u.activeSales(pctx, []primitive.ObjectID{itemId})
+	if err == nil {
+		if sale, ok := sales[itemId]; ok {
+			effectivePrice = item.EffectivePrice(price, sale.PercentOff)
+		}
+	}
+
+	if err := u.itemRepository.InsertOnePriceHistory(context.WithoutCancel(pctx), &item.ItemPriceHistory{
+		ItemId:         itemId,
+		Price:          price,
+		EffectivePrice: effectivePrice,
+		Reason:         reason,
+		ScheduleId:     scheduleId,
+		ChangedBy:      logger.PlayerId(pctx),
+		CreatedAt:      utils.LocalTime(),
+	}); err != nil {
+		itemLog.Error(pctx, "Record price failed", "item_id", itemId.Hex(), "reason", reason, "error", err)
+	}
+}
+
+// activeSales finds the sale running on each item right now. It goes by the sale window,
+// not the status, so a sale is on time even when ApplyPriceSchedules runs late.
+func (u *itemUsecase) activeSales(pctx context.Context, itemIds []primitive.ObjectID) (map[primitive.ObjectID]*item.ItemPriceSchedule, error) {
+	now := utils.LocalTime()
+
+	results, err := u.itemRepository.FindPriceSchedules(pctx, bson.D{
+		{"item_id", bson.D{{"$in", itemIds}}},
+		{"kind", item.ScheduleKindSale},
+		openSchedule,
+		{"start_at", bson.D{{"$lte", now}}},
+		{"end_at", bson.D{{"$gt", now}}},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	sales := make(map[primitive.ObjectID]*item.ItemPriceSchedule)
+	for _, result := range results {
+		if sale, ok := sales[result.ItemId]; !ok || result.PercentOff > sale.PercentOff {
+			sales[result.ItemId] = result
+		}
+	}
+	return sales, nil
+}
+
+// withSales adds the running sale to the items shown to players.
+func (u *itemUsecase) withSales(pctx context.Context, results ...*item.ItemShowCase) {
+	if len(results) == 0 {
+		return
+	}
+
+	itemIds := make([]primitive.ObjectID, 0, len(results))
+	for _, result := range results {
+		itemIds = append(itemIds, utils.ConvertToObjectId(strings.TrimPrefix(result.ItemId, "item:")))
+	}
+
+	sales, err := u.activeSales(pctx, itemIds)
+	if err != nil {
+		itemLog.Warn(pctx, "Find item sales failed", "error", err)
+		return
+	}
+
+	for i, result := range results {
+		if sale, ok := sales[itemIds[i]]; ok {
+			result.Sale = &item.ItemSale{
+				PercentOff: sale.PercentOff,
+				Price:      item.EffectivePrice(result.Price, sale.PercentOff),
+				EndAt:      sale.EndAt,
+			}
+		}
+	}
+}
+
+func (u *itemUsecase) FindPriceHistory(pctx context.Context, cfg *config.Config, itemId string, req *item.ItemPriceHistoryReq) (*models.PaginateRes, error) {
+	baseUrl := cfg.Paginate.ItemNextPageBasedUrl + "/" + itemId + "/price-history"
+	query := url.Values{"item_id": {itemId}}
+
+	page, err := cursor.Open(pctx, cfg.Paginate.CursorSecret, req.Start, "_id", -1, query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter
+	filter := bson.D{{"item_id", utils.ConvertToObjectId(itemId)}}
+	countFilter := append(bson.D{}, filter...)
+	if cursorFilter, ok := page.Filter(); ok {
+		filter = append(filter, cursorFilter)
+	}
+
+	// Option
+	opts := make([]*options.FindOptions, 0)
+
+	opts = append(opts, options.Find().SetSort(page.Sort()))
+	opts = append(opts, options.Find().SetLimit(page.Limit(req.Limit)))
+
+	// Find
+	results, err := u.itemRepository.FindPriceHistory(pctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results, hasPrev, hasNext := cursor.Slice(page, results, req.Limit)
+
+	// Count
+	total, err := u.itemRepository.CountPriceHistory(pctx, countFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]*item.ItemPriceHistoryRes, 0)
+	for _, result := range results {
+		historyRes := &item.ItemPriceHistoryRes{
+			Price:          result.Price,
+			EffectivePrice: result.EffectivePrice,
+			Reason:         result.Reason,
+			ChangedBy:      result.ChangedBy,
+			CreatedAt:      result.CreatedAt,
+		}
+		if !result.ScheduleId.IsZero() {
+			historyRes.ScheduleId = result.ScheduleId.Hex()
+		}
+		data = append(data, historyRes)
+	}
+
+	res := &models.PaginateRes{
+		Data:  data,
+		Total: total,
+		Limit: req.Limit,
+		First: models.FirstPaginate{
+			Href: cursor.Href(baseUrl, nil, req.Limit, ""),
+		},
+	}
+
+	if hasNext {
+		start := page.Token(pctx, cursor.Next, nil, results[len(results)-1].Id.Hex())
+		res.Next = models.NextPaginate{
+			Start: start,
+			Href:  cursor.Href(baseUrl, nil, req.Limit, start),
+		}
+	}
+
+	if hasPrev && len(results) > 0 {
+		start := page.Token(pctx, cursor.Prev, nil, results[0].Id.Hex())
+		res.Prev = models.PrevPaginate{
+			Start: start,
+			Href:  cursor.Href(baseUrl, nil, req.Limit, start),
+		}
+	}
+
+	return res, nil
+}
+
+func priceScheduleRes(schedule *item.ItemPriceSchedule) *item.ItemPriceScheduleRes {
+	res := &item.ItemPriceScheduleRes{
+		ScheduleId: schedule.Id.Hex(),
+		ItemId:     "item:" + schedule.ItemId.Hex(),
+		Kind:       schedule.Kind,
+		Price:      schedule.Price,
+		PercentOff: schedule.PercentOff,
+		StartAt:    schedule.StartAt,
+		Status:     schedule.Status,
+		CreatedBy:  schedule.CreatedBy,
+		CreatedAt:  schedule.CreatedAt,
+	}
+	if !schedule.EndAt.IsZero() {
+		endAt := schedule.EndAt
+		res.EndAt = &endAt
+	}
+	return res
+}
@@ -0,0 +1,243 @@
+package itemUsecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/cursor"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// itemSort is the key a listing is ordered by, _id breaks ties so the order is total.
+type itemSort struct {
+	key   string
+	order int
+}
+
+var itemSorts = map[string]itemSort{
+	"":            {key: "_id", order: 1},
+	"newest":      {key: "_id", order: -1},
+	"price_asc":   {key: "price", order: 1},
+	"price_desc":  {key: "price", order: -1},
+	"damage_asc":  {key: "damage", order: 1},
+	"damage_desc": {key: "damage", order: -1},
+	"popularity":  {key: "sold_count", order: -1},
+}
+
+func (s itemSort) value(result *item.ItemShowCase) any {
+	switch s.key {
+	case "price":
+		return result.Price
+	case "damage":
+		return result.Damage
+	case "sold_count":
+		return result.SoldCount
+	}
+	return nil
+}
+
+func (u *itemUsecase) FindManyItems(pctx context.Context, cfg *config.Config, req *item.ItemSearchReq) (*models.PaginateRes, error) {
+	sort, ok := itemSorts[req.Sort]
+	if !ok {
+		return nil, errors.New("error: sort is invalid")
+	}
+
+	// Filter
+	// The category and rarity filters are kept apart, each facet is counted without its own
+	searchFilter, err := itemSearchFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryFilter := bson.D{}
+	if req.Category != "" {
+		if !item.IsCategory(req.Category) {
+			return nil, errors.New("error: category is invalid")
+		}
+		categoryFilter = append(categoryFilter, bson.E{"category", req.Category})
+	}
+
+	rarityFilter := bson.D{}
+	if req.Rarity != "" {
+		if !item.IsRarity(req.Rarity) {
+			return nil, errors.New("error: rarity is invalid")
+		}
+		rarityFilter = append(rarityFilter, bson.E{"rarity", req.Rarity})
+	}
+
+	countItemsFilter := append(append(append(bson.D{}, searchFilter...), categoryFilter...), rarityFilter...)
+	findItemsFilter := append(bson.D{}, countItemsFilter...)
+
+	query := searchQuery(req)
+	page, err := cursor.Open(pctx, cfg.Paginate.CursorSecret, req.Start, sort.key, sort.order, query.Encode())
+	if err != nil {
+		itemLog.Error(pctx, "FindManyItems failed", "error", err)
+		return nil, err
+	}
+	if cursorFilter, ok := page.Filter(); ok {
+		findItemsFilter = append(findItemsFilter, cursorFilter)
+	}
+
+	// Options
+	findItemOpts := make([]*options.FindOptions, 0)
+	findItemOpts = append(findItemOpts, options.Find().SetSort(page.Sort()))
+	findItemOpts = append(findItemOpts, options.Find().SetLimit(page.Limit(req.Limit)))
+
+	// Find
+	results, err := u.itemRepository.FindManyItems(pctx, findItemsFilter, findItemOpts)
+	if err != nil {
+		return nil, err
+	}
+	results, hasPrev, hasNext := cursor.Slice(page, results, req.Limit)
+	u.withImageUrls(pctx, results...)
+	u.withSales(pctx, results...)
+	u.withLocale(pctx, results...)
+
+	// Count
+	total, err := u.itemRepository.CountItems(pctx, countItemsFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Facets
+	facets, err := u.itemRepository.FindItemFacets(pctx, mongo.Pipeline{
+		{{"$match", searchFilter}},
+		{{"$facet", bson.D{
+			{"category", bson.A{
+				bson.D{{"$match", rarityFilter}},
+				bson.D{{"$group", bson.D{{"_id", "$category"}, {"count", bson.D{{"$sum", 1}}}}}},
+				bson.D{{"$sort", bson.D{{"count", -1}, {"_id", 1}}}},
+			}},
+			{"rarity", bson.A{
+				bson.D{{"$match", categoryFilter}},
+				bson.D{{"$group", bson.D{{"_id", "$rarity"}, {"count", bson.D{{"$sum", 1}}}}}},
+				bson.D{{"$sort", bson.D{{"count", -1}, {"_id", 1}}}},
+			}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.PaginateRes{
+		Data:  results,
+		Total: total,
+		Limit: req.Limit,
+		First: models.FirstPaginate{
+			Href: cursor.Href(cfg.Paginate.ItemNextPageBasedUrl, query, req.Limit, ""),
+		},
+		Facets: facets,
+	}
+
+	if hasNext {
+		last := results[len(results)-1]
+		start := page.Token(pctx, cursor.Next, sort.value(last), strings.TrimPrefix(last.ItemId, "item:"))
+		res.Next = models.NextPaginate{
+			Start: start,
+			Href:  cursor.Href(cfg.Paginate.ItemNextPageBasedUrl, query, req.Limit, start),
+		}
+	}
+
+	if hasPrev && len(results) > 0 {
+		first := results[0]
+		start := page.Token(pctx, cursor.Prev, sort.value(first), strings.TrimPrefix(first.ItemId, "item:"))
+		res.Prev = models.PrevPaginate{
+			Start: start,
+			Href:  cursor.Href(cfg.Paginate.ItemNextPageBasedUrl, query, req.Limit, start),
+		}
+	}
+
+	return res, nil
+}
+
+// itemSearchFilter builds every filter of a search except category and rarity.
+func itemSearchFilter(req *item.ItemSearchReq) (bson.D, error) {
+	filter := bson.D{}
+
+	// A $text query has to be in the first stage of the facet pipeline, so it stays in here
+	if req.Q != "" {
+		filter = append(filter, bson.E{"$text", bson.D{{"$search", req.Q}}})
+	}
+
+	if req.Title != "" {
+		filter = append(filter, bson.E{"title", primitive.Regex{Pattern: regexp.QuoteMeta(req.Title), Options: "i"}})
+	}
+
+	if req.Slot != "" {
+		if !item.IsSlot(req.Slot) {
+			return nil, errors.New("error: slot is invalid")
+		}
+		filter = append(filter, bson.E{"slot", req.Slot})
+	}
+
+	if req.MaxPrice > 0 && req.MinPrice > req.MaxPrice {
+		return nil, errors.New("error: min_price is greater than max_price")
+	}
+	if price := rangeFilter(req.MinPrice, req.MaxPrice); len(price) > 0 {
+		filter = append(filter, bson.E{"price", price})
+	}
+
+	if req.MaxDamage > 0 && req.MinDamage > req.MaxDamage {
+		return nil, errors.New("error: min_damage is greater than max_damage")
+	}
+	if damage := rangeFilter(req.MinDamage, req.MaxDamage); len(damage) > 0 {
+		filter = append(filter, bson.E{"damage", damage})
+	}
+
+	filter = append(filter, bson.E{"usage_status", true})
+
+	return filter, nil
+}
+
+// rangeFilter treats zero as an open bound.
+func rangeFilter[T int | float64](lower, upper T) bson.D {
+	filter := bson.D{}
+	if lower > 0 {
+		filter = append(filter, bson.E{"$gte", lower})
+	}
+	if upper > 0 {
+		filter = append(filter, bson.E{"$lte", upper})
+	}
+	return filter
+}
+
+// searchQuery is the search in canonical form, it is kept in the links to the other pages
+// and a start token only works with the search it was made for.
+func searchQuery(req *item.ItemSearchReq) url.Values {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"q":        req.Q,
+		"title":    req.Title,
+		"category": req.Category,
+		"rarity":   req.Rarity,
+		"slot":     req.Slot,
+		"sort":     req.Sort,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if req.MinPrice > 0 {
+		query.Set("min_price", strconv.FormatFloat(req.MinPrice, 'f', -1, 64))
+	}
+	if req.MaxPrice > 0 {
+		query.Set("max_price", strconv.FormatFloat(req.MaxPrice, 'f', -1, 64))
+	}
+	if req.MinDamage > 0 {
+		query.Set("min_damage", strconv.Itoa(req.MinDamage))
+	}
+	if req.MaxDamage > 0 {
+		query.Set("max_damage", strconv.Itoa(req.MaxDamage))
+	}
+	return query
+}
@@ -0,0 +1,144 @@
+package itemUsecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
+	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReserveStock takes the stock and purchase counts a purchase needs, all or nothing. The
+// payment service releases the reservation when a later step of the purchase fails.
+func (u *itemUsecase) ReserveStock(pctx context.Context, req *itemPb.ReserveStockReq) (*itemPb.ReserveStockRes, error) {
+	quantities := make(map[primitive.ObjectID]int64)
+	objectIds := make([]primitive.ObjectID, 0)
+	for _, itemId := range req.Ids {
+		objectId := utils.ConvertToObjectId(strings.TrimPrefix(itemId, "item:"))
+		if quantities[objectId] == 0 {
+			objectIds = append(objectIds, objectId)
+		}
+		quantities[objectId]++
+	}
+
+	results, err := u.itemRepository.FindManyItems(pctx, bson.D{
+		{"_id", bson.D{{"$in", objectIds}}},
+		{"usage_status", true},
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(objectIds) {
+		return nil, item.ErrItemNotFound
+	}
+
+	// The id is claimed first, a reserve that is retried or comes after its release is refused
+	now := utils.LocalTime()
+	reservation := &item.ItemReservation{
+		Id:        req.ReservationId,
+		PlayerId:  req.PlayerId,
+		Items:     make([]*item.ItemReservationDatum, 0),
+		Status:    item.ReservationStatusReserving,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := u.itemRepository.InsertOneReservation(pctx, reservation); err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		objectId := utils.ConvertToObjectId(strings.TrimPrefix(result.ItemId, "item:"))
+		datum := &item.ItemReservationDatum{ItemId: objectId, Quantity: quantities[objectId]}
+
+		if err := u.reserveItem(pctx, req.PlayerId, result, datum); err != nil {
+			u.returnReservation(pctx, req.PlayerId, append(reservation.Items, datum))
+			u.itemRepository.ReleaseReservation(pctx, req.ReservationId)
+			return nil, err
+		}
+		reservation.Items = append(reservation.Items, datum)
+	}
+
+	// A release that came in meanwhile has already marked the reservation, it gets nothing back
+	reserved, err := u.itemRepository.UpdateReservation(pctx, req.ReservationId, item.ReservationStatusReserving, bson.M{
+		"status": item.ReservationStatusReserved,
+		"items":  reservation.Items,
+	})
+	if err != nil || !reserved {
+		u.returnReservation(pctx, req.PlayerId, reservation.Items)
+		u.itemRepository.ReleaseReservation(pctx, req.ReservationId)
+		if err != nil {
+			return nil, err
+		}
+		return nil, item.ErrReservationReleased
+	}
+
+	return &itemPb.ReserveStockRes{
+		ReservationId: req.ReservationId,
+	}, nil
+}
+
+// reserveItem takes the purchase count before the stock, a player at the limit does not hold
+// stock back from others. datum records what was taken, also when it fails halfway.
+func (u *itemUsecase) reserveItem(pctx context.Context, playerId string, result *item.ItemShowCase, datum *item.ItemReservationDatum) error {
+	if result.PurchaseLimit > 0 {
+		taken, err := u.itemRepository.TakePurchaseLimit(pctx, datum.ItemId, playerId, datum.Quantity, result.PurchaseLimit)
+		if err != nil {
+			return err
+		}
+		if !taken {
+			return item.ErrPurchaseLimitReached.With("error: purchase limit reached for " + result.Title)
+		}
+		datum.LimitTaken = true
+	}
+
+	if result.Stock != nil {
+		taken, err := u.itemRepository.TakeStock(pctx, datum.ItemId, datum.Quantity)
+		if err != nil {
+			return err
+		}
+		if !taken {
+			return item.ErrOutOfStock.With("error: " + result.Title + " is out of stock")
+		}
+		datum.StockTaken = true
+	}
+
+	return nil
+}
+
+// returnReservation gives back what was taken, a failure is logged and left for an admin as
+// the purchase itself has already failed.
+func (u *itemUsecase) returnReservation(pctx context.Context, playerId string, data []*item.ItemReservationDatum) {
+	for _, datum := range data {
+		if datum.StockTaken {
+			if err := u.itemRepository.ReturnStock(pctx, datum.ItemId, datum.Quantity); err != nil {
+				itemLog.Error(pctx, "ReturnStock failed", "item_id", datum.ItemId.Hex(), "quantity", datum.Quantity, "error", err)
+			}
+		}
+		if datum.LimitTaken {
+			if err := u.itemRepository.ReturnPurchaseLimit(pctx, datum.ItemId, playerId, datum.Quantity); err != nil {
+				itemLog.Error(pctx, "ReturnPurchaseLimit failed", "item_id", datum.ItemId.Hex(), "player_id", playerId, "error", err)
+			}
+		}
+	}
+}
+
+// ReleaseStock gives back a reservation, releasing twice or an id that was never reserved
+// is not an error.
+func (u *itemUsecase) ReleaseStock(pctx context.Context, req *itemPb.ReleaseStockReq) (*itemPb.ReleaseStockRes, error) {
+	reservation, err := u.itemRepository.ReleaseReservation(pctx, req.ReservationId)
+	if err != nil {
+		return nil, err
+	}
+
+	released := reservation != nil && reservation.Status == item.ReservationStatusReserved
+	if released {
+		u.returnReservation(pctx, reservation.PlayerId, reservation.Items)
+	}
+
+	return &itemPb.ReleaseStockRes{
+		Released: released,
+	}, nil
+}
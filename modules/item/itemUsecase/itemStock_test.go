@@ -0,0 +1,235 @@
+package itemUsecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
+	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
+	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemRepository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeStockRepository keeps the stock, purchase counts and reservations in memory, only the
+// methods ReserveStock and ReleaseStock use are implemented.
+type fakeStockRepository struct {
+	itemRepository.ItemRepositoryService
+	items        []*item.ItemShowCase
+	stock        map[primitive.ObjectID]int64
+	bought       map[primitive.ObjectID]int64
+	reservations map[string]*item.ItemReservation
+	// beforeUpdate runs before a reservation leaves reserving, to release it meanwhile
+	beforeUpdate func()
+}
+
+// FindManyItems reads only the _id $in condition of the filter
+func (f *fakeStockRepository) FindManyItems(pctx context.Context, filter primitive.D, opts []*options.FindOptions) ([]*item.ItemShowCase, error) {
+	objectIds := filter[0].Value.(primitive.D)[0].Value.([]primitive.ObjectID)
+	results := make([]*item.ItemShowCase, 0)
+	for _, result := range f.items {
+		for _, objectId := range objectIds {
+			if result.ItemId == "item:"+objectId.Hex() {
+				results = append(results, result)
+			}
+		}
+	}
+	return results, nil
+}
+
+func (f *fakeStockRepository) InsertOneReservation(pctx context.Context, req *item.ItemReservation) error {
+	if _, ok := f.reservations[req.Id]; ok {
+		return item.ErrReservationExists
+	}
+	stored := *req
+	f.reservations[req.Id] = &stored
+	return nil
+}
+
+func (f *fakeStockRepository) UpdateReservation(pctx context.Context, reservationId, status string, req primitive.M) (bool, error) {
+	if f.beforeUpdate != nil {
+		f.beforeUpdate()
+	}
+	r, ok := f.reservations[reservationId]
+	if !ok || r.Status != status {
+		return false, nil
+	}
+	r.Status = req["status"].(string)
+	r.Items = req["items"].([]*item.ItemReservationDatum)
+	return true, nil
+}
+
+func (f *fakeStockRepository) ReleaseReservation(pctx context.Context, reservationId string) (*item.ItemReservation, error) {
+	r, ok := f.reservations[reservationId]
+	if !ok {
+		f.reservations[reservationId] = &item.ItemReservation{Id: reservationId, Status: item.ReservationStatusReleased}
+		return nil, nil
+	}
+	before := *r
+	r.Status = item.ReservationStatusReleased
+	return &before, nil
+}
+
+func (f *fakeStockRepository) TakeStock(pctx context.Context, itemId primitive.ObjectID, quantity int64) (bool, error) {
+	if f.stock[itemId] < quantity {
+		return false, nil
+	}
+	f.stock[itemId] -= quantity
+	return true, nil
+}
+
+func (f *fakeStockRepository) ReturnStock(pctx context.Context, itemId primitive.ObjectID, quantity int64) error {
+	f.stock[itemId] += quantity
+	return nil
+}
+
+func (f *fakeStockRepository) TakePurchaseLimit(pctx context.Context, itemId primitive.ObjectID, playerId string, quantity, limit int64) (bool, error) {
+	if f.bought[itemId]+quantity > limit {
+		return false, nil
+	}
+	f.bought[itemId] += quantity
+	return true, nil
+}
+
+func (f *fakeStockRepository) ReturnPurchaseLimit(pctx context.Context, itemId primitive.ObjectID, playerId string, quantity int64) error {
+	f.bought[itemId] -= quantity
+	return nil
+}
+
+type stockStep struct {
+	release  bool
+	ids      []string
+	err      error
+	released bool
+}
+
+func TestReserveAndReleaseStock(t *testing.T) {
+	sword, shield := primitive.NewObjectID(), primitive.NewObjectID()
+	swordId, shieldId := "item:"+sword.Hex(), "item:"+shield.Hex()
+
+	tests := []struct {
+		name          string
+		steps         []stockStep
+		releaseMidway bool
+		status        string
+		stock         int64
+		bought        int64
+	}{
+		{
+			name:   "reserved",
+			steps:  []stockStep{{ids: []string{swordId, swordId, shieldId}}},
+			status: item.ReservationStatusReserved,
+			stock:  2,
+			bought: 2,
+		},
+		{
+			name: "released after reserve",
+			steps: []stockStep{
+				{ids: []string{swordId, shieldId}},
+				{release: true, released: true},
+			},
+			status: item.ReservationStatusReleased,
+			stock:  3,
+		},
+		{
+			name: "released twice",
+			steps: []stockStep{
+				{ids: []string{swordId}},
+				{release: true, released: true},
+				{release: true},
+			},
+			status: item.ReservationStatusReleased,
+			stock:  3,
+		},
+		{
+			name: "release before reserve",
+			steps: []stockStep{
+				{release: true},
+				{ids: []string{swordId}, err: item.ErrReservationExists},
+			},
+			status: item.ReservationStatusReleased,
+			stock:  3,
+		},
+		{
+			name: "reserved twice",
+			steps: []stockStep{
+				{ids: []string{swordId}},
+				{ids: []string{swordId}, err: item.ErrReservationExists},
+			},
+			status: item.ReservationStatusReserved,
+			stock:  3,
+			bought: 1,
+		},
+		{
+			name:   "out of stock gives back what was taken",
+			steps:  []stockStep{{ids: []string{swordId, shieldId, shieldId, shieldId, shieldId}, err: item.ErrOutOfStock}},
+			status: item.ReservationStatusReleased,
+			stock:  3,
+		},
+		{
+			name:   "purchase limit reached",
+			steps:  []stockStep{{ids: []string{swordId, swordId, swordId, shieldId}, err: item.ErrPurchaseLimitReached}},
+			status: item.ReservationStatusReleased,
+			stock:  3,
+		},
+		{
+			name:          "released while reserving",
+			steps:         []stockStep{{ids: []string{swordId, shieldId}, err: item.ErrReservationReleased}},
+			releaseMidway: true,
+			status:        item.ReservationStatusReleased,
+			stock:         3,
+		},
+	}
+
+	for _, tt := range tests {
+		ctx := context.Background()
+		stock := int64(3)
+		repo := &fakeStockRepository{
+			// The sword has no stock and a limit of 2, the shield has a stock of 3 and no limit
+			items: []*item.ItemShowCase{
+				{ItemId: swordId, Title: "Sword", PurchaseLimit: 2},
+				{ItemId: shieldId, Title: "Shield", Stock: &stock},
+			},
+			stock:        map[primitive.ObjectID]int64{shield: 3},
+			bought:       make(map[primitive.ObjectID]int64),
+			reservations: make(map[string]*item.ItemReservation),
+		}
+		u := &itemUsecase{itemRepository: repo}
+		if tt.releaseMidway {
+			repo.beforeUpdate = func() {
+				if _, err := u.ReleaseStock(ctx, &itemPb.ReleaseStockReq{ReservationId: "r1"}); err != nil {
+					t.Fatalf("%s: ReleaseStock error: %v", tt.name, err)
+				}
+			}
+		}
+
+		for i, step := range tt.steps {
+			if step.release {
+				res, err := u.ReleaseStock(ctx, &itemPb.ReleaseStockReq{ReservationId: "r1"})
+				if err != nil {
+					t.Fatalf("%s: step %d: ReleaseStock error: %v", tt.name, i, err)
+				}
+				if res.Released != step.released {
+					t.Errorf("%s: step %d: Released = %v, want %v", tt.name, i, res.Released, step.released)
+				}
+				continue
+			}
+
+			_, err := u.ReserveStock(ctx, &itemPb.ReserveStockReq{ReservationId: "r1", PlayerId: "player:1", Ids: step.ids})
+			if (step.err == nil && err != nil) || (step.err != nil && !errors.Is(err, step.err)) {
+				t.Errorf("%s: step %d: ReserveStock error = %v, want %v", tt.name, i, err, step.err)
+			}
+		}
+
+		if got := repo.reservations["r1"].Status; got != tt.status {
+			t.Errorf("%s: status = %s, want %s", tt.name, got, tt.status)
+		}
+		if got := repo.stock[shield]; got != tt.stock {
+			t.Errorf("%s: shield stock = %d, want %d", tt.name, got, tt.stock)
+		}
+		if got := repo.bought[sword]; got != tt.bought {
+			t.Errorf("%s: swords bought = %d, want %d", tt.name, got, tt.bought)
+		}
+	}
+}
@@ -3,183 +3,445 @@ package itemUsecase
 import (
 	"context"
 	"errors"
-	"fmt"
-	"log"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item"
 	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemRepository"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/blob"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/imaging"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/locale"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type (
 	ItemUsecaseService interface {
-		CreateItem(pctx context.Context, req *item.CreateItemReq) (*item.ItemShowCase, error)
+		CreateItem(pctx context.Context, req *item.CreateItemReq, image *item.ItemImageReq) (*item.ItemShowCase, error)
 		FindOneItem(pctx context.Context, itemId string) (*item.ItemShowCase, error)
-		FindManyItems(pctx context.Context, basePaginateUrl string, req *item.ItemSearchReq) (*models.PaginateRes, error)
-		EditItem(pctx context.Context, itemId string, req *item.ItemUpdateReq) (*item.ItemShowCase, error)
+		FindManyItems(pctx context.Context, cfg *config.Config, req *item.ItemSearchReq) (*models.PaginateRes, error)
+		EditItem(pctx context.Context, itemId string, req *item.ItemUpdateReq, image *item.ItemImageReq) (*item.ItemShowCase, error)
 		EnableOrDisableItem(pctx context.Context, itemId string) (bool, error)
+		DeleteItem(pctx context.Context, itemId string) error
+		RestoreItem(pctx context.Context, itemId string) (*item.ItemShowCase, error)
+		FindItemHistory(pctx context.Context, cfg *config.Config, itemId string, req *item.ItemHistoryReq) (*models.PaginateRes, error)
 		FindItemInIds(pctx context.Context, req *itemPb.FindItemsInIdsReq) (*itemPb.FindItemsInIdsRes, error)
+		IncreaseSoldCount(pctx context.Context, req *itemPb.IncreaseSoldCountReq) (*itemPb.IncreaseSoldCountRes, error)
+		ImportItems(pctx context.Context, rows []*item.ItemImportRow, dryRun bool) (*item.ItemImportRes, error)
+		ExportItems(pctx context.Context, fn func(row *item.ItemExportRow) error) error
+		CreateBundle(pctx context.Context, req *item.CreateBundleReq) (*item.BundleShowCase, error)
+		FindOneBundle(pctx context.Context, bundleId string) (*item.BundleShowCase, error)
+		FindManyBundles(pctx context.Context) ([]*item.BundleShowCase, error)
+		EnableOrDisableBundle(pctx context.Context, bundleId string) (bool, error)
+		ReserveStock(pctx context.Context, req *itemPb.ReserveStockReq) (*itemPb.ReserveStockRes, error)
+		ReleaseStock(pctx context.Context, req *itemPb.ReleaseStockReq) (*itemPb.ReleaseStockRes, error)
+		SchedulePrice(pctx context.Context, itemId string, req *item.ItemPriceScheduleReq) (*item.ItemPriceScheduleRes, error)
+		FindPriceSchedules(pctx context.Context, itemId string) ([]*item.ItemPriceScheduleRes, error)
+		CancelPriceSchedule(pctx context.Context, itemId, scheduleId string) error
+		FindPriceHistory(pctx context.Context, cfg *config.Config, itemId string, req *item.ItemPriceHistoryReq) (*models.PaginateRes, error)
+		ApplyPriceSchedules(pctx context.Context)
 	}
 
 	itemUsecase struct {
 		itemRepository itemRepository.ItemRepositoryService
+		blob           blob.BlobService
 	}
 )
 
-func NewItemUsecase(itemRepository itemRepository.ItemRepositoryService) ItemUsecaseService {
-	return &itemUsecase{itemRepository: itemRepository}
+// thumbnailSize is the largest side of a thumbnail in pixels
+const thumbnailSize = 256
+
+var itemLog = logger.New("item")
+
+func NewItemUsecase(itemRepository itemRepository.ItemRepositoryService, blob blob.BlobService) ItemUsecaseService {
+	return &itemUsecase{itemRepository: itemRepository, blob: blob}
 }
 
-func (u *itemUsecase) CreateItem(pctx context.Context, req *item.CreateItemReq) (*item.ItemShowCase, error) {
+func (u *itemUsecase) CreateItem(pctx context.Context, req *item.CreateItemReq, image *item.ItemImageReq) (*item.ItemShowCase, error) {
+	if req.ImageUrl != "" && image != nil {
+		return nil, errors.New("error: send either image_url or image")
+	}
+	if req.ImageUrl == "" && image == nil {
+		return nil, errors.New("error: image_url or image is required")
+	}
+
+	if (req.Stock != nil && *req.Stock < 0) || req.PurchaseLimit < 0 {
+		return nil, errors.New("error: stock and purchase_limit must not be negative")
+	}
+
 	if !u.itemRepository.IsUniqueItem(pctx, req.Title) {
 		return nil, errors.New("error: this title is already exist")
 	}
 
-	itemId, err := u.itemRepository.InsertOneItem(pctx, &item.Item{
-		Title:       req.Title,
-		Price:       req.Price,
-		Damage:      req.Damage,
-		UsageStatus: true,
-		ImageUrl:    req.ImageUrl,
-		CreatedAt:   utils.LocalTime(),
-		UpdatedAt:   utils.LocalTime(),
-	})
+	if req.Sku != "" {
+		count, err := u.itemRepository.CountItems(pctx, bson.D{{"sku", req.Sku}})
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, errors.New("error: this sku is already exist")
+		}
+	}
+
+	itemType := &item.ItemType{Category: req.Category, Rarity: req.Rarity, Slot: req.Slot}
+	if err := itemType.Normalize(); err != nil {
+		itemLog.Error(pctx, "CreateItem failed", "error", err)
+		return nil, err
+	}
+
+	attributes, err := item.ParseAttributes(itemType.Category, req.Attributes)
+	if err != nil {
+		itemLog.Error(pctx, "CreateItem failed", "error", err)
+		return nil, err
+	}
+
+	titles, descriptions, err := parseLocalized(req.Description, req.Titles, req.Descriptions)
 	if err != nil {
+		itemLog.Error(pctx, "CreateItem failed", "error", err)
 		return nil, err
 	}
 
+	newItem := &item.Item{
+		Sku:           req.Sku,
+		Title:         req.Title,
+		Titles:        titles,
+		Description:   strings.TrimSpace(req.Description),
+		Descriptions:  descriptions,
+		Price:         req.Price,
+		Damage:        req.Damage,
+		UsageStatus:   true,
+		ImageUrl:      req.ImageUrl,
+		Category:      itemType.Category,
+		Rarity:        itemType.Rarity,
+		Slot:          itemType.Slot,
+		Attributes:    attributes,
+		Stock:         req.Stock,
+		PurchaseLimit: req.PurchaseLimit,
+		CreatedAt:     utils.LocalTime(),
+		UpdatedAt:     utils.LocalTime(),
+	}
+
+	if image != nil {
+		newItem.ImageKey, newItem.ThumbnailKey, err = u.uploadImage(pctx, image)
+		if err != nil {
+			itemLog.Error(pctx, "CreateItem failed", "error", err)
+			return nil, err
+		}
+	}
+
+	itemId, err := u.itemRepository.InsertOneItem(pctx, newItem)
+	if err != nil {
+		u.deleteImage(pctx, newItem.ImageKey, newItem.ThumbnailKey)
+		return nil, err
+	}
+	u.recordPrice(pctx, itemId, newItem.Price, item.PriceReasonCreate, primitive.NilObjectID)
+	u.recordChange(pctx, itemId, item.ChangeActionCreate, nil)
+
 	return u.FindOneItem(pctx, itemId.Hex())
 }
 
-func (u *itemUsecase) FindOneItem(pctx context.Context, itemId string) (*item.ItemShowCase, error) {
-	result, err := u.itemRepository.FindOneItem(pctx, itemId)
+// parseLocalized checks the description and the translations of a request. A bound request
+// is not validated, so the length of the description is checked here too.
+func parseLocalized(description string, titles, descriptions map[string]string) (map[string]string, map[string]string, error) {
+	if utf8.RuneCountInString(strings.TrimSpace(description)) > item.MaxDescriptionLength {
+		return nil, nil, errors.New("error: description is at most " + strconv.Itoa(item.MaxDescriptionLength) + " characters")
+	}
+
+	titles, err := item.ParseTranslations("titles", titles, item.MaxTitleLength)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	descriptions, err = item.ParseTranslations("descriptions", descriptions, item.MaxDescriptionLength)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return &item.ItemShowCase{
-		ItemId:   "item:" + result.Id.Hex(),
-		Title:    result.Title,
-		Price:    result.Price,
-		Damage:   result.Damage,
-		ImageUrl: result.ImageUrl,
-	}, nil
+	return titles, descriptions, nil
 }
 
-func (u *itemUsecase) FindManyItems(pctx context.Context, basePaginateUrl string, req *item.ItemSearchReq) (*models.PaginateRes, error) {
-	findItemsFilter := bson.D{}
-	findItemOpts := make([]*options.FindOptions, 0)
+// uploadImage stores the image and its thumbnail under new keys, an upload never overwrites
+// an image that a cached response may still point at.
+func (u *itemUsecase) uploadImage(pctx context.Context, image *item.ItemImageReq) (imageKey, thumbnailKey string, err error) {
+	contentType, ext, err := imaging.Detect(image.Data)
+	if err != nil {
+		return "", "", err
+	}
 
-	countItemsFilter := bson.D{}
+	thumbnail, err := imaging.Thumbnail(image.Data, thumbnailSize)
+	if err != nil {
+		return "", "", err
+	}
 
-	// Filter
-	if req.Start != "" {
-		req.Start = strings.TrimPrefix(req.Start, "item:")
-		findItemsFilter = append(findItemsFilter, bson.E{"_id", bson.D{{"$gt", utils.ConvertToObjectId(req.Start)}}})
+	name := "items/" + uuid.NewString()
+	imageKey = name + "." + ext
+	thumbnailKey = name + "_thumb.jpg"
+
+	if err := u.blob.Put(pctx, imageKey, contentType, image.Data); err != nil {
+		return "", "", err
+	}
+	if err := u.blob.Put(pctx, thumbnailKey, "image/jpeg", thumbnail); err != nil {
+		u.deleteImage(pctx, imageKey)
+		return "", "", err
 	}
 
-	if req.Title != "" {
-		findItemsFilter = append(findItemsFilter, bson.E{"title", primitive.Regex{Pattern: req.Title, Options: "i"}})
-		countItemsFilter = append(countItemsFilter, bson.E{"title", primitive.Regex{Pattern: req.Title, Options: "i"}})
+	itemLog.Info(pctx, "Item image uploaded", "filename", image.Filename, "key", imageKey, "size", len(image.Data))
+
+	return imageKey, thumbnailKey, nil
+}
+
+// deleteImage is best effort, a blob left behind costs storage but breaks nothing.
+func (u *itemUsecase) deleteImage(pctx context.Context, keys ...string) {
+	ctx := context.WithoutCancel(pctx)
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := u.blob.Delete(ctx, key); err != nil {
+			itemLog.Warn(ctx, "Delete item image failed", "key", key, "error", err)
+		}
 	}
+}
 
-	findItemsFilter = append(findItemsFilter, bson.E{"usage_status", true})
-	countItemsFilter = append(countItemsFilter, bson.E{"usage_status", true})
+// withImageUrls turns the keys of uploaded images into urls, items with an external
+// image_url are left as they are.
+func (u *itemUsecase) withImageUrls(pctx context.Context, results ...*item.ItemShowCase) {
+	for _, result := range results {
+		if result.ImageKey != "" {
+			if imageUrl, err := u.blob.Url(result.ImageKey); err == nil {
+				result.ImageUrl = imageUrl
+			} else {
+				itemLog.Warn(pctx, "Item image url failed", "key", result.ImageKey, "error", err)
+			}
+		}
+		if result.ThumbnailKey != "" {
+			if thumbnailUrl, err := u.blob.Url(result.ThumbnailKey); err == nil {
+				result.ThumbnailUrl = thumbnailUrl
+			}
+		}
+	}
+}
 
-	// Options
-	findItemOpts = append(findItemOpts, options.Find().SetSort(bson.D{{"_id", 1}}))
-	findItemOpts = append(findItemOpts, options.Find().SetLimit(int64(req.Limit)))
+// withLocale translates the showcases to the locale of the request.
+func (u *itemUsecase) withLocale(pctx context.Context, results ...*item.ItemShowCase) {
+	l := locale.FromContext(pctx)
+	for _, result := range results {
+		result.Localize(l)
+	}
+}
 
-	// Find
-	results, err := u.itemRepository.FindManyItems(pctx, findItemsFilter, findItemOpts)
+func (u *itemUsecase) FindOneItem(pctx context.Context, itemId string) (*item.ItemShowCase, error) {
+	result, err := u.itemRepository.FindOneItem(pctx, itemId)
 	if err != nil {
 		return nil, err
 	}
+	if result.DeletedAt != nil {
+		return nil, item.ErrItemNotFound
+	}
 
-	// Count
-	total, err := u.itemRepository.CountItems(pctx, countItemsFilter)
-	if err != nil {
-		return nil, err
+	res := &item.ItemShowCase{
+		ItemId:        "item:" + result.Id.Hex(),
+		Sku:           result.Sku,
+		Title:         result.Title,
+		Description:   result.Description,
+		Price:         result.Price,
+		Damage:        result.Damage,
+		ImageUrl:      result.ImageUrl,
+		ImageKey:      result.ImageKey,
+		ThumbnailKey:  result.ThumbnailKey,
+		Category:      result.Category,
+		Rarity:        result.Rarity,
+		Slot:          result.Slot,
+		Attributes:    result.Attributes,
+		Stock:         result.Stock,
+		PurchaseLimit: result.PurchaseLimit,
+		Titles:        result.Titles,
+		Descriptions:  result.Descriptions,
 	}
+	u.withImageUrls(pctx, res)
+	u.withSales(pctx, res)
+	u.withLocale(pctx, res)
 
-	if len(results) == 0 {
-		return &models.PaginateRes{
-			Data:  make([]*item.ItemShowCase, 0),
-			Total: 0,
-			Limit: req.Limit,
-			First: models.FirstPaginate{
-				Href: fmt.Sprintf("%s?limit=%d&title=%s", basePaginateUrl, req.Limit, req.Title),
-			},
-			Next: models.NextPaginate{
-				Start: "",
-				Href:  "",
-			},
-		}, nil
-	}
-
-	return &models.PaginateRes{
-		Data:  results,
-		Total: total,
-		Limit: req.Limit,
-		First: models.FirstPaginate{
-			Href: fmt.Sprintf("%s?limit=%d&title=%s", basePaginateUrl, req.Limit, req.Title),
-		},
-		Next: models.NextPaginate{
-			Start: results[len(results)-1].ItemId,
-			Href:  fmt.Sprintf("%s?limit=%d&title=%s&start=%s", basePaginateUrl, req.Limit, req.Title, results[len(results)-1].ItemId),
-		},
-	}, nil
+	return res, nil
 }
 
-func (u *itemUsecase) EditItem(pctx context.Context, itemId string, req *item.ItemUpdateReq) (*item.ItemShowCase, error) {
+func (u *itemUsecase) EditItem(pctx context.Context, itemId string, req *item.ItemUpdateReq, image *item.ItemImageReq) (*item.ItemShowCase, error) {
+	if req.ImageUrl != "" && image != nil {
+		return nil, errors.New("error: send either image_url or image")
+	}
+
+	result, err := u.itemRepository.FindOneItem(pctx, itemId)
+	if err != nil {
+		return nil, err
+	}
+	if result.DeletedAt != nil {
+		return nil, errors.New("error: item is deleted, restore it first")
+	}
+
 	updateReq := bson.M{}
 
 	if req.Title != "" {
 		if !u.itemRepository.IsUniqueItem(pctx, req.Title) {
-			log.Printf("Error: EditItem failed: this title is already exist")
+			itemLog.Error(pctx, "EditItem failed: this title is already exist")
 			return nil, errors.New("error: this title is already exist")
 		}
 
 		updateReq["title"] = req.Title
 	}
 
+	titles, descriptions, err := parseLocalized(req.Description, req.Titles, req.Descriptions)
+	if err != nil {
+		itemLog.Error(pctx, "EditItem failed", "error", err)
+		return nil, err
+	}
+	if description := strings.TrimSpace(req.Description); description != "" {
+		updateReq["description"] = description
+	}
+	if req.Titles != nil {
+		updateReq["titles"] = titles
+	}
+	if req.Descriptions != nil {
+		updateReq["descriptions"] = descriptions
+	}
+
+	// The uploaded image being replaced is deleted once the item no longer points at it
+	oldImageKeys := make([]string, 0)
+	if req.ImageUrl != "" || image != nil {
+		oldImageKeys = append(oldImageKeys, result.ImageKey, result.ThumbnailKey)
+	}
+
 	if req.ImageUrl != "" {
 		updateReq["image_url"] = req.ImageUrl
+		updateReq["image_key"] = ""
+		updateReq["thumbnail_key"] = ""
 	}
 
 	if req.Damage > 0 {
 		updateReq["damage"] = req.Damage
 	}
 
-	if req.Price >= 0 {
+	if (req.Stock != nil && *req.Stock < -1) || (req.PurchaseLimit != nil && *req.PurchaseLimit < 0) {
+		return nil, errors.New("error: stock must be -1 or more and purchase_limit must not be negative")
+	}
+
+	if req.Stock != nil {
+		if *req.Stock < 0 {
+			updateReq["stock"] = nil
+		} else {
+			updateReq["stock"] = *req.Stock
+		}
+	}
+
+	if req.PurchaseLimit != nil {
+		updateReq["purchase_limit"] = *req.PurchaseLimit
+	}
+
+	// A price left out of the request is zero, not a new price
+	priceChanged := req.Price > 0 && req.Price != result.Price
+	if priceChanged {
 		updateReq["price"] = req.Price
 	}
 
+	if req.Category != "" || req.Rarity != "" || req.Slot != "" || req.Attributes != nil {
+		if err := editItemType(result, req, updateReq); err != nil {
+			itemLog.Error(pctx, "EditItem failed", "error", err)
+			return nil, err
+		}
+	}
+
+	newImageKeys := make([]string, 0)
+	if image != nil {
+		imageKey, thumbnailKey, err := u.uploadImage(pctx, image)
+		if err != nil {
+			itemLog.Error(pctx, "EditItem failed", "error", err)
+			return nil, err
+		}
+		newImageKeys = append(newImageKeys, imageKey, thumbnailKey)
+		updateReq["image_key"] = imageKey
+		updateReq["thumbnail_key"] = thumbnailKey
+	}
+
 	updateReq["updated_at"] = utils.LocalTime()
+	changes := item.Diff(result, updateReq)
 
 	if err := u.itemRepository.UpdateOneItem(pctx, itemId, updateReq); err != nil {
+		u.deleteImage(pctx, newImageKeys...)
 		return nil, err
 	}
+	u.deleteImage(pctx, oldImageKeys...)
+	if len(changes) > 0 {
+		u.recordChange(pctx, result.Id, item.ChangeActionEdit, changes)
+	}
+
+	if priceChanged {
+		u.recordPrice(pctx, result.Id, req.Price, item.PriceReasonEdit, primitive.NilObjectID)
+	}
 
 	return u.FindOneItem(pctx, itemId)
 }
 
+// editItemType checks the new type and attributes against the stored item, a change of
+// category moves the item to the default slot of the new category unless a slot is sent.
+func editItemType(result *item.Item, req *item.ItemUpdateReq, updateReq bson.M) error {
+	itemType := &item.ItemType{Category: result.Category, Rarity: result.Rarity, Slot: result.Slot}
+	if req.Category != "" && req.Category != itemType.Category {
+		itemType.Category = req.Category
+		itemType.Slot = ""
+	}
+	if req.Rarity != "" {
+		itemType.Rarity = req.Rarity
+	}
+	if req.Slot != "" {
+		itemType.Slot = req.Slot
+	}
+	if err := itemType.Normalize(); err != nil {
+		return err
+	}
+
+	attributes := result.Attributes
+	if req.Attributes != nil {
+		var err error
+		attributes, err = item.ParseAttributes(itemType.Category, req.Attributes)
+		if err != nil {
+			return err
+		}
+	} else if !attributes.Allows(itemType.Category) {
+		return errors.New("error: attributes do not fit the new category")
+	}
+
+	updateReq["category"] = itemType.Category
+	updateReq["rarity"] = itemType.Rarity
+	updateReq["slot"] = itemType.Slot
+	updateReq["attributes"] = attributes
+
+	return nil
+}
+
 func (u *itemUsecase) EnableOrDisableItem(pctx context.Context, itemId string) (bool, error) {
 	result, err := u.itemRepository.FindOneItem(pctx, itemId)
 	if err != nil {
 		return false, err
 	}
+	if result.DeletedAt != nil {
+		return false, errors.New("error: item is deleted, restore it first")
+	}
 
 	if err := u.itemRepository.EnableOrDisableItem(pctx, itemId, !result.UsageStatus); err != nil {
 		return false, err
 	}
 
+	action := item.ChangeActionEnable
+	if result.UsageStatus {
+		action = item.ChangeActionDisable
+	}
+	u.recordChange(pctx, result.Id, action, item.Diff(result, bson.M{"usage_status": !result.UsageStatus}))
+
 	return !result.UsageStatus, nil
 }
 
@@ -187,7 +449,12 @@ func (u *itemUsecase) FindItemInIds(pctx context.Context, req *itemPb.FindItemsI
 	filter := bson.D{}
 
 	objectIds := make([]primitive.ObjectID, 0)
+	bundleIds := make([]primitive.ObjectID, 0)
 	for _, itemId := range req.Ids {
+		if strings.HasPrefix(itemId, item.BundleIdPrefix) {
+			bundleIds = append(bundleIds, utils.ConvertToObjectId(strings.TrimPrefix(itemId, item.BundleIdPrefix)))
+			continue
+		}
 		objectIds = append(objectIds, utils.ConvertToObjectId(strings.TrimPrefix(itemId, "item:")))
 	}
 
@@ -199,18 +466,66 @@ func (u *itemUsecase) FindItemInIds(pctx context.Context, req *itemPb.FindItemsI
 		return nil, err
 	}
 
+	u.withImageUrls(pctx, results...)
+	u.withLocale(pctx, results...)
+
+	// The price is the one a player pays now, a buyer is never charged a sale that is over
+	sales, err := u.activeSales(pctx, objectIds)
+	if err != nil {
+		return nil, err
+	}
+
 	resultsToRes := make([]*itemPb.Item, 0)
 
 	for _, result := range results {
+		price := result.Price
+		if sale, ok := sales[utils.ConvertToObjectId(strings.TrimPrefix(result.ItemId, "item:"))]; ok {
+			price = item.EffectivePrice(result.Price, sale.PercentOff)
+		}
+
 		resultsToRes = append(resultsToRes, &itemPb.Item{
-			Id:       result.ItemId,
-			Title:    result.Title,
-			Price:    result.Price,
-			Damage:   int32(result.Damage),
-			ImageUrl: result.ImageUrl,
+			Id:           result.ItemId,
+			Title:        result.Title,
+			Description:  result.Description,
+			Price:        price,
+			Damage:       int32(result.Damage),
+			ImageUrl:     result.ImageUrl,
+			ThumbnailUrl: result.ThumbnailUrl,
+			Category:     result.Category,
+			Rarity:       result.Rarity,
+			Slot:         result.Slot,
+			Attributes: &itemPb.ItemAttributes{
+				Defense:    int32(result.Attributes.Defense),
+				Durability: int32(result.Attributes.Durability),
+				Effects:    result.Attributes.Effects,
+			},
 		})
 	}
+	bundles, err := u.findBundlesInIds(pctx, bundleIds)
+	if err != nil {
+		return nil, err
+	}
+	resultsToRes = append(resultsToRes, bundles...)
+
 	return &itemPb.FindItemsInIdsRes{
 		Items: resultsToRes,
 	}, nil
 }
+
+// IncreaseSoldCount counts the items of a finished purchase, an item bought twice in one
+// purchase counts twice. The count is what the popularity sort orders by.
+func (u *itemUsecase) IncreaseSoldCount(pctx context.Context, req *itemPb.IncreaseSoldCountReq) (*itemPb.IncreaseSoldCountRes, error) {
+	soldCounts := make(map[primitive.ObjectID]int64)
+	for _, itemId := range req.Ids {
+		soldCounts[utils.ConvertToObjectId(strings.TrimPrefix(itemId, "item:"))]++
+	}
+
+	modified, err := u.itemRepository.IncreaseSoldCount(pctx, soldCounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &itemPb.IncreaseSoldCountRes{
+		Modified: modified,
+	}, nil
+}
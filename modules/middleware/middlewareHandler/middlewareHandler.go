@@ -13,7 +13,7 @@ import (
 type (
 	MiddlewareHandlerService interface {
 		JwtAuthorization(next echo.HandlerFunc) echo.HandlerFunc
-		RbacAuthorization(next echo.HandlerFunc, expected []int) echo.HandlerFunc
+		RequirePermission(permission string) echo.MiddlewareFunc
 		PlayerIdParamValidation(next echo.HandlerFunc) echo.HandlerFunc
 	}
 
@@ -42,13 +42,15 @@ func (h *middlewareHandler) JwtAuthorization(next echo.HandlerFunc) echo.Handler
 	}
 }
 
-func (h *middlewareHandler) RbacAuthorization(next echo.HandlerFunc, expected []int) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		newCtx, err := h.middlewareUsecase.RbacAuthorization(c, h.cfg, expected)
-		if err != nil {
-			return response.ErrorResponse(c, http.StatusForbidden, err.Error())
+func (h *middlewareHandler) RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			newCtx, err := h.middlewareUsecase.RequirePermission(c, permission)
+			if err != nil {
+				return response.ErrorResponse(c, http.StatusForbidden, err.Error())
+			}
+			return next(newCtx)
 		}
-		return next(newCtx)
 	}
 }
 
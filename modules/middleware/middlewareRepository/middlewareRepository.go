@@ -3,35 +3,36 @@ package middlewareRepository
 import (
 	"context"
 	"errors"
-	"log"
-	"time"
 
 	authPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/auth/authPb"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/deadline"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
 )
 
 type (
 	MiddlewareRepositoryService interface {
 		AccessTokenSearch(pctx context.Context, grpcUrl, accessToken string) error
-		RolesCount(pctx context.Context, grpcUrl string) (int64, error)
 	}
 
 	middlewareRepository struct{}
 )
 
+var middlewareLog = logger.New("middleware")
+
 func NewMiddlewareRepository() MiddlewareRepositoryService {
 	return &middlewareRepository{}
 }
 
 func (m *middlewareRepository) AccessTokenSearch(pctx context.Context, grpcUrl, accessToken string) error {
-	ctx, cancel := context.WithTimeout(pctx, 30*time.Second)
+	ctx, cancel := deadline.Grpc(pctx)
 	defer cancel()
 
 	jwtauth.SetApiKeyInContext(&ctx)
 	conn, err := grpccon.NewGrpcClient(grpcUrl)
 	if err != nil {
-		log.Printf("Error: gRPC connection failed: %s", err.Error())
+		middlewareLog.Error(ctx, "gRPC connection failed", "error", err)
 		return errors.New("error: gRPC connection failed")
 	}
 
@@ -39,44 +40,19 @@ func (m *middlewareRepository) AccessTokenSearch(pctx context.Context, grpcUrl,
 		AccessToken: accessToken,
 	})
 	if err != nil {
-		log.Printf("Error: CredentialSearch failed: %s", err.Error())
+		middlewareLog.Error(ctx, "CredentialSearch failed", "error", err)
 		return errors.New("error: email or password is incorrect")
 	}
 
 	if result == nil {
-		log.Printf("Error: access token is invalid")
+		middlewareLog.Error(ctx, "access token is invalid")
 		return errors.New("error: access token is invalid")
 	}
 
 	if !result.IsValid {
-		log.Printf("Error: access token is invalid")
+		middlewareLog.Error(ctx, "access token is invalid")
 		return errors.New("error: access token is invalid")
 	}
 
 	return nil
 }
-
-func (r *middlewareRepository) RolesCount(pctx context.Context, grpcUrl string) (int64, error) {
-	ctx, cancel := context.WithTimeout(pctx, 30*time.Second)
-	defer cancel()
-
-	jwtauth.SetApiKeyInContext(&ctx)
-	conn, err := grpccon.NewGrpcClient(grpcUrl)
-	if err != nil {
-		log.Printf("Error: gRPC connection failed: %s", err.Error())
-		return -1, errors.New("error: gRPC connection failed")
-	}
-
-	result, err := conn.Auth().RolesCount(ctx, &authPb.RolesCountReq{})
-	if err != nil {
-		log.Printf("Error: CredentialSearch failed: %s", err.Error())
-		return -1, errors.New("error: email or password is incorrect")
-	}
-
-	if result == nil {
-		log.Printf("Error: roles count failed")
-		return -1, errors.New("error: roles count failed")
-	}
-
-	return result.Count, nil
-}
@@ -2,11 +2,11 @@ package middlewareUsecase
 
 import (
 	"errors"
-	"log"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/middleware/middlewareRepository"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/rbac"
 	"github.com/labstack/echo/v4"
 )
@@ -14,7 +14,7 @@ import (
 type (
 	MiddlewareUsecaseService interface {
 		JwtAuthorization(c echo.Context, cfg *config.Config, accessToken string) (echo.Context, error)
-		RbacAuthorization(c echo.Context, cfg *config.Config, expected []int) (echo.Context, error)
+		RequirePermission(c echo.Context, permission string) (echo.Context, error)
 		PlayerParamValidation(c echo.Context) (echo.Context, error)
 	}
 
@@ -23,6 +23,8 @@ type (
 	}
 )
 
+var middlewareLog = logger.New("middleware")
+
 func NewMiddlewareUsecase(middlewareRepository middlewareRepository.MiddlewareRepositoryService) MiddlewareUsecaseService {
 	return &middlewareUsecase{middlewareRepository}
 }
@@ -40,30 +42,26 @@ func (u *middlewareUsecase) JwtAuthorization(c echo.Context, cfg *config.Config,
 	}
 
 	c.Set("player_id", claims.PlayerId)
-	c.Set("role_code", claims.RoleCode)
+	c.Set("roles", claims.Roles)
+	c.Set("permissions", claims.Permissions)
+	c.SetRequest(c.Request().WithContext(logger.WithPlayerId(ctx, claims.PlayerId)))
 
 	return c, nil
 }
 
-func (u *middlewareUsecase) RbacAuthorization(c echo.Context, cfg *config.Config, expected []int) (echo.Context, error) {
-	ctx := c.Request().Context()
-
-	playerRoleCode := c.Get("role_code").(int)
-
-	rolesCount, err := u.middlewareRepository.RolesCount(ctx, cfg.Grpc.AuthUrl)
-	if err != nil {
-		return nil, err
+func (u *middlewareUsecase) RequirePermission(c echo.Context, permission string) (echo.Context, error) {
+	permissions, ok := c.Get("permissions").([]string)
+	if !ok {
+		middlewareLog.Error(c.Request().Context(), "permissions not found in context")
+		return nil, errors.New("error: permission denied")
 	}
 
-	playerRoleCodeBinary := rbac.IntToBinary(playerRoleCode, int(rolesCount))
-
-	for i := 0; i < int(rolesCount); i++ {
-		if playerRoleCodeBinary[i]&expected[i] == 1 {
-			return c, nil
-		}
+	if !rbac.HasPermission(permissions, permission) {
+		middlewareLog.Warn(c.Request().Context(), "Permission denied", "permission", permission)
+		return nil, errors.New("error: permission denied")
 	}
 
-	return nil, errors.New("error: permission denied")
+	return c, nil
 }
 
 func (u *middlewareUsecase) PlayerParamValidation(c echo.Context) (echo.Context, error) {
@@ -71,12 +69,12 @@ func (u *middlewareUsecase) PlayerParamValidation(c echo.Context) (echo.Context,
 	playerIdToken := c.Get("player_id").(string)
 
 	if playerIdToken == "" {
-		log.Printf("Error: player_id_token is not found")
+		middlewareLog.Error(c.Request().Context(), "player_id_token is not found")
 		return nil, errors.New("error: player_id_token is not found")
 	}
 
 	if playerIdToken != playerIdReq {
-		log.Printf("Error: player_id not match, player_id_req: %s, player_id_token: %s", playerIdReq, playerIdToken)
+		middlewareLog.Warn(c.Request().Context(), "player_id not match", "player_id_req", playerIdReq, "player_id_token", playerIdToken)
 		return nil, errors.New("error: player_id not match")
 	}
 
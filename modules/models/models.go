@@ -2,7 +2,7 @@ package models
 
 type (
 	PaginateReq struct {
-		Start string `query:"start" validate:"max=64"`
+		Start string `query:"start" validate:"max=512"`
 		Limit int    `query:"limit" validate:"required,min=2,max=10"`
 	}
 
@@ -12,6 +12,9 @@ type (
 		Total int64         `json:"total"`
 		First FirstPaginate `json:"first"`
 		Next  NextPaginate  `json:"next"`
+		Prev  PrevPaginate  `json:"prev"`
+		// Facets is set by listings that support faceted search
+		Facets any `json:"facets,omitempty"`
 	}
 
 	FirstPaginate struct {
@@ -23,6 +26,11 @@ type (
 		Href  string `json:"href"`
 	}
 
+	PrevPaginate struct {
+		Start string `json:"start"`
+		Href  string `json:"href"`
+	}
+
 	KafkaOffset struct {
 		Offset int64 `json:"offset" bson:"offset"`
 	}
@@ -1,7 +1,6 @@
 package paymentHandler
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
@@ -29,7 +28,7 @@ func NewPaymentHttpHandler(cfg *config.Config, paymentUsecase paymentUsecase.Pay
 }
 
 func (h *paymentHttpHandler) BuyItem(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
@@ -52,7 +51,7 @@ func (h *paymentHttpHandler) BuyItem(c echo.Context) error {
 }
 
 func (h *paymentHttpHandler) SellItem(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
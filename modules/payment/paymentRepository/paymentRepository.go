@@ -4,25 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
-	"time"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/inventory"
 	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/deadline"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/queue"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/status"
 )
 
 type (
 	PaymentRepositoryService interface {
 		FindItemsInIds(pctx context.Context, grpcUrl string, req *itemPb.FindItemsInIdsReq) (*itemPb.FindItemsInIdsRes, error)
+		IncreaseSoldCount(pctx context.Context, grpcUrl string, req *itemPb.IncreaseSoldCountReq) error
+		ReserveStock(pctx context.Context, grpcUrl string, req *itemPb.ReserveStockReq) error
+		ReleaseStock(pctx context.Context, grpcUrl string, req *itemPb.ReleaseStockReq) error
 		GetOffset(pctx context.Context) (int64, error)
 		UpsertOffset(pctx context.Context, offset int64) error
 		DockedPlayerMoney(pctx context.Context, cfg *config.Config, req *player.CreatePlayerTransactionReq) error
@@ -39,6 +43,8 @@ type (
 	}
 )
 
+var paymentLog = logger.New("payment")
+
 func NewPaymentRepository(db *mongo.Client) PaymentRepositoryService {
 	return &paymentRepository{db: db}
 }
@@ -48,7 +54,7 @@ func (r *paymentRepository) paymentDbConn(ptcx context.Context) *mongo.Database
 }
 
 func (r *paymentRepository) GetOffset(pctx context.Context) (int64, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.paymentDbConn(ctx)
@@ -56,14 +62,14 @@ func (r *paymentRepository) GetOffset(pctx context.Context) (int64, error) {
 
 	result := new(models.KafkaOffset)
 	if err := col.FindOne(ctx, bson.M{}).Decode(result); err != nil {
-		log.Printf("Error: GetOffset failed: %s", err.Error())
+		paymentLog.Error(ctx, "GetOffset failed", "error", err)
 		return -1, errors.New("error: GetOffset failed")
 	}
 	return result.Offset, nil
 }
 
 func (r *paymentRepository) UpsertOffset(pctx context.Context, offset int64) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.paymentDbConn(ctx)
@@ -71,38 +77,38 @@ func (r *paymentRepository) UpsertOffset(pctx context.Context, offset int64) err
 
 	result, err := col.UpdateOne(ctx, bson.M{}, bson.M{"$set": bson.M{"offset": offset}}, options.Update().SetUpsert(true))
 	if err != nil {
-		log.Printf("Error: UpserOffset failed: %s", err.Error())
+		paymentLog.Error(ctx, "UpserOffset failed", "error", err)
 		return errors.New("error: UpserOffset failed")
 	}
-	log.Printf("Info: UpserOffset result: %v", result)
+	paymentLog.Debug(ctx, "UpsertOffset", "result", result)
 
 	return nil
 }
 
 func (r *paymentRepository) FindItemsInIds(pctx context.Context, grpcUrl string, req *itemPb.FindItemsInIdsReq) (*itemPb.FindItemsInIdsRes, error) {
-	ctx, cancel := context.WithTimeout(pctx, 30*time.Second)
+	ctx, cancel := deadline.Grpc(pctx)
 	defer cancel()
 
 	jwtauth.SetApiKeyInContext(&ctx)
 	conn, err := grpccon.NewGrpcClient(grpcUrl)
 	if err != nil {
-		log.Printf("Error: gRPC connection failed: %s", err.Error())
+		paymentLog.Error(ctx, "gRPC connection failed", "error", err)
 		return nil, errors.New("error: gRPC connection failed")
 	}
 
 	result, err := conn.Item().FindItemsInIds(ctx, req)
 	if err != nil {
-		log.Printf("Error: FindItemsInIds failed: %s", err.Error())
+		paymentLog.Error(ctx, "FindItemsInIds failed", "error", err)
 		return nil, errors.New("error: email or password is incorrect")
 	}
 
 	if result == nil {
-		log.Printf("Error: FindItemsInIds failed: %s", err.Error())
+		paymentLog.Error(ctx, "FindItemsInIds failed", "error", err)
 		return nil, errors.New("error: item not found")
 	}
 
 	if len(result.Items) == 0 {
-		log.Printf("Error: FindItemsInIds failed: %s", err.Error())
+		paymentLog.Error(ctx, "FindItemsInIds failed", "error", err)
 		return nil, errors.New("error: item not found")
 	}
 
@@ -110,15 +116,78 @@ func (r *paymentRepository) FindItemsInIds(pctx context.Context, grpcUrl string,
 
 }
 
+func (r *paymentRepository) IncreaseSoldCount(pctx context.Context, grpcUrl string, req *itemPb.IncreaseSoldCountReq) error {
+	ctx, cancel := deadline.Grpc(pctx)
+	defer cancel()
+
+	jwtauth.SetApiKeyInContext(&ctx)
+	conn, err := grpccon.NewGrpcClient(grpcUrl)
+	if err != nil {
+		paymentLog.Error(ctx, "gRPC connection failed", "error", err)
+		return errors.New("error: gRPC connection failed")
+	}
+
+	if _, err := conn.Item().IncreaseSoldCount(ctx, req); err != nil {
+		paymentLog.Error(ctx, "IncreaseSoldCount failed", "error", err)
+		return errors.New("error: increase sold count failed")
+	}
+
+	return nil
+}
+
+// ReserveStock passes on why the items could not be reserved when it is something the player
+// should be told, any other failure stays generic.
+func (r *paymentRepository) ReserveStock(pctx context.Context, grpcUrl string, req *itemPb.ReserveStockReq) error {
+	ctx, cancel := deadline.Grpc(pctx)
+	defer cancel()
+
+	jwtauth.SetApiKeyInContext(&ctx)
+	conn, err := grpccon.NewGrpcClient(grpcUrl)
+	if err != nil {
+		paymentLog.Error(ctx, "gRPC connection failed", "error", err)
+		return errors.New("error: gRPC connection failed")
+	}
+
+	if _, err := conn.Item().ReserveStock(ctx, req); err != nil {
+		paymentLog.Error(ctx, "ReserveStock failed", "error", err)
+		switch grpccon.ErrorReason(err) {
+		case "OUT_OF_STOCK", "PURCHASE_LIMIT_REACHED":
+			return errors.New(status.Convert(err).Message())
+		}
+		return errors.New("error: reserve stock failed")
+	}
+
+	return nil
+}
+
+func (r *paymentRepository) ReleaseStock(pctx context.Context, grpcUrl string, req *itemPb.ReleaseStockReq) error {
+	ctx, cancel := deadline.Grpc(pctx)
+	defer cancel()
+
+	jwtauth.SetApiKeyInContext(&ctx)
+	conn, err := grpccon.NewGrpcClient(grpcUrl)
+	if err != nil {
+		paymentLog.Error(ctx, "gRPC connection failed", "error", err)
+		return errors.New("error: gRPC connection failed")
+	}
+
+	if _, err := conn.Item().ReleaseStock(ctx, req); err != nil {
+		paymentLog.Error(ctx, "ReleaseStock failed", "reservation_id", req.ReservationId, "error", err)
+		return errors.New("error: release stock failed")
+	}
+
+	return nil
+}
+
 func (r *paymentRepository) DockedPlayerMoney(pctx context.Context, cfg *config.Config, req *player.CreatePlayerTransactionReq) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: DockedPlayerMoney failed: %s", err.Error())
+		paymentLog.Error(pctx, "DockedPlayerMoney failed", "error", err)
 		return errors.New("error: docked player money failed")
 	}
 
-	if err := queue.PushMessageWithKeyToQueue([]string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "player", "buy", reqInBytes); err != nil {
-		log.Printf("Error: DockedPlayerMoney failed: %s", err.Error())
+	if err := queue.PushMessageWithKeyToQueue(pctx, []string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "player", "buy", reqInBytes); err != nil {
+		paymentLog.Error(pctx, "DockedPlayerMoney failed", "error", err)
 		return errors.New("error: docked player money failed")
 	}
 
@@ -128,12 +197,12 @@ func (r *paymentRepository) DockedPlayerMoney(pctx context.Context, cfg *config.
 func (r *paymentRepository) AddPlayerMoney(pctx context.Context, cfg *config.Config, req *player.CreatePlayerTransactionReq) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: AddPlayerMoney failed: %s", err.Error())
+		paymentLog.Error(pctx, "AddPlayerMoney failed", "error", err)
 		return errors.New("error: add player money failed")
 	}
 
-	if err := queue.PushMessageWithKeyToQueue([]string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "player", "sell", reqInBytes); err != nil {
-		log.Printf("Error: AddPlayerMoney failed: %s", err.Error())
+	if err := queue.PushMessageWithKeyToQueue(pctx, []string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "player", "sell", reqInBytes); err != nil {
+		paymentLog.Error(pctx, "AddPlayerMoney failed", "error", err)
 		return errors.New("error: add player money failed")
 	}
 
@@ -143,12 +212,12 @@ func (r *paymentRepository) AddPlayerMoney(pctx context.Context, cfg *config.Con
 func (r *paymentRepository) RollbackTransaction(pctx context.Context, cfg *config.Config, req *player.RollbackPlayerTransactionReq) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: RollbackTransaction failed: %s", err.Error())
+		paymentLog.Error(pctx, "RollbackTransaction failed", "error", err)
 		return errors.New("error: rollback docked player money")
 	}
 
-	if err := queue.PushMessageWithKeyToQueue([]string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "player", "rtransaction", reqInBytes); err != nil {
-		log.Printf("Error: RollbackTransaction failed: %s", err.Error())
+	if err := queue.PushMessageWithKeyToQueue(pctx, []string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "player", "rtransaction", reqInBytes); err != nil {
+		paymentLog.Error(pctx, "RollbackTransaction failed", "error", err)
 		return errors.New("error: rollback docked player money failed")
 	}
 
@@ -158,12 +227,12 @@ func (r *paymentRepository) RollbackTransaction(pctx context.Context, cfg *confi
 func (r *paymentRepository) AddPlayerItem(pctx context.Context, cfg *config.Config, req *inventory.UpdateInventoryReq) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: AddPlayerItem failed: %s", err.Error())
+		paymentLog.Error(pctx, "AddPlayerItem failed", "error", err)
 		return errors.New("error: add player item")
 	}
 
-	if err := queue.PushMessageWithKeyToQueue([]string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "inventory", "buy", reqInBytes); err != nil {
-		log.Printf("Error: AddPlayerItem failed: %s", err.Error())
+	if err := queue.PushMessageWithKeyToQueue(pctx, []string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "inventory", "buy", reqInBytes); err != nil {
+		paymentLog.Error(pctx, "AddPlayerItem failed", "error", err)
 		return errors.New("error: add player item failed")
 	}
 
@@ -173,12 +242,12 @@ func (r *paymentRepository) AddPlayerItem(pctx context.Context, cfg *config.Conf
 func (r *paymentRepository) RemovePlayerItem(pctx context.Context, cfg *config.Config, req *inventory.UpdateInventoryReq) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: RemovePlayerItem failed: %s", err.Error())
+		paymentLog.Error(pctx, "RemovePlayerItem failed", "error", err)
 		return errors.New("error: remove player item")
 	}
 
-	if err := queue.PushMessageWithKeyToQueue([]string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "inventory", "sell", reqInBytes); err != nil {
-		log.Printf("Error: RemovePlayerItem failed: %s", err.Error())
+	if err := queue.PushMessageWithKeyToQueue(pctx, []string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "inventory", "sell", reqInBytes); err != nil {
+		paymentLog.Error(pctx, "RemovePlayerItem failed", "error", err)
 		return errors.New("error: add player item failed")
 	}
 
@@ -188,12 +257,12 @@ func (r *paymentRepository) RemovePlayerItem(pctx context.Context, cfg *config.C
 func (r *paymentRepository) RollbackAddPlayerItem(pctx context.Context, cfg *config.Config, req *inventory.RollbackPlayerInventoryReq) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: RollbackAddPlayerItem failed: %s", err.Error())
+		paymentLog.Error(pctx, "RollbackAddPlayerItem failed", "error", err)
 		return errors.New("error: rollback add player item")
 	}
 
-	if err := queue.PushMessageWithKeyToQueue([]string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "inventory", "rremove", reqInBytes); err != nil {
-		log.Printf("Error: RollbackAddPlayerItem failed: %s", err.Error())
+	if err := queue.PushMessageWithKeyToQueue(pctx, []string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "inventory", "rremove", reqInBytes); err != nil {
+		paymentLog.Error(pctx, "RollbackAddPlayerItem failed", "error", err)
 		return errors.New("error: rollback add player item failed")
 	}
 
@@ -203,12 +272,12 @@ func (r *paymentRepository) RollbackAddPlayerItem(pctx context.Context, cfg *con
 func (r *paymentRepository) RollbackRemovePlayerItem(pctx context.Context, cfg *config.Config, req *inventory.RollbackPlayerInventoryReq) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: RollbackRemovePlayerItem failed: %s", err.Error())
+		paymentLog.Error(pctx, "RollbackRemovePlayerItem failed", "error", err)
 		return errors.New("error: rollback remove player item")
 	}
 
-	if err := queue.PushMessageWithKeyToQueue([]string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "inventory", "radd", reqInBytes); err != nil {
-		log.Printf("Error: RollbackRemovePlayerItem failed: %s", err.Error())
+	if err := queue.PushMessageWithKeyToQueue(pctx, []string{cfg.Kafka.Url}, cfg.Kafka.ApiKey, cfg.Kafka.Secret, "inventory", "radd", reqInBytes); err != nil {
+		paymentLog.Error(pctx, "RollbackRemovePlayerItem failed", "error", err)
 		return errors.New("error: rollback remove player item failed")
 	}
 
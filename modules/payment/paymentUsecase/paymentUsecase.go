@@ -3,7 +3,7 @@ package paymentUsecase
 import (
 	"context"
 	"errors"
-	"log"
+	"strings"
 
 	"github.com/IBM/sarama"
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
@@ -13,7 +13,11 @@ import (
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/payment"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/payment/paymentRepository"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/deadline"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/metrics"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/queue"
+	"github.com/google/uuid"
 )
 
 type (
@@ -31,6 +35,8 @@ type (
 	}
 )
 
+var paymentLog = logger.New("payment")
+
 func NewPaymentUsecase(paymentRepository paymentRepository.PaymentRepositoryService) PaymentUsecaseService {
 	return &paymentUsecase{paymentRepository: paymentRepository}
 }
@@ -56,10 +62,10 @@ func (u *paymentUsecase) PaymentConsumer(pctx context.Context, cfg *config.Confi
 
 	consumer, err := worker.ConsumePartition("payment", 0, offset)
 	if err != nil {
-		log.Println("Trying to set offset as 0")
+		paymentLog.Warn(pctx, "Trying to set offset as 0")
 		consumer, err = worker.ConsumePartition("payment", 0, 0)
 		if err != nil {
-			log.Println("Error: PaymentConsumer failed: ", err.Error())
+			paymentLog.Error(pctx, "PaymentConsumer failed", "error", err)
 			return nil, err
 		}
 	}
@@ -67,212 +73,341 @@ func (u *paymentUsecase) PaymentConsumer(pctx context.Context, cfg *config.Confi
 	return consumer, nil
 }
 
+// BuyOrSellConsumer reads the replies until the one of key arrives, replies of other keys are
+// skipped. Every way out sends on resCh exactly once, nil when there is no reply.
 func (u *paymentUsecase) BuyOrSellConsumer(pctx context.Context, key string, cfg *config.Config, resCh chan<- *payment.PaymentTransferRes) {
+	var res *payment.PaymentTransferRes
+	defer func() { resCh <- res }()
+
 	consumer, err := u.PaymentConsumer(pctx, cfg)
 	if err != nil {
-		resCh <- nil
 		return
 	}
 	defer consumer.Close()
-	log.Println("Start BuyOrSellConsumer ...")
+	paymentLog.Info(pctx, "Start BuyOrSellConsumer", "key", key)
+
+	for {
+		select {
+		case <-pctx.Done():
+			paymentLog.Error(pctx, "BuyOrSellConsumer stopped waiting", "key", key, "error", pctx.Err())
+			return
+		case err := <-consumer.Errors():
+			paymentLog.Error(pctx, "BuyOrSellConsumer failed", "error", err)
+			return
+		case msg := <-consumer.Messages():
+			metrics.ObserveConsumed(msg, consumer.HighWaterMarkOffset())
+			if string(msg.Key) != key {
+				continue
+			}
 
-	select {
-	case err := <-consumer.Errors():
-		log.Println("Error: BuyOrSellConsumer failed: ", err.Error())
-		resCh <- nil
-		return
-	case msg := <-consumer.Messages():
-		if string(msg.Key) == key {
-			u.UpsertOffset(pctx, msg.Offset+1)
+			ctx, span := queue.StartConsumeSpan(pctx, msg)
+			u.UpsertOffset(ctx, msg.Offset+1)
 
 			req := new(payment.PaymentTransferRes)
-
 			if err := queue.DecodeMessage(req, msg.Value); err != nil {
-				resCh <- nil
+				span.End()
 				return
 			}
 
-			resCh <- req
-			log.Printf("BuyOrSellConsumer | Topic(%s)| Offset(%d) Message(%s) \n", msg.Topic, msg.Offset, string(msg.Value))
+			paymentLog.Debug(ctx, "BuyOrSellConsumer", "topic", msg.Topic, "offset", msg.Offset, "message", string(msg.Value))
+			span.End()
+			res = req
+			return
 		}
 	}
 }
 
-func (u *paymentUsecase) BuyItem(pctx context.Context, cfg *config.Config, playerId string, req *payment.ItemServiceReq) ([]*payment.PaymentTransferRes, error) {
+// waitTransferRes waits for the reply of a step that has already been sent. The wait is not
+// cut short when the request is cancelled, the reply is needed to compensate the step, but it
+// never outlasts the kafka deadline.
+func (u *paymentUsecase) waitTransferRes(pctx context.Context, key string, cfg *config.Config) *payment.PaymentTransferRes {
+	ctx, cancel := deadline.Kafka(context.WithoutCancel(pctx))
+	defer cancel()
+
+	resCh := make(chan *payment.PaymentTransferRes, 1)
+
+	go u.BuyOrSellConsumer(ctx, key, cfg, resCh)
+
+	select {
+	case res := <-resCh:
+		return res
+	case <-ctx.Done():
+		paymentLog.Error(pctx, "waitTransferRes timed out", "key", key, "error", ctx.Err())
+		return nil
+	}
+}
+
+// stageFailed reports whether a stage of the saga has to be compensated, because a step
+// failed, a step got no reply or the request was cancelled while the stage was running.
+func stageFailed(pctx context.Context, stage []*payment.PaymentTransferRes, steps int) bool {
+	if pctx.Err() != nil || len(stage) != steps {
+		return true
+	}
+	for _, s := range stage {
+		if s.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func sagaError(pctx context.Context, operation string) error {
+	if pctx.Err() != nil {
+		return errors.New("error: " + operation + " item cancelled")
+	}
+	return errors.New("error: " + operation + " item failed")
+}
+
+func (u *paymentUsecase) BuyItem(pctx context.Context, cfg *config.Config, playerId string, req *payment.ItemServiceReq) (_ []*payment.PaymentTransferRes, err error) {
+	defer func() {
+		metrics.Purchases.WithLabelValues(metrics.Result(err)).Inc()
+	}()
+
 	if err := u.FindItemsInIds(pctx, cfg.Grpc.ItemUrl, req.Items); err != nil {
 		return nil, err
 	}
 
+	// Compensation runs to the end even if the player has gone away
+	cctx := context.WithoutCancel(pctx)
+
+	// Limited stock and purchase limits are held before any money moves, a failed reserve is
+	// released too as it may have gone through without its reply arriving
+	reservation := &itemPb.ReleaseStockReq{ReservationId: uuid.NewString()}
+	itemIds := make([]string, 0)
+	for _, item := range req.Items {
+		itemIds = append(itemIds, item.Grants...)
+	}
+	if err := u.paymentRepository.ReserveStock(pctx, cfg.Grpc.ItemUrl, &itemPb.ReserveStockReq{
+		ReservationId: reservation.ReservationId,
+		PlayerId:      playerId,
+		Ids:           itemIds,
+	}); err != nil {
+		u.paymentRepository.ReleaseStock(cctx, cfg.Grpc.ItemUrl, reservation)
+		return nil, err
+	}
+
 	stage1 := make([]*payment.PaymentTransferRes, 0)
 	for _, item := range req.Items {
-		u.paymentRepository.DockedPlayerMoney(pctx, cfg, &player.CreatePlayerTransactionReq{
+		if err := u.paymentRepository.DockedPlayerMoney(pctx, cfg, &player.CreatePlayerTransactionReq{
 			PlayerId: playerId,
 			Amount:   -item.Price,
-		})
-
-		resCh := make(chan *payment.PaymentTransferRes)
-
-		go u.BuyOrSellConsumer(pctx, "buy", cfg, resCh)
+		}); err != nil {
+			break
+		}
 
-		res := <-resCh
-		if res != nil {
-			log.Println(res)
-			stage1 = append(stage1, &payment.PaymentTransferRes{
-				InventoryId:   "",
-				TransactionId: res.TransactionId,
-				PlayerId:      playerId,
-				ItemId:        item.ItemId,
-				Amount:        item.Price,
-				Error:         res.Error,
-			})
+		res := u.waitTransferRes(pctx, "buy", cfg)
+		if res == nil {
+			break
 		}
+		paymentLog.Info(pctx, "BuyItem transfer result", "res", res)
+		stage1 = append(stage1, &payment.PaymentTransferRes{
+			InventoryId:   "",
+			TransactionId: res.TransactionId,
+			PlayerId:      playerId,
+			ItemId:        item.ItemId,
+			Amount:        item.Price,
+			Error:         res.Error,
+		})
 	}
 
-	for _, s1 := range stage1 {
-		if s1.Error != "" {
-			for _, ss1 := range stage1 {
-				u.paymentRepository.RollbackTransaction(pctx, cfg, &player.RollbackPlayerTransactionReq{
-					TransactionId: ss1.TransactionId,
-				})
-			}
-			return nil, errors.New("error: buy item failed")
+	if stageFailed(pctx, stage1, len(req.Items)) {
+		metrics.Rollbacks.WithLabelValues("buy", "docked_player_money").Inc()
+		for _, ss1 := range stage1 {
+			u.paymentRepository.RollbackTransaction(cctx, cfg, &player.RollbackPlayerTransactionReq{
+				TransactionId: ss1.TransactionId,
+			})
 		}
+		u.paymentRepository.ReleaseStock(cctx, cfg.Grpc.ItemUrl, reservation)
+		return nil, sagaError(pctx, "buy")
 	}
 
+	// A bundle was paid for once above, here it is granted item by item
 	stage2 := make([]*payment.PaymentTransferRes, 0)
-	for _, s1 := range stage1 {
-		u.paymentRepository.AddPlayerItem(pctx, cfg, &inventory.UpdateInventoryReq{
-			PlayerId: playerId,
-			ItemId:   s1.ItemId,
-		})
+	grantCount := 0
+	for _, item := range req.Items {
+		grantCount += len(item.Grants)
+	}
+grants:
+	for i, s1 := range stage1 {
+		bundleId := ""
+		if strings.HasPrefix(s1.ItemId, item.BundleIdPrefix) {
+			bundleId = s1.ItemId
+		}
 
-		resCh := make(chan *payment.PaymentTransferRes)
+		for j, grantId := range req.Items[i].Grants {
+			amount := s1.Amount
+			if j > 0 {
+				amount = 0
+			}
 
-		go u.BuyOrSellConsumer(pctx, "buy", cfg, resCh)
+			if err := u.paymentRepository.AddPlayerItem(pctx, cfg, &inventory.UpdateInventoryReq{
+				PlayerId:  playerId,
+				ItemId:    grantId,
+				PaidPrice: req.Items[i].GrantPrices[j],
+			}); err != nil {
+				break grants
+			}
 
-		res := <-resCh
-		if res != nil {
-			log.Println(res)
+			res := u.waitTransferRes(pctx, "buy", cfg)
+			if res == nil {
+				break grants
+			}
+			paymentLog.Info(pctx, "BuyItem transfer result", "res", res)
 			stage2 = append(stage2, &payment.PaymentTransferRes{
 				InventoryId:   res.InventoryId,
 				TransactionId: s1.TransactionId,
 				PlayerId:      playerId,
-				ItemId:        s1.ItemId,
-				Amount:        s1.Amount,
-				Error:         s1.Error,
+				ItemId:        grantId,
+				Amount:        amount,
+				Error:         res.Error,
+				BundleId:      bundleId,
 			})
 		}
 	}
 
-	for _, s2 := range stage2 {
-		if s2.Error != "" {
-			for _, ss2 := range stage2 {
-				u.paymentRepository.RollbackAddPlayerItem(pctx, cfg, &inventory.RollbackPlayerInventoryReq{
+	if stageFailed(pctx, stage2, grantCount) {
+		metrics.Rollbacks.WithLabelValues("buy", "add_player_item").Inc()
+		for _, ss2 := range stage2 {
+			if ss2.InventoryId != "" {
+				u.paymentRepository.RollbackAddPlayerItem(cctx, cfg, &inventory.RollbackPlayerInventoryReq{
 					InventoryId: ss2.InventoryId,
 				})
 			}
+		}
 
-			for _, ss2 := range stage2 {
-				u.paymentRepository.RollbackTransaction(pctx, cfg, &player.RollbackPlayerTransactionReq{
-					TransactionId: ss2.TransactionId,
-				})
-			}
-
-			return nil, errors.New("error: buy item failed")
+		// Every docked money is refunded, also for items whose step got no reply
+		for _, ss1 := range stage1 {
+			u.paymentRepository.RollbackTransaction(cctx, cfg, &player.RollbackPlayerTransactionReq{
+				TransactionId: ss1.TransactionId,
+			})
 		}
+		u.paymentRepository.ReleaseStock(cctx, cfg.Grpc.ItemUrl, reservation)
+
+		return nil, sagaError(pctx, "buy")
+	}
+
+	// Popularity is a side effect, a failure here must not undo the purchase
+	soldIds := make([]string, 0)
+	for _, s2 := range stage2 {
+		soldIds = append(soldIds, s2.ItemId)
 	}
+	u.paymentRepository.IncreaseSoldCount(cctx, cfg.Grpc.ItemUrl, &itemPb.IncreaseSoldCountReq{
+		Ids: soldIds,
+	})
 
 	return stage2, nil
 }
 
-func (u *paymentUsecase) SellItem(pctx context.Context, cfg *config.Config, playerId string, req *payment.ItemServiceReq) ([]*payment.PaymentTransferRes, error) {
+func (u *paymentUsecase) SellItem(pctx context.Context, cfg *config.Config, playerId string, req *payment.ItemServiceReq) (_ []*payment.PaymentTransferRes, err error) {
+	defer func() {
+		metrics.Sales.WithLabelValues(metrics.Result(err)).Inc()
+	}()
+
+	// A bundle is granted as its items, those are sold one by one
+	for _, datum := range req.Items {
+		if strings.HasPrefix(datum.ItemId, item.BundleIdPrefix) {
+			return nil, errors.New("error: a bundle cannot be sold, sell its items instead")
+		}
+	}
+
 	if err := u.FindItemsInIds(pctx, cfg.Grpc.ItemUrl, req.Items); err != nil {
 		return nil, err
 	}
 
+	// Compensation runs to the end even if the player has gone away
+	cctx := context.WithoutCancel(pctx)
+
 	stage1 := make([]*payment.PaymentTransferRes, 0)
 	for _, item := range req.Items {
-		u.paymentRepository.RemovePlayerItem(pctx, cfg, &inventory.UpdateInventoryReq{
+		if err := u.paymentRepository.RemovePlayerItem(pctx, cfg, &inventory.UpdateInventoryReq{
 			PlayerId: playerId,
 			ItemId:   item.ItemId,
-		})
-
-		resCh := make(chan *payment.PaymentTransferRes)
+		}); err != nil {
+			break
+		}
 
-		go u.BuyOrSellConsumer(pctx, "sell", cfg, resCh)
+		res := u.waitTransferRes(pctx, "sell", cfg)
+		if res == nil {
+			break
+		}
+		paymentLog.Info(pctx, "SellItem transfer result", "res", res)
 
-		res := <-resCh
-		if res != nil {
-			log.Println(res)
-			stage1 = append(stage1, &payment.PaymentTransferRes{
-				InventoryId:   "",
-				TransactionId: "",
-				PlayerId:      playerId,
-				ItemId:        item.ItemId,
-				Amount:        item.Price,
-				Error:         res.Error,
-			})
+		// A sell back is paid from what the player paid, an item from before that was
+		// recorded falls back to the price of today
+		amount := item.Price
+		if res.PaidPrice != nil {
+			amount = *res.PaidPrice
 		}
+		stage1 = append(stage1, &payment.PaymentTransferRes{
+			InventoryId:   "",
+			TransactionId: "",
+			PlayerId:      playerId,
+			ItemId:        item.ItemId,
+			Amount:        amount,
+			Error:         res.Error,
+			PaidPrice:     res.PaidPrice,
+		})
 	}
 
-	for _, s1 := range stage1 {
-		if s1.Error != "" {
-			for _, ss1 := range stage1 {
-				if ss1.Error != "error: item not found" {
-					u.paymentRepository.RollbackRemovePlayerItem(pctx, cfg, &inventory.RollbackPlayerInventoryReq{
-						PlayerId: playerId,
-						ItemId:   ss1.ItemId,
-					})
-				}
+	if stageFailed(pctx, stage1, len(req.Items)) {
+		metrics.Rollbacks.WithLabelValues("sell", "remove_player_item").Inc()
+		for _, ss1 := range stage1 {
+			if ss1.Error != "error: item not found" {
+				u.paymentRepository.RollbackRemovePlayerItem(cctx, cfg, &inventory.RollbackPlayerInventoryReq{
+					PlayerId:  playerId,
+					ItemId:    ss1.ItemId,
+					PaidPrice: ss1.PaidPrice,
+				})
 			}
-			return nil, errors.New("error: sell item failed")
 		}
+		return nil, sagaError(pctx, "sell")
 	}
 
 	stage2 := make([]*payment.PaymentTransferRes, 0)
 	for _, s1 := range stage1 {
-		u.paymentRepository.AddPlayerMoney(pctx, cfg, &player.CreatePlayerTransactionReq{
+		if err := u.paymentRepository.AddPlayerMoney(pctx, cfg, &player.CreatePlayerTransactionReq{
 			PlayerId: playerId,
-			Amount:   s1.Amount * 0.5,
-		})
-
-		resCh := make(chan *payment.PaymentTransferRes)
-
-		go u.BuyOrSellConsumer(pctx, "sell", cfg, resCh)
+			Amount:   s1.Amount * item.SellBackRate,
+		}); err != nil {
+			break
+		}
 
-		res := <-resCh
-		if res != nil {
-			log.Println(res)
-			stage2 = append(stage2, &payment.PaymentTransferRes{
-				InventoryId:   "",
-				TransactionId: s1.TransactionId,
-				PlayerId:      playerId,
-				ItemId:        s1.ItemId,
-				Amount:        s1.Amount,
-				Error:         s1.Error,
-			})
+		res := u.waitTransferRes(pctx, "sell", cfg)
+		if res == nil {
+			break
 		}
+		paymentLog.Info(pctx, "SellItem transfer result", "res", res)
+		stage2 = append(stage2, &payment.PaymentTransferRes{
+			InventoryId:   "",
+			TransactionId: res.TransactionId,
+			PlayerId:      playerId,
+			ItemId:        s1.ItemId,
+			Amount:        s1.Amount,
+			Error:         res.Error,
+		})
 	}
 
-	for _, s2 := range stage2 {
-		if s2.Error != "" {
+	if stageFailed(pctx, stage2, len(stage1)) {
+		metrics.Rollbacks.WithLabelValues("sell", "add_player_money").Inc()
 
-			for _, ss2 := range stage2 {
-				u.paymentRepository.RollbackTransaction(pctx, cfg, &player.RollbackPlayerTransactionReq{
+		for _, ss2 := range stage2 {
+			if ss2.TransactionId != "" {
+				u.paymentRepository.RollbackTransaction(cctx, cfg, &player.RollbackPlayerTransactionReq{
 					TransactionId: ss2.TransactionId,
 				})
 			}
+		}
 
-			for _, ss2 := range stage2 {
-				if ss2.Error != "error: item not found" {
-					u.paymentRepository.RollbackRemovePlayerItem(pctx, cfg, &inventory.RollbackPlayerInventoryReq{
-						InventoryId: ss2.InventoryId,
-					})
-				}
-			}
-
-			return nil, errors.New("error: sell item failed")
+		// Every removed item is given back, also for items whose step got no reply
+		for _, ss1 := range stage1 {
+			u.paymentRepository.RollbackRemovePlayerItem(cctx, cfg, &inventory.RollbackPlayerInventoryReq{
+				PlayerId:  playerId,
+				ItemId:    ss1.ItemId,
+				PaidPrice: ss1.PaidPrice,
+			})
 		}
+
+		return nil, sagaError(pctx, "sell")
 	}
 
 	return stage2, nil
@@ -297,11 +432,13 @@ func (u *paymentUsecase) FindItemsInIds(pctx context.Context, grpcUrl string, re
 		}(),
 	})
 	if err != nil {
-		log.Printf("Error: FindItemsInIds failed: %s", err.Error())
+		paymentLog.Error(pctx, "FindItemsInIds failed", "error", err)
 		return errors.New("error: item not found")
 	}
 
 	itemMaps := make(map[string]*item.ItemShowCase)
+	grantMaps := make(map[string][]string)
+	grantPriceMaps := make(map[string][]float64)
 	for _, v := range itemData.Items {
 		itemMaps[v.Id] = &item.ItemShowCase{
 			ItemId:   v.Id,
@@ -310,15 +447,36 @@ func (u *paymentUsecase) FindItemsInIds(pctx context.Context, grpcUrl string, re
 			ImageUrl: v.ImageUrl,
 			Damage:   int(v.Damage),
 		}
+		grantMaps[v.Id], grantPriceMaps[v.Id] = grantsOf(v)
 	}
 
 	for i := range req {
 		if _, ok := itemMaps[req[i].ItemId]; !ok {
-			log.Printf("Error: FindItemsInIds failed: %s", err.Error())
+			paymentLog.Error(pctx, "FindItemsInIds failed", "error", err)
 			return errors.New("error: items not found")
 		}
 		req[i].Price = itemMaps[req[i].ItemId].Price
+		req[i].Grants = grantMaps[req[i].ItemId]
+		req[i].GrantPrices = grantPriceMaps[req[i].ItemId]
 	}
 
 	return nil
 }
+
+// grantsOf expands an item or a bundle into the items a purchase of it adds to the inventory,
+// once per unit, with the part of the price each of them is bought for.
+func grantsOf(v *itemPb.Item) ([]string, []float64) {
+	if len(v.BundleItems) == 0 {
+		return []string{v.Id}, []float64{v.Price}
+	}
+
+	grants := make([]string, 0)
+	unitPrices := make([]float64, 0)
+	for _, bundleItem := range v.BundleItems {
+		for n := int64(0); n < bundleItem.Quantity; n++ {
+			grants = append(grants, bundleItem.Id)
+			unitPrices = append(unitPrices, bundleItem.Price)
+		}
+	}
+	return grants, item.ProrateBundle(v.Price, unitPrices)
+}
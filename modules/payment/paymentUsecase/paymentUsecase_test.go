@@ -0,0 +1,52 @@
+package paymentUsecase
+
+import (
+	"reflect"
+	"testing"
+
+	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
+)
+
+func TestGrantsOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		item   *itemPb.Item
+		grants []string
+		prices []float64
+	}{
+		{
+			name:   "item",
+			item:   &itemPb.Item{Id: "item:1", Price: 12.5},
+			grants: []string{"item:1"},
+			prices: []float64{12.5},
+		},
+		{
+			name: "bundle expanded per unit",
+			item: &itemPb.Item{Id: "bundle:1", Price: 15, BundleItems: []*itemPb.BundleItem{
+				{Id: "item:1", Quantity: 2, Price: 5},
+				{Id: "item:2", Quantity: 1, Price: 10},
+			}},
+			grants: []string{"item:1", "item:1", "item:2"},
+			prices: []float64{3.75, 3.75, 7.5},
+		},
+		{
+			name: "bundle of free items",
+			item: &itemPb.Item{Id: "bundle:2", Price: 2, BundleItems: []*itemPb.BundleItem{
+				{Id: "item:1", Quantity: 1},
+				{Id: "item:2", Quantity: 1},
+			}},
+			grants: []string{"item:1", "item:2"},
+			prices: []float64{1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		grants, prices := grantsOf(tt.item)
+		if !reflect.DeepEqual(grants, tt.grants) {
+			t.Errorf("%s: grants = %v, want %v", tt.name, grants, tt.grants)
+		}
+		if !reflect.DeepEqual(prices, tt.prices) {
+			t.Errorf("%s: prices = %v, want %v", tt.name, prices, tt.prices)
+		}
+	}
+}
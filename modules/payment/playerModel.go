@@ -5,9 +5,14 @@ type (
 		Items []*ItemServiceReqDatum `json:"items" validate:"required"`
 	}
 
+	// ItemServiceReqDatum is an item or a bundle. Grants are the items a purchase adds to the
+	// inventory, once per unit, and GrantPrices the part of Price each of them is bought for.
+	// Both are filled in by FindItemsInIds.
 	ItemServiceReqDatum struct {
-		ItemId string  `json:"item_id" validate:"required,max=64"`
-		Price  float64 `json:"price"`
+		ItemId      string    `json:"item_id" validate:"required,max=64"`
+		Price       float64   `json:"price"`
+		Grants      []string  `json:"-"`
+		GrantPrices []float64 `json:"-"`
 	}
 
 	PaymentTransferReq struct {
@@ -23,5 +28,11 @@ type (
 		ItemId        string  `json:"item_id"`
 		Amount        float64 `json:"amount"`
 		Error         string  `json:"error"`
+		// BundleId is the bundle an item was granted for, its price is the Amount of the
+		// first of them and the others are 0
+		BundleId string `json:"bundle_id,omitempty"`
+		// PaidPrice is what a removed item was bought for, nil for the items of the
+		// inventory from before the price was recorded
+		PaidPrice *float64 `json:"paid_price,omitempty"`
 	}
 )
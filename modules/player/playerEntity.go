@@ -8,13 +8,23 @@ import (
 
 type (
 	Player struct {
-		Id          primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
-		Email       string             `json:"email" bson:"email"`
-		Password    string             `json:"password" bson:"password"`
-		Username    string             `json:"username" bson:"username"`
-		CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-		UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
-		PlayerRoles []PlayerRole       `bson:"player_roles"`
+		Id            primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+		Email         string             `json:"email" bson:"email"`
+		Password      string             `json:"password" bson:"password"`
+		Username      string             `json:"username" bson:"username"`
+		EmailVerified bool               `json:"email_verified" bson:"email_verified"`
+		CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+		UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+		PlayerRoles   []PlayerRole       `bson:"player_roles"`
+		Mfa           *PlayerMfa         `json:"-" bson:"mfa,omitempty"`
+	}
+
+	PlayerMfa struct {
+		Secret        string    `bson:"secret"`
+		Enabled       bool      `bson:"enabled"`
+		RecoveryCodes []string  `bson:"recovery_codes"`
+		LastUsedStep  int64     `bson:"last_used_step"`
+		UpdatedAt     time.Time `bson:"updated_at"`
 	}
 
 	PlayerRole struct {
@@ -23,11 +33,23 @@ type (
 	}
 
 	PlayerProfileBson struct {
-		Id        primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
-		Email     string             `json:"email" bson:"email"`
-		Username  string             `json:"username" bson:"username"`
-		CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-		UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+		Id            primitive.ObjectID `json:"_id" bson:"_id,omitempty"`
+		Email         string             `json:"email" bson:"email"`
+		Username      string             `json:"username" bson:"username"`
+		EmailVerified bool               `json:"email_verified" bson:"email_verified"`
+		CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+		UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	}
+
+	// PlayerActionToken tracks a signed email verification or password reset token so it can only be used once
+	PlayerActionToken struct {
+		Id        primitive.ObjectID `bson:"_id,omitempty"`
+		TokenId   string             `bson:"token_id"`
+		PlayerId  string             `bson:"player_id"`
+		Action    string             `bson:"action"`
+		UsedAt    *time.Time         `bson:"used_at"`
+		ExpiredAt time.Time          `bson:"expired_at"`
+		CreatedAt time.Time          `bson:"created_at"`
 	}
 
 	PlayerSavingAccount struct {
@@ -28,6 +28,14 @@ func (g *playerGrpcHandler) FindOnePlayerProfileToRefresh(ctx context.Context, r
 	return g.playerUsecase.FindOnePlayerProfileToRefresh(ctx, req.PlayerId)
 }
 
+func (g *playerGrpcHandler) ProvisionPlayer(ctx context.Context, req *playerPb.ProvisionPlayerReq) (*playerPb.PlayerProfile, error) {
+	return g.playerUsecase.ProvisionPlayer(ctx, req)
+}
+
+func (g *playerGrpcHandler) VerifyMfaCode(ctx context.Context, req *playerPb.VerifyMfaCodeReq) (*playerPb.VerifyMfaCodeRes, error) {
+	return g.playerUsecase.VerifyMfaCode(ctx, req)
+}
+
 func (g *playerGrpcHandler) GetPlayerSavingAccount(ctx context.Context, req *playerPb.GetPlayerSavingAccountReq) (*playerPb.GetPlayerSavingAccountRes, error) {
 	return nil, nil
 }
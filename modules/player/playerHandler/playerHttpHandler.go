@@ -1,7 +1,6 @@
 package playerHandler
 
 import (
-	"context"
 	"net/http"
 	"strings"
 
@@ -19,6 +18,12 @@ type (
 		FindOnePlayerProfile(c echo.Context) error
 		AddPlayerMoney(c echo.Context) error
 		GetPlayerSavingAccount(c echo.Context) error
+		EnrollPlayerMfa(c echo.Context) error
+		ActivatePlayerMfa(c echo.Context) error
+		RequestEmailVerification(c echo.Context) error
+		ConfirmEmailVerification(c echo.Context) error
+		RequestPasswordReset(c echo.Context) error
+		ConfirmPasswordReset(c echo.Context) error
 	}
 
 	playerHttpHandler struct {
@@ -28,11 +33,11 @@ type (
 )
 
 func NewPlayerHttpHandler(cfg *config.Config, playerUsecase playerUsecase.PlayerUsecaseService) PlayerHttpHandlerService {
-	return &playerHttpHandler{playerUsecase: playerUsecase}
+	return &playerHttpHandler{cfg: cfg, playerUsecase: playerUsecase}
 }
 
 func (h *playerHttpHandler) CreatePlayer(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
@@ -51,7 +56,7 @@ func (h *playerHttpHandler) CreatePlayer(c echo.Context) error {
 }
 
 func (h *playerHttpHandler) FindOnePlayerProfile(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	playerId := strings.TrimPrefix(c.Param("player_id"), "player:")
 
@@ -64,7 +69,7 @@ func (h *playerHttpHandler) FindOnePlayerProfile(c echo.Context) error {
 }
 
 func (h *playerHttpHandler) AddPlayerMoney(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	wrapper := request.ContextWrapper(c)
 
@@ -84,7 +89,7 @@ func (h *playerHttpHandler) AddPlayerMoney(c echo.Context) error {
 }
 
 func (h *playerHttpHandler) GetPlayerSavingAccount(c echo.Context) error {
-	ctx := context.Background()
+	ctx := c.Request().Context()
 
 	playerId := c.Get("player_id").(string)
 
@@ -95,3 +100,110 @@ func (h *playerHttpHandler) GetPlayerSavingAccount(c echo.Context) error {
 
 	return response.SuccessResponse(c, http.StatusOK, res)
 }
+
+func (h *playerHttpHandler) EnrollPlayerMfa(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	playerId := strings.TrimPrefix(c.Get("player_id").(string), "player:")
+
+	res, err := h.playerUsecase.EnrollPlayerMfa(ctx, playerId)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusCreated, res)
+}
+
+func (h *playerHttpHandler) ActivatePlayerMfa(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(player.PlayerMfaActivateReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	playerId := strings.TrimPrefix(c.Get("player_id").(string), "player:")
+
+	res, err := h.playerUsecase.ActivatePlayerMfa(ctx, playerId, req)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *playerHttpHandler) RequestEmailVerification(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	playerId := strings.TrimPrefix(c.Get("player_id").(string), "player:")
+
+	if err := h.playerUsecase.RequestEmailVerification(ctx, h.cfg, playerId); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, &response.MsgResponse{
+		Message: "verification email sent",
+	})
+}
+
+func (h *playerHttpHandler) ConfirmEmailVerification(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(player.EmailVerificationConfirmReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	res, err := h.playerUsecase.ConfirmEmailVerification(ctx, h.cfg, req)
+	if err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *playerHttpHandler) RequestPasswordReset(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(player.PasswordResetReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.playerUsecase.RequestPasswordReset(ctx, h.cfg, req); err != nil {
+		return response.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusAccepted, &response.MsgResponse{
+		Message: "if the email is registered, a reset link has been sent",
+	})
+}
+
+func (h *playerHttpHandler) ConfirmPasswordReset(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	wrapper := request.ContextWrapper(c)
+
+	req := new(player.PasswordResetConfirmReq)
+
+	if err := wrapper.Bind(req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.playerUsecase.ConfirmPasswordReset(ctx, h.cfg, req); err != nil {
+		return response.ErrorResponse(c, http.StatusBadRequest, err.Error())
+	}
+
+	return response.SuccessResponse(c, http.StatusOK, &response.MsgResponse{
+		Message: "password has been reset",
+	})
+}
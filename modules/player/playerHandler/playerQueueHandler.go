@@ -2,15 +2,18 @@ package playerHandler
 
 import (
 	"context"
-	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player/playerUsecase"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/metrics"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/probe"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/queue"
 )
 
@@ -27,6 +30,8 @@ type (
 	}
 )
 
+var playerLog = logger.New("player")
+
 func NewPlayerQueueHandler(cfg *config.Config, playerUsecase playerUsecase.PlayerUsecaseService) PlayerQueueHandlerService {
 	return &playerQueueHandler{
 		cfg:           cfg,
@@ -47,10 +52,10 @@ func (h *playerQueueHandler) PlayerConsumer(pctx context.Context) (sarama.Partit
 
 	consumer, err := worker.ConsumePartition("player", 0, offset)
 	if err != nil {
-		log.Println("Trying to set offset as 0")
+		playerLog.Warn(pctx, "Trying to set offset as 0")
 		consumer, err = worker.ConsumePartition("player", 0, 0)
 		if err != nil {
-			log.Println("Error: PaymentConsumer failed: ", err.Error())
+			playerLog.Error(pctx, "PaymentConsumer failed", "error", err)
 			return nil, err
 		}
 	}
@@ -61,38 +66,51 @@ func (h *playerQueueHandler) PlayerConsumer(pctx context.Context) (sarama.Partit
 func (h *playerQueueHandler) DockedPlayerMoney() {
 	ctx := context.Background()
 
+	heartbeat := probe.NewHeartbeat("DockedPlayerMoney")
+	defer heartbeat.Stop()
+
 	consumer, err := h.PlayerConsumer(ctx)
 	if err != nil {
 		return
 	}
 	defer consumer.Close()
 
-	log.Println("Start DockedPlayerMoney ...")
+	playerLog.Info(ctx, "Start DockedPlayerMoney")
 
 	sigchan := make(chan os.Signal, 1)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 
+	ticker := time.NewTicker(probe.HeartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case err := <-consumer.Errors():
-			log.Println("Error: DockedPlayerMoney failed: ", err.Error())
+			playerLog.Error(ctx, "DockedPlayerMoney failed", "error", err)
 			continue
 		case msg := <-consumer.Messages():
+			metrics.ObserveConsumed(msg, consumer.HighWaterMarkOffset())
 			if string(msg.Key) == "buy" {
-				h.playerUsecase.UpsertOffset(ctx, msg.Offset+1)
+				msgCtx, span := queue.StartConsumeSpan(ctx, msg)
+
+				h.playerUsecase.UpsertOffset(msgCtx, msg.Offset+1)
 
 				req := new(player.CreatePlayerTransactionReq)
 
 				if err := queue.DecodeMessage(req, msg.Value); err != nil {
+					span.End()
 					continue
 				}
 
-				h.playerUsecase.DockedPlayerMoneyRes(ctx, h.cfg, req)
+				h.playerUsecase.DockedPlayerMoneyRes(msgCtx, h.cfg, req)
 
-				log.Printf("DockedPlayerMoney | Topic(%s)| Offset(%d) Message(%s) \n", msg.Topic, msg.Offset, string(msg.Value))
+				playerLog.Debug(msgCtx, "DockedPlayerMoney", "topic", msg.Topic, "offset", msg.Offset, "message", string(msg.Value))
+				span.End()
 			}
+		case <-ticker.C:
+			heartbeat.Beat()
 		case <-sigchan:
-			log.Println("Stop DockedPlayerMoney...")
+			playerLog.Info(ctx, "Stop DockedPlayerMoney")
 			return
 		}
 	}
@@ -100,38 +118,51 @@ func (h *playerQueueHandler) DockedPlayerMoney() {
 func (h *playerQueueHandler) AddPlayerMoney() {
 	ctx := context.Background()
 
+	heartbeat := probe.NewHeartbeat("AddPlayerMoney")
+	defer heartbeat.Stop()
+
 	consumer, err := h.PlayerConsumer(ctx)
 	if err != nil {
 		return
 	}
 	defer consumer.Close()
 
-	log.Println("Start AddPlayerMoney ...")
+	playerLog.Info(ctx, "Start AddPlayerMoney")
 
 	sigchan := make(chan os.Signal, 1)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 
+	ticker := time.NewTicker(probe.HeartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case err := <-consumer.Errors():
-			log.Println("Error: AddPlayerMoney failed: ", err.Error())
+			playerLog.Error(ctx, "AddPlayerMoney failed", "error", err)
 			continue
 		case msg := <-consumer.Messages():
+			metrics.ObserveConsumed(msg, consumer.HighWaterMarkOffset())
 			if string(msg.Key) == "sell" {
-				h.playerUsecase.UpsertOffset(ctx, msg.Offset+1)
+				msgCtx, span := queue.StartConsumeSpan(ctx, msg)
+
+				h.playerUsecase.UpsertOffset(msgCtx, msg.Offset+1)
 
 				req := new(player.CreatePlayerTransactionReq)
 
 				if err := queue.DecodeMessage(req, msg.Value); err != nil {
+					span.End()
 					continue
 				}
 
-				h.playerUsecase.AddPlayerMoneyRes(ctx, h.cfg, req)
+				h.playerUsecase.AddPlayerMoneyRes(msgCtx, h.cfg, req)
 
-				log.Printf("AddPlayerMoney | Topic(%s)| Offset(%d) Message(%s) \n", msg.Topic, msg.Offset, string(msg.Value))
+				playerLog.Debug(msgCtx, "AddPlayerMoney", "topic", msg.Topic, "offset", msg.Offset, "message", string(msg.Value))
+				span.End()
 			}
+		case <-ticker.C:
+			heartbeat.Beat()
 		case <-sigchan:
-			log.Println("Stop AddPlayerMoney...")
+			playerLog.Info(ctx, "Stop AddPlayerMoney")
 			return
 		}
 	}
@@ -140,38 +171,51 @@ func (h *playerQueueHandler) AddPlayerMoney() {
 func (h *playerQueueHandler) RollbackPlayerTransaction() {
 	ctx := context.Background()
 
+	heartbeat := probe.NewHeartbeat("RollbackPlayerTransaction")
+	defer heartbeat.Stop()
+
 	consumer, err := h.PlayerConsumer(ctx)
 	if err != nil {
 		return
 	}
 	defer consumer.Close()
 
-	log.Println("Start RollbackPlayerTransaction ...")
+	playerLog.Info(ctx, "Start RollbackPlayerTransaction")
 
 	sigchan := make(chan os.Signal, 1)
 	signal.Notify(sigchan, syscall.SIGINT, syscall.SIGTERM)
 
+	ticker := time.NewTicker(probe.HeartbeatInterval)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case err := <-consumer.Errors():
-			log.Println("Error: RollbackPlayerTransaction failed: ", err.Error())
+			playerLog.Error(ctx, "RollbackPlayerTransaction failed", "error", err)
 			continue
 		case msg := <-consumer.Messages():
+			metrics.ObserveConsumed(msg, consumer.HighWaterMarkOffset())
 			if string(msg.Key) == "rtransaction" {
-				h.playerUsecase.UpsertOffset(ctx, msg.Offset+1)
+				msgCtx, span := queue.StartConsumeSpan(ctx, msg)
+
+				h.playerUsecase.UpsertOffset(msgCtx, msg.Offset+1)
 
 				req := new(player.RollbackPlayerTransactionReq)
 
 				if err := queue.DecodeMessage(req, msg.Value); err != nil {
+					span.End()
 					continue
 				}
 
-				h.playerUsecase.RollbackPlayerTransaction(ctx, req)
+				h.playerUsecase.RollbackPlayerTransaction(msgCtx, req)
 
-				log.Printf("RollbackPlayerTransaction | Topic(%s)| Offset(%d) Message(%s) \n", msg.Topic, msg.Offset, string(msg.Value))
+				playerLog.Debug(msgCtx, "RollbackPlayerTransaction", "topic", msg.Topic, "offset", msg.Offset, "message", string(msg.Value))
+				span.End()
 			}
+		case <-ticker.C:
+			heartbeat.Beat()
 		case <-sigchan:
-			log.Println("Stop RollbackPlayerTransaction...")
+			playerLog.Info(ctx, "Stop RollbackPlayerTransaction")
 			return
 		}
 	}
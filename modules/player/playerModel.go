@@ -1,14 +1,20 @@
 package player
 
-import "time"
+import (
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
+	"google.golang.org/grpc/codes"
+)
 
 type (
 	PlayerProfile struct {
-		Id        string    `json:"_id"`
-		Email     string    `json:"email"`
-		Username  string    `json:"username"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
+		Id            string    `json:"_id"`
+		Email         string    `json:"email"`
+		Username      string    `json:"username"`
+		EmailVerified bool      `json:"email_verified"`
+		CreatedAt     time.Time `json:"created_at"`
+		UpdatedAt     time.Time `json:"updated_at"`
 	}
 
 	PlayerClaims struct {
@@ -27,7 +33,43 @@ type (
 		Amount   float64 `json:"amount" validate:"required"`
 	}
 
+	PlayerMfaEnrollRes struct {
+		Secret          string `json:"secret"`
+		ProvisioningUri string `json:"provisioning_uri"`
+	}
+
+	PlayerMfaActivateReq struct {
+		Code string `json:"code" form:"code" validate:"required,len=6,numeric"`
+	}
+
+	PlayerMfaActivateRes struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	EmailVerificationConfirmReq struct {
+		Token string `json:"token" form:"token" validate:"required,max=1000"`
+	}
+
+	PasswordResetReq struct {
+		Email string `json:"email" form:"email" validate:"required,email,max=255"`
+	}
+
+	PasswordResetConfirmReq struct {
+		Token    string `json:"token" form:"token" validate:"required,max=1000"`
+		Password string `json:"password" form:"password" validate:"required,max=32"`
+	}
+
 	RollbackPlayerTransactionReq struct {
 		TransactionId string `json:"transaction_id"`
 	}
 )
+
+// The errors other services act on, their status does not depend on the message.
+var (
+	ErrPlayerNotFound  = grpccon.NewError(codes.NotFound, "NOT_FOUND", "error: player profile not found")
+	ErrEmailInvalid    = grpccon.NewError(codes.InvalidArgument, "INVALID_ARGUMENT", "error: email is invalid")
+	ErrPasswordInvalid = grpccon.NewError(codes.InvalidArgument, "INVALID_ARGUMENT", "error: password is invalid")
+	ErrEmailExists     = grpccon.NewError(codes.AlreadyExists, "ALREADY_EXISTS", "error: email already exist")
+	ErrUsernameExists  = grpccon.NewError(codes.AlreadyExists, "ALREADY_EXISTS", "error: username already exist")
+	ErrMfaNotEnabled   = grpccon.NewError(codes.FailedPrecondition, "FAILED_PRECONDITION", "error: mfa is not enabled")
+)
@@ -28,12 +28,13 @@ type PlayerProfile struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id        string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Email     string `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
-	Username  string `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
-	RoleCode  int32  `protobuf:"varint,4,opt,name=roleCode,proto3" json:"roleCode,omitempty"`
-	CreatedAt string `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt string `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Id         string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email      string   `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Username   string   `protobuf:"bytes,3,opt,name=username,proto3" json:"username,omitempty"`
+	CreatedAt  string   `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt  string   `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Roles      []string `protobuf:"bytes,7,rep,name=roles,proto3" json:"roles,omitempty"`
+	MfaEnabled bool     `protobuf:"varint,8,opt,name=mfaEnabled,proto3" json:"mfaEnabled,omitempty"`
 }
 
 func (x *PlayerProfile) Reset() {
@@ -89,13 +90,6 @@ func (x *PlayerProfile) GetUsername() string {
 	return ""
 }
 
-func (x *PlayerProfile) GetRoleCode() int32 {
-	if x != nil {
-		return x.RoleCode
-	}
-	return 0
-}
-
 func (x *PlayerProfile) GetCreatedAt() string {
 	if x != nil {
 		return x.CreatedAt
@@ -110,6 +104,20 @@ func (x *PlayerProfile) GetUpdatedAt() string {
 	return ""
 }
 
+func (x *PlayerProfile) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *PlayerProfile) GetMfaEnabled() bool {
+	if x != nil {
+		return x.MfaEnabled
+	}
+	return false
+}
+
 type CredentialSearchReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -212,6 +220,171 @@ func (x *FindOnePlayerProfileToRefreshReq) GetPlayerId() string {
 	return ""
 }
 
+type ProvisionPlayerReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Email         string `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Username      string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	EmailVerified bool   `protobuf:"varint,3,opt,name=emailVerified,proto3" json:"emailVerified,omitempty"`
+}
+
+func (x *ProvisionPlayerReq) Reset() {
+	*x = ProvisionPlayerReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProvisionPlayerReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProvisionPlayerReq) ProtoMessage() {}
+
+func (x *ProvisionPlayerReq) ProtoReflect() protoreflect.Message {
+	mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProvisionPlayerReq.ProtoReflect.Descriptor instead.
+func (*ProvisionPlayerReq) Descriptor() ([]byte, []int) {
+	return file_modules_player_playerPb_playerPb_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ProvisionPlayerReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *ProvisionPlayerReq) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ProvisionPlayerReq) GetEmailVerified() bool {
+	if x != nil {
+		return x.EmailVerified
+	}
+	return false
+}
+
+type VerifyMfaCodeReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PlayerId string `protobuf:"bytes,1,opt,name=playerId,proto3" json:"playerId,omitempty"`
+	Code     string `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+}
+
+func (x *VerifyMfaCodeReq) Reset() {
+	*x = VerifyMfaCodeReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyMfaCodeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyMfaCodeReq) ProtoMessage() {}
+
+func (x *VerifyMfaCodeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyMfaCodeReq.ProtoReflect.Descriptor instead.
+func (*VerifyMfaCodeReq) Descriptor() ([]byte, []int) {
+	return file_modules_player_playerPb_playerPb_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *VerifyMfaCodeReq) GetPlayerId() string {
+	if x != nil {
+		return x.PlayerId
+	}
+	return ""
+}
+
+func (x *VerifyMfaCodeReq) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+type VerifyMfaCodeRes struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	IsValid bool `protobuf:"varint,1,opt,name=isValid,proto3" json:"isValid,omitempty"`
+}
+
+func (x *VerifyMfaCodeRes) Reset() {
+	*x = VerifyMfaCodeRes{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *VerifyMfaCodeRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyMfaCodeRes) ProtoMessage() {}
+
+func (x *VerifyMfaCodeRes) ProtoReflect() protoreflect.Message {
+	mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyMfaCodeRes.ProtoReflect.Descriptor instead.
+func (*VerifyMfaCodeRes) Descriptor() ([]byte, []int) {
+	return file_modules_player_playerPb_playerPb_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *VerifyMfaCodeRes) GetIsValid() bool {
+	if x != nil {
+		return x.IsValid
+	}
+	return false
+}
+
 type GetPlayerSavingAccountReq struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -223,7 +396,7 @@ type GetPlayerSavingAccountReq struct {
 func (x *GetPlayerSavingAccountReq) Reset() {
 	*x = GetPlayerSavingAccountReq{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[3]
+		mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -236,7 +409,7 @@ func (x *GetPlayerSavingAccountReq) String() string {
 func (*GetPlayerSavingAccountReq) ProtoMessage() {}
 
 func (x *GetPlayerSavingAccountReq) ProtoReflect() protoreflect.Message {
-	mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[3]
+	mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -249,7 +422,7 @@ func (x *GetPlayerSavingAccountReq) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPlayerSavingAccountReq.ProtoReflect.Descriptor instead.
 func (*GetPlayerSavingAccountReq) Descriptor() ([]byte, []int) {
-	return file_modules_player_playerPb_playerPb_proto_rawDescGZIP(), []int{3}
+	return file_modules_player_playerPb_playerPb_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetPlayerSavingAccountReq) GetPlayerId() string {
@@ -271,7 +444,7 @@ type GetPlayerSavingAccountRes struct {
 func (x *GetPlayerSavingAccountRes) Reset() {
 	*x = GetPlayerSavingAccountRes{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[4]
+		mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -284,7 +457,7 @@ func (x *GetPlayerSavingAccountRes) String() string {
 func (*GetPlayerSavingAccountRes) ProtoMessage() {}
 
 func (x *GetPlayerSavingAccountRes) ProtoReflect() protoreflect.Message {
-	mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[4]
+	mi := &file_modules_player_playerPb_playerPb_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -297,7 +470,7 @@ func (x *GetPlayerSavingAccountRes) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetPlayerSavingAccountRes.ProtoReflect.Descriptor instead.
 func (*GetPlayerSavingAccountRes) Descriptor() ([]byte, []int) {
-	return file_modules_player_playerPb_playerPb_proto_rawDescGZIP(), []int{4}
+	return file_modules_player_playerPb_playerPb_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *GetPlayerSavingAccountRes) GetPlayerId() string {
@@ -319,18 +492,20 @@ var File_modules_player_playerPb_playerPb_proto protoreflect.FileDescriptor
 var file_modules_player_playerPb_playerPb_proto_rawDesc = []byte{
 	0x0a, 0x26, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x73, 0x2f, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
 	0x2f, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x50, 0x62, 0x2f, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72,
-	0x50, 0x62, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xab, 0x01, 0x0a, 0x0d, 0x50, 0x6c, 0x61,
+	0x50, 0x62, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xcb, 0x01, 0x0a, 0x0d, 0x50, 0x6c, 0x61,
 	0x79, 0x65, 0x72, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
 	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x6d,
 	0x61, 0x69, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c,
 	0x12, 0x1a, 0x0a, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08,
-	0x72, 0x6f, 0x6c, 0x65, 0x43, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08,
-	0x72, 0x6f, 0x6c, 0x65, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x72, 0x65, 0x61,
-	0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x72,
-	0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x75, 0x70, 0x64,
-	0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x22, 0x47, 0x0a, 0x13, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e,
+	0x28, 0x09, 0x52, 0x08, 0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a,
+	0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x75,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x72, 0x6f,
+	0x6c, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x72, 0x6f, 0x6c, 0x65, 0x73,
+	0x12, 0x1e, 0x0a, 0x0a, 0x6d, 0x66, 0x61, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x6d, 0x66, 0x61, 0x45, 0x6e, 0x61, 0x62, 0x6c, 0x65, 0x64,
+	0x4a, 0x04, 0x08, 0x04, 0x10, 0x05, 0x22, 0x47, 0x0a, 0x13, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e,
 	0x74, 0x69, 0x61, 0x6c, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x52, 0x65, 0x71, 0x12, 0x14, 0x0a,
 	0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d,
 	0x61, 0x69, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x18,
@@ -339,6 +514,20 @@ var file_modules_player_playerPb_playerPb_proto_rawDesc = []byte{
 	0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x54, 0x6f, 0x52, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68,
 	0x52, 0x65, 0x71, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18,
 	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x22,
+	0x6c, 0x0a, 0x12, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x6d, 0x61, 0x69, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x75,
+	0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x65, 0x6d, 0x61, 0x69, 0x6c,
+	0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d,
+	0x65, 0x6d, 0x61, 0x69, 0x6c, 0x56, 0x65, 0x72, 0x69, 0x66, 0x69, 0x65, 0x64, 0x22, 0x42, 0x0a,
+	0x10, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x4d, 0x66, 0x61, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x65,
+	0x71, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x63, 0x6f, 0x64,
+	0x65, 0x22, 0x2c, 0x0a, 0x10, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x4d, 0x66, 0x61, 0x43, 0x6f,
+	0x64, 0x65, 0x52, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x73, 0x56, 0x61, 0x6c, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69, 0x73, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x22,
 	0x37, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x53, 0x61, 0x76, 0x69,
 	0x6e, 0x67, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x12, 0x1a, 0x0a, 0x08,
 	0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
@@ -347,7 +536,7 @@ var file_modules_player_playerPb_playerPb_proto_rawDesc = []byte{
 	0x6e, 0x74, 0x52, 0x65, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49,
 	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x49,
 	0x64, 0x12, 0x18, 0x0a, 0x07, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x01, 0x52, 0x07, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x32, 0xf3, 0x01, 0x0a, 0x11,
+	0x28, 0x01, 0x52, 0x07, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x32, 0xe2, 0x02, 0x0a, 0x11,
 	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x47, 0x72, 0x70, 0x63, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63,
 	0x65, 0x12, 0x38, 0x0a, 0x10, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x53,
 	0x65, 0x61, 0x72, 0x63, 0x68, 0x12, 0x14, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
@@ -363,10 +552,17 @@ var file_modules_player_playerPb_playerPb_proto_rawDesc = []byte{
 	0x6c, 0x61, 0x79, 0x65, 0x72, 0x53, 0x61, 0x76, 0x69, 0x6e, 0x67, 0x41, 0x63, 0x63, 0x6f, 0x75,
 	0x6e, 0x74, 0x52, 0x65, 0x71, 0x1a, 0x1a, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x6c, 0x61, 0x79, 0x65,
 	0x72, 0x53, 0x61, 0x76, 0x69, 0x6e, 0x67, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x52, 0x65,
-	0x73, 0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
-	0x62, 0x6f, 0x6e, 0x78, 0x61, 0x74, 0x69, 0x77, 0x61, 0x74, 0x2f, 0x62, 0x6f, 0x6e, 0x78, 0x2d,
-	0x73, 0x68, 0x6f, 0x70, 0x2d, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x62, 0x06, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x12, 0x36, 0x0a, 0x0f, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e, 0x50, 0x6c,
+	0x61, 0x79, 0x65, 0x72, 0x12, 0x13, 0x2e, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x73, 0x69, 0x6f, 0x6e,
+	0x50, 0x6c, 0x61, 0x79, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x0e, 0x2e, 0x50, 0x6c, 0x61, 0x79,
+	0x65, 0x72, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x35, 0x0a, 0x0d, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x79, 0x4d, 0x66, 0x61, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x11, 0x2e, 0x56, 0x65, 0x72,
+	0x69, 0x66, 0x79, 0x4d, 0x66, 0x61, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x11, 0x2e,
+	0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x4d, 0x66, 0x61, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x73,
+	0x42, 0x2a, 0x5a, 0x28, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x62,
+	0x6f, 0x6e, 0x78, 0x61, 0x74, 0x69, 0x77, 0x61, 0x74, 0x2f, 0x62, 0x6f, 0x6e, 0x78, 0x2d, 0x73,
+	0x68, 0x6f, 0x70, 0x2d, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -381,23 +577,30 @@ func file_modules_player_playerPb_playerPb_proto_rawDescGZIP() []byte {
 	return file_modules_player_playerPb_playerPb_proto_rawDescData
 }
 
-var file_modules_player_playerPb_playerPb_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_modules_player_playerPb_playerPb_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_modules_player_playerPb_playerPb_proto_goTypes = []interface{}{
 	(*PlayerProfile)(nil),                    // 0: PlayerProfile
 	(*CredentialSearchReq)(nil),              // 1: CredentialSearchReq
 	(*FindOnePlayerProfileToRefreshReq)(nil), // 2: FindOnePlayerProfileToRefreshReq
-	(*GetPlayerSavingAccountReq)(nil),        // 3: GetPlayerSavingAccountReq
-	(*GetPlayerSavingAccountRes)(nil),        // 4: GetPlayerSavingAccountRes
+	(*ProvisionPlayerReq)(nil),               // 3: ProvisionPlayerReq
+	(*VerifyMfaCodeReq)(nil),                 // 4: VerifyMfaCodeReq
+	(*VerifyMfaCodeRes)(nil),                 // 5: VerifyMfaCodeRes
+	(*GetPlayerSavingAccountReq)(nil),        // 6: GetPlayerSavingAccountReq
+	(*GetPlayerSavingAccountRes)(nil),        // 7: GetPlayerSavingAccountRes
 }
 var file_modules_player_playerPb_playerPb_proto_depIdxs = []int32{
 	1, // 0: PlayerGrpcService.CredentialSearch:input_type -> CredentialSearchReq
 	2, // 1: PlayerGrpcService.FindOnePlayerProfileToRefresh:input_type -> FindOnePlayerProfileToRefreshReq
-	3, // 2: PlayerGrpcService.GetPlayerSavingAccount:input_type -> GetPlayerSavingAccountReq
-	0, // 3: PlayerGrpcService.CredentialSearch:output_type -> PlayerProfile
-	0, // 4: PlayerGrpcService.FindOnePlayerProfileToRefresh:output_type -> PlayerProfile
-	4, // 5: PlayerGrpcService.GetPlayerSavingAccount:output_type -> GetPlayerSavingAccountRes
-	3, // [3:6] is the sub-list for method output_type
-	0, // [0:3] is the sub-list for method input_type
+	6, // 2: PlayerGrpcService.GetPlayerSavingAccount:input_type -> GetPlayerSavingAccountReq
+	3, // 3: PlayerGrpcService.ProvisionPlayer:input_type -> ProvisionPlayerReq
+	4, // 4: PlayerGrpcService.VerifyMfaCode:input_type -> VerifyMfaCodeReq
+	0, // 5: PlayerGrpcService.CredentialSearch:output_type -> PlayerProfile
+	0, // 6: PlayerGrpcService.FindOnePlayerProfileToRefresh:output_type -> PlayerProfile
+	7, // 7: PlayerGrpcService.GetPlayerSavingAccount:output_type -> GetPlayerSavingAccountRes
+	0, // 8: PlayerGrpcService.ProvisionPlayer:output_type -> PlayerProfile
+	5, // 9: PlayerGrpcService.VerifyMfaCode:output_type -> VerifyMfaCodeRes
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -446,7 +649,7 @@ func file_modules_player_playerPb_playerPb_proto_init() {
 			}
 		}
 		file_modules_player_playerPb_playerPb_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GetPlayerSavingAccountReq); i {
+			switch v := v.(*ProvisionPlayerReq); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -458,6 +661,42 @@ func file_modules_player_playerPb_playerPb_proto_init() {
 			}
 		}
 		file_modules_player_playerPb_playerPb_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyMfaCodeReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_modules_player_playerPb_playerPb_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*VerifyMfaCodeRes); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_modules_player_playerPb_playerPb_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPlayerSavingAccountReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_modules_player_playerPb_playerPb_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetPlayerSavingAccountRes); i {
 			case 0:
 				return &v.state
@@ -476,7 +715,7 @@ func file_modules_player_playerPb_playerPb_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_modules_player_playerPb_playerPb_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   5,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
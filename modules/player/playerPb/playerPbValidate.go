@@ -0,0 +1,40 @@
+package bonx_shop_tutorial
+
+import "errors"
+
+// Validate methods are called by the grpccon validation interceptor before the handler runs.
+
+func (x *CredentialSearchReq) Validate() error {
+	if x.GetEmail() == "" || x.GetPassword() == "" {
+		return errors.New("error: email and password are required")
+	}
+	return nil
+}
+
+func (x *FindOnePlayerProfileToRefreshReq) Validate() error {
+	if x.GetPlayerId() == "" {
+		return errors.New("error: player id is required")
+	}
+	return nil
+}
+
+func (x *GetPlayerSavingAccountReq) Validate() error {
+	if x.GetPlayerId() == "" {
+		return errors.New("error: player id is required")
+	}
+	return nil
+}
+
+func (x *ProvisionPlayerReq) Validate() error {
+	if x.GetEmail() == "" {
+		return errors.New("error: email is required")
+	}
+	return nil
+}
+
+func (x *VerifyMfaCodeReq) Validate() error {
+	if x.GetPlayerId() == "" || x.GetCode() == "" {
+		return errors.New("error: player id and code are required")
+	}
+	return nil
+}
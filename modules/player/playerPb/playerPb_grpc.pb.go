@@ -25,6 +25,8 @@ type PlayerGrpcServiceClient interface {
 	CredentialSearch(ctx context.Context, in *CredentialSearchReq, opts ...grpc.CallOption) (*PlayerProfile, error)
 	FindOnePlayerProfileToRefresh(ctx context.Context, in *FindOnePlayerProfileToRefreshReq, opts ...grpc.CallOption) (*PlayerProfile, error)
 	GetPlayerSavingAccount(ctx context.Context, in *GetPlayerSavingAccountReq, opts ...grpc.CallOption) (*GetPlayerSavingAccountRes, error)
+	ProvisionPlayer(ctx context.Context, in *ProvisionPlayerReq, opts ...grpc.CallOption) (*PlayerProfile, error)
+	VerifyMfaCode(ctx context.Context, in *VerifyMfaCodeReq, opts ...grpc.CallOption) (*VerifyMfaCodeRes, error)
 }
 
 type playerGrpcServiceClient struct {
@@ -62,6 +64,24 @@ func (c *playerGrpcServiceClient) GetPlayerSavingAccount(ctx context.Context, in
 	return out, nil
 }
 
+func (c *playerGrpcServiceClient) ProvisionPlayer(ctx context.Context, in *ProvisionPlayerReq, opts ...grpc.CallOption) (*PlayerProfile, error) {
+	out := new(PlayerProfile)
+	err := c.cc.Invoke(ctx, "/PlayerGrpcService/ProvisionPlayer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *playerGrpcServiceClient) VerifyMfaCode(ctx context.Context, in *VerifyMfaCodeReq, opts ...grpc.CallOption) (*VerifyMfaCodeRes, error) {
+	out := new(VerifyMfaCodeRes)
+	err := c.cc.Invoke(ctx, "/PlayerGrpcService/VerifyMfaCode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PlayerGrpcServiceServer is the server API for PlayerGrpcService service.
 // All implementations must embed UnimplementedPlayerGrpcServiceServer
 // for forward compatibility
@@ -69,6 +89,8 @@ type PlayerGrpcServiceServer interface {
 	CredentialSearch(context.Context, *CredentialSearchReq) (*PlayerProfile, error)
 	FindOnePlayerProfileToRefresh(context.Context, *FindOnePlayerProfileToRefreshReq) (*PlayerProfile, error)
 	GetPlayerSavingAccount(context.Context, *GetPlayerSavingAccountReq) (*GetPlayerSavingAccountRes, error)
+	ProvisionPlayer(context.Context, *ProvisionPlayerReq) (*PlayerProfile, error)
+	VerifyMfaCode(context.Context, *VerifyMfaCodeReq) (*VerifyMfaCodeRes, error)
 	mustEmbedUnimplementedPlayerGrpcServiceServer()
 }
 
@@ -85,6 +107,12 @@ func (UnimplementedPlayerGrpcServiceServer) FindOnePlayerProfileToRefresh(contex
 func (UnimplementedPlayerGrpcServiceServer) GetPlayerSavingAccount(context.Context, *GetPlayerSavingAccountReq) (*GetPlayerSavingAccountRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPlayerSavingAccount not implemented")
 }
+func (UnimplementedPlayerGrpcServiceServer) ProvisionPlayer(context.Context, *ProvisionPlayerReq) (*PlayerProfile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProvisionPlayer not implemented")
+}
+func (UnimplementedPlayerGrpcServiceServer) VerifyMfaCode(context.Context, *VerifyMfaCodeReq) (*VerifyMfaCodeRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyMfaCode not implemented")
+}
 func (UnimplementedPlayerGrpcServiceServer) mustEmbedUnimplementedPlayerGrpcServiceServer() {}
 
 // UnsafePlayerGrpcServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -152,6 +180,42 @@ func _PlayerGrpcService_GetPlayerSavingAccount_Handler(srv interface{}, ctx cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PlayerGrpcService_ProvisionPlayer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProvisionPlayerReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlayerGrpcServiceServer).ProvisionPlayer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlayerGrpcService/ProvisionPlayer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlayerGrpcServiceServer).ProvisionPlayer(ctx, req.(*ProvisionPlayerReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PlayerGrpcService_VerifyMfaCode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyMfaCodeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PlayerGrpcServiceServer).VerifyMfaCode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/PlayerGrpcService/VerifyMfaCode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PlayerGrpcServiceServer).VerifyMfaCode(ctx, req.(*VerifyMfaCodeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PlayerGrpcService_ServiceDesc is the grpc.ServiceDesc for PlayerGrpcService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -171,6 +235,14 @@ var PlayerGrpcService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetPlayerSavingAccount",
 			Handler:    _PlayerGrpcService_GetPlayerSavingAccount_Handler,
 		},
+		{
+			MethodName: "ProvisionPlayer",
+			Handler:    _PlayerGrpcService_ProvisionPlayer_Handler,
+		},
+		{
+			MethodName: "VerifyMfaCode",
+			Handler:    _PlayerGrpcService_VerifyMfaCode_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "modules/player/playerPb/playerPb.proto",
@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"log"
-	"time"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	authPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/auth/authPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/models"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/payment"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/deadline"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/queue"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson"
@@ -28,6 +31,15 @@ type (
 		GetPlayerSavingAccount(pctx context.Context, playerId string) (*player.PlayerSavingAccount, error)
 		FindOnePlayerCredential(pctx context.Context, email string) (*player.Player, error)
 		FindOnePlayerProfileToRefresh(pctx context.Context, playerId string) (*player.Player, error)
+		UpdateOnePlayerMfa(pctx context.Context, playerId string, req *player.PlayerMfa) error
+		UseOnePlayerMfaStep(pctx context.Context, playerId string, step int64) (bool, error)
+		UseOnePlayerMfaRecoveryCode(pctx context.Context, playerId, hashedCode string) (bool, error)
+		UpdateOnePlayerEmailVerified(pctx context.Context, playerId string) error
+		UpdateOnePlayerPassword(pctx context.Context, playerId, hashedPassword string) error
+		RevokePlayerCredentials(pctx context.Context, grpcUrl, playerId string) error
+		InsertOnePlayerActionToken(pctx context.Context, req *player.PlayerActionToken) error
+		UseOnePlayerActionToken(pctx context.Context, tokenId, action string) (*player.PlayerActionToken, error)
+		RevokeManyPlayerActionTokens(pctx context.Context, playerId, action string) error
 		GetOffset(pctx context.Context) (int64, error)
 		UpsertOffset(pctx context.Context, offset int64) error
 		DockedPlayerMoneyRes(pctx context.Context, cfg *config.Config, req *payment.PaymentTransferRes) error
@@ -40,6 +52,8 @@ type (
 	}
 )
 
+var playerLog = logger.New("player")
+
 func NewPlayerRepository(db *mongo.Client) PlayerRepositoryService {
 	return &playerRepository{db: db}
 }
@@ -49,7 +63,7 @@ func (r *playerRepository) playerDbConn(pctx context.Context) *mongo.Database {
 }
 
 func (r *playerRepository) GetOffset(pctx context.Context) (int64, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -57,7 +71,7 @@ func (r *playerRepository) GetOffset(pctx context.Context) (int64, error) {
 
 	result := new(models.KafkaOffset)
 	if err := col.FindOne(ctx, bson.M{}).Decode(result); err != nil {
-		log.Printf("Error: GetOffset failed: %s", err.Error())
+		playerLog.Error(ctx, "GetOffset failed", "error", err)
 		return -1, errors.New("error: GetOffset failed")
 	}
 
@@ -65,7 +79,7 @@ func (r *playerRepository) GetOffset(pctx context.Context) (int64, error) {
 }
 
 func (r *playerRepository) UpsertOffset(pctx context.Context, offset int64) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -73,16 +87,16 @@ func (r *playerRepository) UpsertOffset(pctx context.Context, offset int64) erro
 
 	result, err := col.UpdateOne(ctx, bson.M{}, bson.M{"$set": bson.M{"offset": offset}}, options.Update().SetUpsert(true))
 	if err != nil {
-		log.Printf("Error: UpsertOffset failed: %s", err.Error())
+		playerLog.Error(ctx, "UpsertOffset failed", "error", err)
 		return errors.New("error: UpsertOffset failed")
 	}
-	log.Printf("Info: UpsertOffset result: %v", result)
+	playerLog.Debug(ctx, "UpsertOffset", "result", result)
 
 	return nil
 }
 
 func (r *playerRepository) IsUniquePlayer(pctx context.Context, email, username string) bool {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -96,14 +110,14 @@ func (r *playerRepository) IsUniquePlayer(pctx context.Context, email, username
 			{"email": email},
 		}},
 	).Decode(player); err != nil {
-		log.Printf("Error: IsUniquePlayer: %s", err.Error())
+		playerLog.Error(ctx, "IsUniquePlayer", "error", err)
 		return true
 	}
 	return false
 }
 
 func (r *playerRepository) InsertOnePlayer(pctx context.Context, req *player.Player) (primitive.ObjectID, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -111,7 +125,7 @@ func (r *playerRepository) InsertOnePlayer(pctx context.Context, req *player.Pla
 
 	playerId, err := col.InsertOne(ctx, req)
 	if err != nil {
-		log.Printf("Error: InsertOnePlayer: %s", err.Error())
+		playerLog.Error(ctx, "InsertOnePlayer", "error", err)
 		return primitive.NilObjectID, errors.New("error: insert one player failed")
 	}
 
@@ -119,7 +133,7 @@ func (r *playerRepository) InsertOnePlayer(pctx context.Context, req *player.Pla
 }
 
 func (r *playerRepository) DeleteOnePlayerTransaction(pctx context.Context, transactionId string) error {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -127,16 +141,16 @@ func (r *playerRepository) DeleteOnePlayerTransaction(pctx context.Context, tran
 
 	result, err := col.DeleteOne(ctx, bson.M{"_id": utils.ConvertToObjectId(transactionId)})
 	if err != nil {
-		log.Printf("Error: DeleteOnePlayerTransaction: %s", err.Error())
+		playerLog.Error(ctx, "DeleteOnePlayerTransaction", "error", err)
 		return errors.New("error: delete one player transaction failed")
 	}
-	log.Printf("Delete result: %v", result)
+	playerLog.Debug(ctx, "DeleteOnePlayer", "deleted_count", result.DeletedCount)
 
 	return nil
 }
 
 func (r *playerRepository) FindOnePlayerProfile(pctx context.Context, playerId string) (*player.PlayerProfileBson, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -149,23 +163,24 @@ func (r *playerRepository) FindOnePlayerProfile(pctx context.Context, playerId s
 		bson.M{"_id": utils.ConvertToObjectId(playerId)},
 		options.FindOne().SetProjection(
 			bson.M{
-				"_id":        1,
-				"email":      1,
-				"username":   1,
-				"created_at": 1,
-				"updated_at": 1,
+				"_id":            1,
+				"email":          1,
+				"username":       1,
+				"email_verified": 1,
+				"created_at":     1,
+				"updated_at":     1,
 			},
 		),
 	).Decode(result); err != nil {
-		log.Printf("Error: FindOnePlayerProfile: %s", err.Error())
-		return nil, errors.New("error: player profile not found")
+		playerLog.Error(ctx, "FindOnePlayerProfile", "error", err)
+		return nil, player.ErrPlayerNotFound
 	}
 
 	return result, nil
 }
 
 func (r *playerRepository) InsertOnePlayerTransaction(pctx context.Context, req *player.PlayerTransaction) (primitive.ObjectID, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -173,16 +188,16 @@ func (r *playerRepository) InsertOnePlayerTransaction(pctx context.Context, req
 
 	result, err := col.InsertOne(ctx, req)
 	if err != nil {
-		log.Printf("Error: InsertOnePlayerTransaction: %s", err.Error())
+		playerLog.Error(ctx, "InsertOnePlayerTransaction", "error", err)
 		return primitive.NilObjectID, errors.New("error: insert one player transaction failed")
 	}
-	log.Printf("Result: InsertOnePlayerTransaction: %v", result.InsertedID)
+	playerLog.Debug(ctx, "InsertOnePlayerTransaction", "inserted_id", result.InsertedID)
 
 	return result.InsertedID.(primitive.ObjectID), nil
 }
 
 func (r *playerRepository) GetPlayerSavingAccount(pctx context.Context, playerId string) (*player.PlayerSavingAccount, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -211,14 +226,14 @@ func (r *playerRepository) GetPlayerSavingAccount(pctx context.Context, playerId
 
 	cursors, err := col.Aggregate(ctx, filter)
 	if err != nil {
-		log.Printf("Error: GetPlayerSavingAccount: %s", err.Error())
+		playerLog.Error(ctx, "GetPlayerSavingAccount", "error", err)
 		return nil, errors.New("error: failed to get player saving account")
 	}
 
 	result := new(player.PlayerSavingAccount)
 	for cursors.Next(ctx) {
 		if err := cursors.Decode(result); err != nil {
-			log.Printf("Error: GetPlayerSavingAccount: %s", err.Error())
+			playerLog.Error(ctx, "GetPlayerSavingAccount", "error", err)
 			return nil, errors.New("error: failed to get player saving account")
 		}
 	}
@@ -227,7 +242,7 @@ func (r *playerRepository) GetPlayerSavingAccount(pctx context.Context, playerId
 }
 
 func (r *playerRepository) FindOnePlayerCredential(pctx context.Context, email string) (*player.Player, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -236,15 +251,15 @@ func (r *playerRepository) FindOnePlayerCredential(pctx context.Context, email s
 	result := new(player.Player)
 
 	if err := col.FindOne(ctx, bson.M{"email": email}).Decode(result); err != nil {
-		log.Printf("Error: FindOnePlayerCredential: %s", err.Error())
-		return nil, errors.New("error: email is invalid")
+		playerLog.Error(ctx, "FindOnePlayerCredential", "error", err)
+		return nil, player.ErrEmailInvalid
 	}
 
 	return result, nil
 }
 
 func (r *playerRepository) FindOnePlayerProfileToRefresh(pctx context.Context, playerId string) (*player.Player, error) {
-	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	ctx, cancel := deadline.Db(pctx)
 	defer cancel()
 
 	db := r.playerDbConn(ctx)
@@ -253,21 +268,106 @@ func (r *playerRepository) FindOnePlayerProfileToRefresh(pctx context.Context, p
 	result := new(player.Player)
 
 	if err := col.FindOne(ctx, bson.M{"_id": utils.ConvertToObjectId(playerId)}).Decode(result); err != nil {
-		log.Printf("Error: FindOnePlayerProfileToRefresh: %s", err.Error())
-		return nil, errors.New("error: player profile not found")
+		playerLog.Error(ctx, "FindOnePlayerProfileToRefresh", "error", err)
+		return nil, player.ErrPlayerNotFound
 	}
 
 	return result, nil
 }
 
+func (r *playerRepository) UpdateOnePlayerMfa(pctx context.Context, playerId string, req *player.PlayerMfa) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.playerDbConn(ctx)
+	col := db.Collection("players")
+
+	result, err := col.UpdateOne(
+		ctx,
+		bson.M{"_id": utils.ConvertToObjectId(playerId)},
+		bson.M{"$set": bson.M{"mfa": req, "updated_at": utils.LocalTime()}},
+	)
+	if err != nil {
+		playerLog.Error(ctx, "UpdateOnePlayerMfa", "error", err)
+		return errors.New("error: update player mfa failed")
+	}
+
+	if result.MatchedCount == 0 {
+		return player.ErrPlayerNotFound
+	}
+
+	return nil
+}
+
+// UseOnePlayerMfaStep moves the last used totp step forward, false when the step is not
+// newer than the last one. The check and the write are one update, so two logins racing
+// with the same code cannot both win.
+func (r *playerRepository) UseOnePlayerMfaStep(pctx context.Context, playerId string, step int64) (bool, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.playerDbConn(ctx)
+	col := db.Collection("players")
+
+	result, err := col.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":                utils.ConvertToObjectId(playerId),
+			"mfa.enabled":        true,
+			"mfa.last_used_step": bson.M{"$lt": step},
+		},
+		bson.M{"$set": bson.M{
+			"mfa.last_used_step": step,
+			"mfa.updated_at":     utils.LocalTime(),
+			"updated_at":         utils.LocalTime(),
+		}},
+	)
+	if err != nil {
+		playerLog.Error(ctx, "UseOnePlayerMfaStep", "error", err)
+		return false, errors.New("error: update player mfa failed")
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
+// UseOnePlayerMfaRecoveryCode removes a recovery code, false when the code is not there
+// anymore. Like a totp step a code is only taken by one update.
+func (r *playerRepository) UseOnePlayerMfaRecoveryCode(pctx context.Context, playerId, hashedCode string) (bool, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.playerDbConn(ctx)
+	col := db.Collection("players")
+
+	result, err := col.UpdateOne(
+		ctx,
+		bson.M{
+			"_id":                utils.ConvertToObjectId(playerId),
+			"mfa.enabled":        true,
+			"mfa.recovery_codes": hashedCode,
+		},
+		bson.M{
+			"$pull": bson.M{"mfa.recovery_codes": hashedCode},
+			"$set":  bson.M{"mfa.updated_at": utils.LocalTime(), "updated_at": utils.LocalTime()},
+		},
+	)
+	if err != nil {
+		playerLog.Error(ctx, "UseOnePlayerMfaRecoveryCode", "error", err)
+		return false, errors.New("error: update player mfa failed")
+	}
+
+	return result.MatchedCount > 0, nil
+}
+
 func (r *playerRepository) DockedPlayerMoneyRes(pctx context.Context, cfg *config.Config, req *payment.PaymentTransferRes) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: DockedPlayerMoneyRes failed: %s", err.Error())
+		playerLog.Error(pctx, "DockedPlayerMoneyRes failed", "error", err)
 		return errors.New("error: docked player money res failed")
 	}
 
 	if err := queue.PushMessageWithKeyToQueue(
+		pctx,
 		[]string{cfg.Kafka.Url},
 		cfg.Kafka.ApiKey,
 		cfg.Kafka.Secret,
@@ -275,7 +375,7 @@ func (r *playerRepository) DockedPlayerMoneyRes(pctx context.Context, cfg *confi
 		"buy",
 		reqInBytes,
 	); err != nil {
-		log.Printf("Error: DockedPlayerMoneyRes failed: %s", err.Error())
+		playerLog.Error(pctx, "DockedPlayerMoneyRes failed", "error", err)
 		return errors.New("error: docked player money res failed")
 	}
 
@@ -285,11 +385,12 @@ func (r *playerRepository) DockedPlayerMoneyRes(pctx context.Context, cfg *confi
 func (r *playerRepository) AddPlayerMoneyRes(pctx context.Context, cfg *config.Config, req *payment.PaymentTransferRes) error {
 	reqInBytes, err := json.Marshal(req)
 	if err != nil {
-		log.Printf("Error: AddPlayerMoneyRes failed: %s", err.Error())
+		playerLog.Error(pctx, "AddPlayerMoneyRes failed", "error", err)
 		return errors.New("error: add player money res failed")
 	}
 
 	if err := queue.PushMessageWithKeyToQueue(
+		pctx,
 		[]string{cfg.Kafka.Url},
 		cfg.Kafka.ApiKey,
 		cfg.Kafka.Secret,
@@ -297,9 +398,140 @@ func (r *playerRepository) AddPlayerMoneyRes(pctx context.Context, cfg *config.C
 		"sell",
 		reqInBytes,
 	); err != nil {
-		log.Printf("Error: AddPlayerMoneyRes failed: %s", err.Error())
+		playerLog.Error(pctx, "AddPlayerMoneyRes failed", "error", err)
 		return errors.New("error: add player money res failed")
 	}
 
 	return nil
 }
+
+func (r *playerRepository) UpdateOnePlayerEmailVerified(pctx context.Context, playerId string) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.playerDbConn(ctx)
+	col := db.Collection("players")
+
+	result, err := col.UpdateOne(
+		ctx,
+		bson.M{"_id": utils.ConvertToObjectId(playerId)},
+		bson.M{"$set": bson.M{"email_verified": true, "updated_at": utils.LocalTime()}},
+	)
+	if err != nil {
+		playerLog.Error(ctx, "UpdateOnePlayerEmailVerified", "error", err)
+		return errors.New("error: update player email verified failed")
+	}
+
+	if result.MatchedCount == 0 {
+		return player.ErrPlayerNotFound
+	}
+
+	return nil
+}
+
+// RevokePlayerCredentials asks auth to delete every credential of the player.
+func (r *playerRepository) RevokePlayerCredentials(pctx context.Context, grpcUrl, playerId string) error {
+	ctx, cancel := deadline.Grpc(pctx)
+	defer cancel()
+
+	jwtauth.SetApiKeyInContext(&ctx)
+	conn, err := grpccon.NewGrpcClient(grpcUrl)
+	if err != nil {
+		playerLog.Error(ctx, "gRPC connection failed", "error", err)
+		return errors.New("error: gRPC connection failed")
+	}
+
+	if _, err := conn.Auth().RevokePlayerCredentials(ctx, &authPb.RevokePlayerCredentialsReq{
+		PlayerId: playerId,
+	}); err != nil {
+		playerLog.Error(ctx, "RevokePlayerCredentials failed", "error", err)
+		return errors.New("error: revoke player credentials failed")
+	}
+
+	return nil
+}
+
+func (r *playerRepository) UpdateOnePlayerPassword(pctx context.Context, playerId, hashedPassword string) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.playerDbConn(ctx)
+	col := db.Collection("players")
+
+	result, err := col.UpdateOne(
+		ctx,
+		bson.M{"_id": utils.ConvertToObjectId(playerId)},
+		bson.M{"$set": bson.M{"password": hashedPassword, "updated_at": utils.LocalTime()}},
+	)
+	if err != nil {
+		playerLog.Error(ctx, "UpdateOnePlayerPassword", "error", err)
+		return errors.New("error: update player password failed")
+	}
+
+	if result.MatchedCount == 0 {
+		return player.ErrPlayerNotFound
+	}
+
+	return nil
+}
+
+func (r *playerRepository) InsertOnePlayerActionToken(pctx context.Context, req *player.PlayerActionToken) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.playerDbConn(ctx)
+	col := db.Collection("player_action_tokens")
+
+	if _, err := col.InsertOne(ctx, req); err != nil {
+		playerLog.Error(ctx, "InsertOnePlayerActionToken", "error", err)
+		return errors.New("error: insert one player action token failed")
+	}
+
+	return nil
+}
+
+// UseOnePlayerActionToken marks an unused, unexpired token as used in a single update,
+// so two concurrent requests with the same token cannot both succeed.
+func (r *playerRepository) UseOnePlayerActionToken(pctx context.Context, tokenId, action string) (*player.PlayerActionToken, error) {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.playerDbConn(ctx)
+	col := db.Collection("player_action_tokens")
+
+	result := new(player.PlayerActionToken)
+	if err := col.FindOneAndUpdate(
+		ctx,
+		bson.M{
+			"token_id":   tokenId,
+			"action":     action,
+			"used_at":    nil,
+			"expired_at": bson.M{"$gt": utils.LocalTime()},
+		},
+		bson.M{"$set": bson.M{"used_at": utils.LocalTime()}},
+	).Decode(result); err != nil {
+		playerLog.Error(ctx, "UseOnePlayerActionToken", "error", err)
+		return nil, errors.New("error: token is invalid or already used")
+	}
+
+	return result, nil
+}
+
+func (r *playerRepository) RevokeManyPlayerActionTokens(pctx context.Context, playerId, action string) error {
+	ctx, cancel := deadline.Db(pctx)
+	defer cancel()
+
+	db := r.playerDbConn(ctx)
+	col := db.Collection("player_action_tokens")
+
+	if _, err := col.UpdateMany(
+		ctx,
+		bson.M{"player_id": playerId, "action": action, "used_at": nil},
+		bson.M{"$set": bson.M{"used_at": utils.LocalTime()}},
+	); err != nil {
+		playerLog.Error(ctx, "RevokeManyPlayerActionTokens", "error", err)
+		return errors.New("error: revoke player action tokens failed")
+	}
+
+	return nil
+}
@@ -2,9 +2,15 @@ package playerUsecase
 
 import (
 	"context"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
-	"log"
+	"fmt"
 	"math"
+	"math/rand"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
@@ -12,10 +18,27 @@ import (
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player"
 	playerPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/player/playerPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player/playerRepository"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/mailer"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/rbac"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/totp"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	mfaIssuer            = "BonxShop"
+	mfaRecoveryCodeCount = 10
+
+	actionEmailVerification = "email-verification"
+	actionPasswordReset     = "password-reset"
+
+	// seconds
+	emailVerificationDuration = 86400
+	passwordResetDuration     = 1800
+)
+
 type (
 	PlayerUsecaseService interface {
 		CreatePlayer(pctx context.Context, req *player.CreatePlayerReq) (*player.PlayerProfile, error)
@@ -24,6 +47,14 @@ type (
 		GetPlayerSavingAccount(pctx context.Context, playerId string) (*player.PlayerSavingAccount, error)
 		FindOnePlayerCredential(pctx context.Context, password, email string) (*playerPb.PlayerProfile, error)
 		FindOnePlayerProfileToRefresh(pctx context.Context, playerId string) (*playerPb.PlayerProfile, error)
+		ProvisionPlayer(pctx context.Context, req *playerPb.ProvisionPlayerReq) (*playerPb.PlayerProfile, error)
+		EnrollPlayerMfa(pctx context.Context, playerId string) (*player.PlayerMfaEnrollRes, error)
+		ActivatePlayerMfa(pctx context.Context, playerId string, req *player.PlayerMfaActivateReq) (*player.PlayerMfaActivateRes, error)
+		VerifyMfaCode(pctx context.Context, req *playerPb.VerifyMfaCodeReq) (*playerPb.VerifyMfaCodeRes, error)
+		RequestEmailVerification(pctx context.Context, cfg *config.Config, playerId string) error
+		ConfirmEmailVerification(pctx context.Context, cfg *config.Config, req *player.EmailVerificationConfirmReq) (*player.PlayerProfile, error)
+		RequestPasswordReset(pctx context.Context, cfg *config.Config, req *player.PasswordResetReq) error
+		ConfirmPasswordReset(pctx context.Context, cfg *config.Config, req *player.PasswordResetConfirmReq) error
 		GetOffset(pctx context.Context) (int64, error)
 		UpsertOffset(pctx context.Context, offset int64) error
 		RollbackPlayerTransaction(pctx context.Context, req *player.RollbackPlayerTransactionReq)
@@ -33,11 +64,14 @@ type (
 
 	playerUsecase struct {
 		playerRepository playerRepository.PlayerRepositoryService
+		mailer           mailer.MailerService
 	}
 )
 
-func NewPlayerUsecase(playerRepository playerRepository.PlayerRepositoryService) PlayerUsecaseService {
-	return &playerUsecase{playerRepository: playerRepository}
+var playerLog = logger.New("player")
+
+func NewPlayerUsecase(playerRepository playerRepository.PlayerRepositoryService, mailer mailer.MailerService) PlayerUsecaseService {
+	return &playerUsecase{playerRepository: playerRepository, mailer: mailer}
 }
 
 func (u *playerUsecase) GetOffset(pctx context.Context) (int64, error) {
@@ -67,7 +101,7 @@ func (u *playerUsecase) CreatePlayer(pctx context.Context, req *player.CreatePla
 		UpdatedAt: utils.LocalTime(),
 		PlayerRoles: []player.PlayerRole{
 			{
-				RoleTitle: "Player",
+				RoleTitle: rbac.RolePlayer,
 				RoleCode:  0,
 			},
 		},
@@ -85,11 +119,12 @@ func (u *playerUsecase) FindOnePlayerProfile(pctx context.Context, playerId stri
 	loc, _ := time.LoadLocation("Asia/Bangkok")
 
 	return &player.PlayerProfile{
-		Id:        result.Id.Hex(),
-		Email:     result.Email,
-		Username:  result.Username,
-		CreatedAt: result.CreatedAt.In(loc),
-		UpdatedAt: result.UpdatedAt.In(loc),
+		Id:            result.Id.Hex(),
+		Email:         result.Email,
+		Username:      result.Username,
+		EmailVerified: result.EmailVerified,
+		CreatedAt:     result.CreatedAt.In(loc),
+		UpdatedAt:     result.UpdatedAt.In(loc),
 	}, nil
 }
 
@@ -118,50 +153,376 @@ func (u *playerUsecase) FindOnePlayerCredential(pctx context.Context, password,
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(result.Password), []byte(password)); err != nil {
-		log.Printf("Error: FindOnePlayerCredential: %s", err.Error())
-		return nil, errors.New("error: password is invalid")
+		playerLog.Error(pctx, "FindOnePlayerCredential", "error", err)
+		return nil, player.ErrPasswordInvalid
+	}
+
+	return playerProfileToPb(result), nil
+}
+
+func (u *playerUsecase) FindOnePlayerProfileToRefresh(pctx context.Context, playerId string) (*playerPb.PlayerProfile, error) {
+	result, err := u.playerRepository.FindOnePlayerProfileToRefresh(pctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return playerProfileToPb(result), nil
+}
+
+func (u *playerUsecase) ProvisionPlayer(pctx context.Context, req *playerPb.ProvisionPlayerReq) (*playerPb.PlayerProfile, error) {
+	result, err := u.playerRepository.FindOnePlayerCredential(pctx, req.Email)
+	if err == nil {
+		// Only link to an existing account when the identity provider vouches for the email
+		if !req.EmailVerified {
+			playerLog.Error(pctx, "ProvisionPlayer: email is not verified by provider", "email", req.Email)
+			return nil, player.ErrEmailExists
+		}
+		// The local account must have proven the email too, or whoever registered it with a
+		// password of their own would share the account with the provider login
+		if !result.EmailVerified {
+			playerLog.Error(pctx, "ProvisionPlayer: email of the local account is not verified", "email", req.Email)
+			return nil, player.ErrEmailExists.With("error: email already exist, verify it before signing in with a provider")
+		}
+		return playerProfileToPb(result), nil
+	}
+
+	baseUsername := req.Username
+	if baseUsername == "" {
+		baseUsername = strings.Split(req.Email, "@")[0]
+	}
+	username := baseUsername
+	for i := 0; !u.playerRepository.IsUniquePlayer(pctx, req.Email, username); i++ {
+		if i >= 5 {
+			return nil, player.ErrUsernameExists
+		}
+		username = fmt.Sprintf("%s%04d", baseUsername, rand.Intn(10000))
 	}
 
-	roleCode := 0
+	// Social accounts sign in through their provider, so the password is random and never shared
+	randomPassword := make([]byte, 32)
+	if _, err := crand.Read(randomPassword); err != nil {
+		return nil, errors.New("error: failed to generate password")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(randomPassword)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("error: failed to hash password")
+	}
+
+	playerId, err := u.playerRepository.InsertOnePlayer(pctx, &player.Player{
+		Email:         req.Email,
+		Password:      string(hashedPassword),
+		Username:      username,
+		EmailVerified: req.EmailVerified,
+		CreatedAt:     utils.LocalTime(),
+		UpdatedAt:     utils.LocalTime(),
+		PlayerRoles: []player.PlayerRole{
+			{
+				RoleTitle: rbac.RolePlayer,
+				RoleCode:  0,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return u.FindOnePlayerProfileToRefresh(pctx, playerId.Hex())
+}
+
+func playerProfileToPb(result *player.Player) *playerPb.PlayerProfile {
+	roles := make([]string, 0)
 	for _, v := range result.PlayerRoles {
-		roleCode += v.RoleCode
+		roles = append(roles, v.RoleTitle)
 	}
 
 	loc, _ := time.LoadLocation("Asia/Bangkok")
 
 	return &playerPb.PlayerProfile{
-		Id:        result.Id.Hex(),
-		Email:     result.Email,
-		Username:  result.Username,
-		RoleCode:  int32(roleCode),
-		CreatedAt: result.CreatedAt.In(loc).String(),
-		UpdatedAt: result.UpdatedAt.In(loc).String(),
+		Id:         result.Id.Hex(),
+		Email:      result.Email,
+		Username:   result.Username,
+		Roles:      roles,
+		MfaEnabled: result.Mfa != nil && result.Mfa.Enabled,
+		CreatedAt:  result.CreatedAt.In(loc).String(),
+		UpdatedAt:  result.UpdatedAt.In(loc).String(),
+	}
+}
+
+func (u *playerUsecase) EnrollPlayerMfa(pctx context.Context, playerId string) (*player.PlayerMfaEnrollRes, error) {
+	result, err := u.playerRepository.FindOnePlayerProfileToRefresh(pctx, playerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Mfa != nil && result.Mfa.Enabled {
+		return nil, errors.New("error: mfa already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret stays pending until the player proves they can generate a valid code
+	if err := u.playerRepository.UpdateOnePlayerMfa(pctx, playerId, &player.PlayerMfa{
+		Secret:        secret,
+		Enabled:       false,
+		RecoveryCodes: make([]string, 0),
+		UpdatedAt:     utils.LocalTime(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &player.PlayerMfaEnrollRes{
+		Secret:          secret,
+		ProvisioningUri: totp.ProvisioningUri(mfaIssuer, result.Email, secret),
 	}, nil
 }
 
-func (u *playerUsecase) FindOnePlayerProfileToRefresh(pctx context.Context, playerId string) (*playerPb.PlayerProfile, error) {
+func (u *playerUsecase) ActivatePlayerMfa(pctx context.Context, playerId string, req *player.PlayerMfaActivateReq) (*player.PlayerMfaActivateRes, error) {
 	result, err := u.playerRepository.FindOnePlayerProfileToRefresh(pctx, playerId)
 	if err != nil {
 		return nil, err
 	}
 
-	roleCode := 0
-	for _, v := range result.PlayerRoles {
-		roleCode += v.RoleCode
+	if result.Mfa == nil || result.Mfa.Secret == "" {
+		return nil, errors.New("error: mfa is not enrolled")
+	}
+	if result.Mfa.Enabled {
+		return nil, errors.New("error: mfa already enabled")
 	}
 
-	loc, _ := time.LoadLocation("Asia/Bangkok")
+	step, ok := totp.Validate(result.Mfa.Secret, req.Code, time.Now())
+	if !ok {
+		return nil, errors.New("error: mfa code is invalid")
+	}
 
-	return &playerPb.PlayerProfile{
-		Id:        result.Id.Hex(),
-		Email:     result.Email,
-		Username:  result.Username,
-		RoleCode:  int32(roleCode),
-		CreatedAt: result.CreatedAt.In(loc).String(),
-		UpdatedAt: result.UpdatedAt.In(loc).String(),
+	recoveryCodes := make([]string, 0, mfaRecoveryCodeCount)
+	hashedCodes := make([]string, 0, mfaRecoveryCodeCount)
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := crand.Read(b); err != nil {
+			return nil, errors.New("error: failed to generate recovery codes")
+		}
+		code := hex.EncodeToString(b)
+		recoveryCodes = append(recoveryCodes, code[:5]+"-"+code[5:])
+		hashedCodes = append(hashedCodes, hashRecoveryCode(code))
+	}
+
+	if err := u.playerRepository.UpdateOnePlayerMfa(pctx, playerId, &player.PlayerMfa{
+		Secret:        result.Mfa.Secret,
+		Enabled:       true,
+		RecoveryCodes: hashedCodes,
+		LastUsedStep:  step,
+		UpdatedAt:     utils.LocalTime(),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &player.PlayerMfaActivateRes{
+		RecoveryCodes: recoveryCodes,
 	}, nil
 }
 
+// VerifyMfaCode accepts either a totp code or one of the recovery codes. Each totp step and
+// each recovery code can only be used once.
+func (u *playerUsecase) VerifyMfaCode(pctx context.Context, req *playerPb.VerifyMfaCodeReq) (*playerPb.VerifyMfaCodeRes, error) {
+	result, err := u.playerRepository.FindOnePlayerProfileToRefresh(pctx, req.PlayerId)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Mfa == nil || !result.Mfa.Enabled {
+		return &playerPb.VerifyMfaCodeRes{IsValid: false}, player.ErrMfaNotEnabled
+	}
+
+	// The repository checks and spends the step or the code in one update, a code read as
+	// unused here may already be taken by a login running at the same time
+	if step, ok := totp.Validate(result.Mfa.Secret, req.Code, time.Now()); ok {
+		used, err := u.playerRepository.UseOnePlayerMfaStep(pctx, req.PlayerId, step)
+		if err != nil {
+			return &playerPb.VerifyMfaCodeRes{IsValid: false}, err
+		}
+		if used {
+			return &playerPb.VerifyMfaCodeRes{IsValid: true}, nil
+		}
+	}
+
+	hashed := hashRecoveryCode(strings.ReplaceAll(strings.TrimSpace(req.Code), "-", ""))
+	used, err := u.playerRepository.UseOnePlayerMfaRecoveryCode(pctx, req.PlayerId, hashed)
+	if err != nil {
+		return &playerPb.VerifyMfaCodeRes{IsValid: false}, err
+	}
+	if !used {
+		return &playerPb.VerifyMfaCodeRes{IsValid: false}, nil
+	}
+
+	return &playerPb.VerifyMfaCodeRes{IsValid: true}, nil
+}
+
+// issueActionToken stores the token id so the signed link can be used once, and revokes
+// any older link for the same action.
+func (u *playerUsecase) issueActionToken(pctx context.Context, cfg *config.Config, playerId, action string, duration int64) (string, error) {
+	if err := u.playerRepository.RevokeManyPlayerActionTokens(pctx, playerId, action); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 16)
+	if _, err := crand.Read(b); err != nil {
+		return "", errors.New("error: failed to generate token")
+	}
+	tokenId := hex.EncodeToString(b)
+
+	if err := u.playerRepository.InsertOnePlayerActionToken(pctx, &player.PlayerActionToken{
+		TokenId:   tokenId,
+		PlayerId:  playerId,
+		Action:    action,
+		ExpiredAt: utils.LocalTime().Add(time.Duration(duration) * time.Second),
+		CreatedAt: utils.LocalTime(),
+	}); err != nil {
+		return "", err
+	}
+
+	return jwtauth.NewActionToken(cfg.Jwt.ActionSecretKey, duration, action, tokenId, &jwtauth.Claims{
+		PlayerId: playerId,
+	}).SignToken(), nil
+}
+
+// useActionToken verifies the signature and marks the token as used, returning the player id.
+func (u *playerUsecase) useActionToken(pctx context.Context, cfg *config.Config, token, action string) (string, error) {
+	claims, err := jwtauth.ParseToken(cfg.Jwt.ActionSecretKey, token)
+	if err != nil {
+		return "", err
+	}
+
+	if claims.Subject != action {
+		playerLog.Error(pctx, "useActionToken: unexpected token subject", "error", claims.Subject)
+		return "", errors.New("error: token is invalid")
+	}
+
+	result, err := u.playerRepository.UseOnePlayerActionToken(pctx, claims.ID, action)
+	if err != nil {
+		return "", err
+	}
+
+	if result.PlayerId != claims.PlayerId {
+		playerLog.Error(pctx, "useActionToken: token does not belong to the player", "token_id", claims.ID, "player_id", claims.PlayerId)
+		return "", errors.New("error: token is invalid")
+	}
+
+	return result.PlayerId, nil
+}
+
+func (u *playerUsecase) RequestEmailVerification(pctx context.Context, cfg *config.Config, playerId string) error {
+	result, err := u.playerRepository.FindOnePlayerProfile(pctx, playerId)
+	if err != nil {
+		return err
+	}
+
+	if result.EmailVerified {
+		return errors.New("error: email already verified")
+	}
+
+	token, err := u.issueActionToken(pctx, cfg, playerId, actionEmailVerification, emailVerificationDuration)
+	if err != nil {
+		return err
+	}
+
+	return u.mailer.Send(pctx, &mailer.Message{
+		To:      result.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease verify your email by opening the link below. The link expires in 24 hours.\n\n%s/verify-email?token=%s\n",
+			result.Username,
+			cfg.Mail.ActionBasedUrl,
+			url.QueryEscape(token),
+		),
+	})
+}
+
+func (u *playerUsecase) ConfirmEmailVerification(pctx context.Context, cfg *config.Config, req *player.EmailVerificationConfirmReq) (*player.PlayerProfile, error) {
+	playerId, err := u.useActionToken(pctx, cfg, req.Token, actionEmailVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.playerRepository.UpdateOnePlayerEmailVerified(pctx, playerId); err != nil {
+		return nil, err
+	}
+
+	return u.FindOnePlayerProfile(pctx, playerId)
+}
+
+// RequestPasswordReset does not tell the caller whether the email exists.
+func (u *playerUsecase) RequestPasswordReset(pctx context.Context, cfg *config.Config, req *player.PasswordResetReq) error {
+	result, err := u.playerRepository.FindOnePlayerCredential(pctx, req.Email)
+	if err != nil {
+		return nil
+	}
+
+	playerId := result.Id.Hex()
+
+	// A failure is only logged, an error for a known email alone would tell that it exists
+	token, err := u.issueActionToken(pctx, cfg, playerId, actionPasswordReset, passwordResetDuration)
+	if err != nil {
+		playerLog.Error(pctx, "RequestPasswordReset failed", "error", err)
+		return nil
+	}
+
+	if err := u.mailer.Send(pctx, &mailer.Message{
+		To:      result.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nSomebody asked to reset the password of your account. Open the link below within 30 minutes to choose a new one.\n\n%s/reset-password?token=%s\n\nIf it was not you, you can ignore this email.\n",
+			result.Username,
+			cfg.Mail.ActionBasedUrl,
+			url.QueryEscape(token),
+		),
+	}); err != nil {
+		playerLog.Error(pctx, "RequestPasswordReset failed", "error", err)
+	}
+
+	return nil
+}
+
+func (u *playerUsecase) ConfirmPasswordReset(pctx context.Context, cfg *config.Config, req *player.PasswordResetConfirmReq) error {
+	if len(req.Password) < 6 || len(req.Password) > 32 {
+		return errors.New("error: password must be between 6 and 32 characters")
+	}
+
+	playerId, err := u.useActionToken(pctx, cfg, req.Token, actionPasswordReset)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("error: failed to hash password")
+	}
+
+	if err := u.playerRepository.UpdateOnePlayerPassword(pctx, playerId, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	// Sessions signed in with the old password, a stolen one included, end with it
+	if err := u.playerRepository.RevokePlayerCredentials(pctx, cfg.Grpc.AuthUrl, playerId); err != nil {
+		playerLog.Error(pctx, "ConfirmPasswordReset failed", "player_id", playerId, "error", err)
+		return errors.New("error: password is changed but signing out the other sessions failed")
+	}
+
+	// Receiving the reset link proves ownership of the email as well
+	if err := u.playerRepository.UpdateOnePlayerEmailVerified(pctx, playerId); err != nil {
+		playerLog.Error(pctx, "ConfirmPasswordReset", "error", err)
+	}
+
+	return nil
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(code)))
+	return hex.EncodeToString(sum[:])
+}
+
 func (u *playerUsecase) DockedPlayerMoneyRes(pctx context.Context, cfg *config.Config, req *player.CreatePlayerTransactionReq) {
 	// Get saving account
 	savingAccount, err := u.playerRepository.GetPlayerSavingAccount(pctx, req.PlayerId)
@@ -178,7 +539,7 @@ func (u *playerUsecase) DockedPlayerMoneyRes(pctx context.Context, cfg *config.C
 	}
 
 	if savingAccount.Balance < math.Abs(req.Amount) {
-		log.Printf("Error: DockedPlayerMoneyRes failed: %s", "not enough money")
+		playerLog.Error(pctx, "DockedPlayerMoneyRes failed", "error", "not enough money")
 		u.playerRepository.DockedPlayerMoneyRes(pctx, cfg, &payment.PaymentTransferRes{
 			InventoryId:   "",
 			TransactionId: "",
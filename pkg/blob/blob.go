@@ -0,0 +1,103 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+)
+
+type (
+	BlobService interface {
+		Put(pctx context.Context, key, contentType string, data []byte) error
+		Delete(pctx context.Context, key string) error
+		// Url is the address a client downloads the object from, public or signed
+		Url(key string) (string, error)
+	}
+
+	// localBlob keeps the objects in a directory served by the service itself, the urls
+	// are always public.
+	localBlob struct {
+		cfg *config.Blob
+	}
+)
+
+var blobLog = logger.New("blob")
+
+// NewBlob picks the implementation from BLOB_DRIVER, "s3" or "local" (default).
+func NewBlob(cfg *config.Blob) BlobService {
+	switch cfg.Driver {
+	case "s3":
+		return newS3Blob(cfg)
+	default:
+		return &localBlob{cfg: cfg}
+	}
+}
+
+// validKey keeps a key inside the bucket or the directory, keys are made by the services
+// but are read back from the database.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *localBlob) path(key string) string {
+	return filepath.Join(b.cfg.LocalDir, filepath.FromSlash(key))
+}
+
+func (b *localBlob) Put(pctx context.Context, key, contentType string, data []byte) error {
+	if !validKey(key) {
+		return errors.New("error: blob key is invalid")
+	}
+
+	path := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		blobLog.Error(pctx, "Create blob dir failed", "error", err)
+		return errors.New("error: put blob failed")
+	}
+
+	// Written aside and renamed, a reader never sees half an image
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		blobLog.Error(pctx, "Write blob failed", "key", key, "error", err)
+		return errors.New("error: put blob failed")
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		blobLog.Error(pctx, "Write blob failed", "key", key, "error", err)
+		return errors.New("error: put blob failed")
+	}
+
+	return nil
+}
+
+func (b *localBlob) Delete(pctx context.Context, key string) error {
+	if !validKey(key) {
+		return errors.New("error: blob key is invalid")
+	}
+
+	if err := os.Remove(b.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		blobLog.Error(pctx, "Delete blob failed", "key", key, "error", err)
+		return errors.New("error: delete blob failed")
+	}
+
+	return nil
+}
+
+func (b *localBlob) Url(key string) (string, error) {
+	if !validKey(key) {
+		return "", errors.New("error: blob key is invalid")
+	}
+	return strings.TrimSuffix(b.cfg.PublicUrl, "/") + "/" + key, nil
+}
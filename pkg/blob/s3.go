@@ -0,0 +1,237 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+)
+
+const (
+	amzDateFormat    = "20060102T150405Z"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	maxPresignExpire = 7 * 24 * 3600
+)
+
+// s3Blob talks to any S3 compatible storage (AWS, MinIO, R2) with path style urls,
+// requests are signed with AWS signature version 4.
+type s3Blob struct {
+	cfg    *config.Blob
+	client *http.Client
+}
+
+func newS3Blob(cfg *config.Blob) *s3Blob {
+	return &s3Blob{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// uriEncode escapes everything but the unreserved characters of RFC 3986, as signature
+// version 4 expects.
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (b *s3Blob) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + b.cfg.S3Region + "/s3/aws4_request"
+}
+
+// signature signs a request, headers are the lower case names and values of the signed
+// headers and always hold the host.
+func (b *s3Blob) signature(method, path string, query url.Values, headers map[string]string, payloadHash string, now time.Time) (signature, signedHeaders string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders = strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		uriEncode(path, false),
+		canonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(amzDateFormat),
+		b.scope(now),
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+b.cfg.S3SecretKey), now.Format("20060102"))
+	key = hmacSha256(key, b.cfg.S3Region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSha256(key, stringToSign)), signedHeaders
+}
+
+func (b *s3Blob) objectUrl(key string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSuffix(b.cfg.S3Endpoint, "/"))
+	if err != nil || u.Host == "" {
+		return nil, errors.New("error: s3 endpoint is invalid")
+	}
+	u.Path = "/" + b.cfg.S3Bucket + "/" + key
+	return u, nil
+}
+
+func (b *s3Blob) do(pctx context.Context, method, key, contentType string, data []byte) error {
+	u, err := b.objectUrl(key)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	payloadHash := sha256Hex(data)
+	headers := map[string]string{
+		"host":                 u.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(amzDateFormat),
+	}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+	signature, signedHeaders := b.signature(method, u.Path, nil, headers, payloadHash, now)
+
+	req, err := http.NewRequestWithContext(pctx, method, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	for name, value := range headers {
+		if name != "host" {
+			req.Header.Set(name, value)
+		}
+	}
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+b.cfg.S3AccessKey+"/"+b.scope(now)+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 && !(method == http.MethodDelete && res.StatusCode == http.StatusNotFound) {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return errors.New(res.Status + ": " + string(body))
+	}
+	return nil
+}
+
+func (b *s3Blob) Put(pctx context.Context, key, contentType string, data []byte) error {
+	if !validKey(key) {
+		return errors.New("error: blob key is invalid")
+	}
+
+	if err := b.do(pctx, http.MethodPut, key, contentType, data); err != nil {
+		blobLog.Error(pctx, "Put blob failed", "key", key, "error", err)
+		return errors.New("error: put blob failed")
+	}
+	return nil
+}
+
+func (b *s3Blob) Delete(pctx context.Context, key string) error {
+	if !validKey(key) {
+		return errors.New("error: blob key is invalid")
+	}
+
+	if err := b.do(pctx, http.MethodDelete, key, "", nil); err != nil {
+		blobLog.Error(pctx, "Delete blob failed", "key", key, "error", err)
+		return errors.New("error: delete blob failed")
+	}
+	return nil
+}
+
+// Url is a presigned GET url, or a plain one below PublicUrl when the bucket is public
+// or behind a CDN.
+func (b *s3Blob) Url(key string) (string, error) {
+	if !validKey(key) {
+		return "", errors.New("error: blob key is invalid")
+	}
+	if b.cfg.PublicUrl != "" {
+		return strings.TrimSuffix(b.cfg.PublicUrl, "/") + "/" + key, nil
+	}
+
+	u, err := b.objectUrl(key)
+	if err != nil {
+		return "", err
+	}
+	return b.presign(u, time.Now().UTC()), nil
+}
+
+func (b *s3Blob) presign(u *url.URL, now time.Time) string {
+	expire := b.cfg.UrlExpire
+	if expire <= 0 || expire > maxPresignExpire {
+		expire = maxPresignExpire
+	}
+
+	query := url.Values{
+		"X-Amz-Algorithm":     {"AWS4-HMAC-SHA256"},
+		"X-Amz-Credential":    {b.cfg.S3AccessKey + "/" + b.scope(now)},
+		"X-Amz-Date":          {now.Format(amzDateFormat)},
+		"X-Amz-Expires":       {strconv.FormatInt(expire, 10)},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	signature, _ := b.signature(http.MethodGet, u.Path, query, map[string]string{"host": u.Host}, unsignedPayload, now)
+
+	return u.Scheme + "://" + u.Host + uriEncode(u.Path, false) + "?" + canonicalQuery(query) + "&X-Amz-Signature=" + signature
+}
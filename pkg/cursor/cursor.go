@@ -0,0 +1,210 @@
+package cursor
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	Next = "next"
+	Prev = "prev"
+)
+
+type (
+	// Cursor points at the item a page continues from. It is sent to the client as an
+	// opaque token, the signature keeps a client from editing the key or the value.
+	Cursor struct {
+		Key       string `json:"k"`
+		Order     int    `json:"o"`
+		Value     any    `json:"v,omitempty"`
+		Id        string `json:"i"`
+		Direction string `json:"d"`
+		// Filters is a digest of the search the cursor belongs to
+		Filters string `json:"f"`
+	}
+
+	// Page is one page of a listing ordered by key and then by _id, so the order is total
+	// even when many documents share the same key.
+	Page struct {
+		secret  string
+		key     string
+		order   int
+		filters string
+		start   *Cursor
+	}
+)
+
+var cursorLog = logger.New("cursor")
+
+func sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func digest(filters string) string {
+	sum := sha256.Sum256([]byte(filters))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+func Encode(pctx context.Context, secret string, c *Cursor) string {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		cursorLog.Error(pctx, "Encode cursor failed", "error", err)
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + sign(secret, payload)
+}
+
+func Decode(pctx context.Context, secret, token string) (*Cursor, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, errors.New("error: start is invalid")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("error: start is invalid")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, payload))) {
+		cursorLog.Warn(pctx, "Cursor signature mismatch")
+		return nil, errors.New("error: start is invalid")
+	}
+
+	c := new(Cursor)
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, errors.New("error: start is invalid")
+	}
+	if c.Direction != Next && c.Direction != Prev {
+		return nil, errors.New("error: start is invalid")
+	}
+	return c, nil
+}
+
+// Open starts a page of a listing sorted by key in order (1 or -1). Filters is the query of
+// the search in a canonical form, a token made for another search or sort is refused.
+func Open(pctx context.Context, secret, token, key string, order int, filters string) (*Page, error) {
+	p := &Page{
+		secret:  secret,
+		key:     key,
+		order:   order,
+		filters: digest(filters),
+	}
+	if token == "" {
+		return p, nil
+	}
+
+	c, err := Decode(pctx, secret, token)
+	if err != nil {
+		return nil, err
+	}
+	if c.Key != p.key || c.Order != p.order || c.Filters != p.filters {
+		return nil, errors.New("error: start does not belong to this search")
+	}
+	p.start = c
+
+	return p, nil
+}
+
+// direction is the order the documents are read in, a previous page is read backwards.
+func (p *Page) direction() int {
+	if p.start != nil && p.start.Direction == Prev {
+		return -p.order
+	}
+	return p.order
+}
+
+// Filter is the condition for the documents after the cursor, ok is false on the first page.
+func (p *Page) Filter() (bson.E, bool) {
+	if p.start == nil {
+		return bson.E{}, false
+	}
+
+	op := "$gt"
+	if p.direction() < 0 {
+		op = "$lt"
+	}
+
+	id, err := primitive.ObjectIDFromHex(p.start.Id)
+	if err != nil {
+		id = primitive.NilObjectID
+	}
+
+	if p.key == "_id" {
+		return bson.E{Key: "_id", Value: bson.D{{Key: op, Value: id}}}, true
+	}
+	return bson.E{Key: "$or", Value: bson.A{
+		bson.D{{Key: p.key, Value: bson.D{{Key: op, Value: p.start.Value}}}},
+		bson.D{{Key: p.key, Value: p.start.Value}, {Key: "_id", Value: bson.D{{Key: op, Value: id}}}},
+	}}, true
+}
+
+func (p *Page) Sort() bson.D {
+	if p.key == "_id" {
+		return bson.D{{Key: "_id", Value: p.direction()}}
+	}
+	return bson.D{{Key: p.key, Value: p.direction()}, {Key: "_id", Value: p.direction()}}
+}
+
+// Limit reads one document more than the page holds, to know if there is a page after it.
+func (p *Page) Limit(limit int) int64 {
+	return int64(limit) + 1
+}
+
+// Token is the cursor of a link from this page, value is the sort key of the document.
+func (p *Page) Token(pctx context.Context, direction string, value any, id string) string {
+	c := &Cursor{
+		Key:       p.key,
+		Order:     p.order,
+		Id:        id,
+		Direction: direction,
+		Filters:   p.filters,
+	}
+	if p.key != "_id" {
+		c.Value = value
+	}
+	return Encode(pctx, p.secret, c)
+}
+
+// Slice cuts the documents read for p down to the page and puts them back in the order of
+// the listing. It reports whether there are pages before and after it.
+func Slice[T any](p *Page, results []T, limit int) (page []T, hasPrev, hasNext bool) {
+	more := len(results) > limit
+	if more {
+		results = results[:limit]
+	}
+
+	if p.start == nil || p.start.Direction == Next {
+		return results, p.start != nil, more
+	}
+
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	return results, more, true
+}
+
+// Href is the link to a page of a listing, start is empty for the first page.
+func Href(baseUrl string, query url.Values, limit int, start string) string {
+	link := url.Values{}
+	for key, values := range query {
+		link[key] = values
+	}
+	link.Set("limit", strconv.Itoa(limit))
+	if start != "" {
+		link.Set("start", start)
+	}
+	return baseUrl + "?" + link.Encode()
+}
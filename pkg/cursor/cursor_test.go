@@ -0,0 +1,183 @@
+package cursor
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testSecret = "cursor-test-secret"
+
+var testId = primitive.NewObjectID()
+
+func TestDecodeRejectsTampering(t *testing.T) {
+	ctx := context.Background()
+	token := Encode(ctx, testSecret, &Cursor{Key: "price", Order: 1, Value: 10.0, Id: testId.Hex(), Direction: Next})
+	payload, signature, _ := strings.Cut(token, ".")
+
+	raw, _ := base64.RawURLEncoding.DecodeString(payload)
+	edited := base64.RawURLEncoding.EncodeToString([]byte(strings.Replace(string(raw), "10", "99", 1)))
+
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+		valid  bool
+	}{
+		{"untouched", testSecret, token, true},
+		{"edited payload", testSecret, edited + "." + signature, false},
+		{"edited signature", testSecret, payload + "." + strings.Repeat("A", len(signature)), false},
+		{"missing signature", testSecret, payload, false},
+		{"not base64", testSecret, "!!!." + signature, false},
+		{"other secret", "another-secret", token, false},
+	}
+
+	for _, tt := range tests {
+		c, err := Decode(ctx, tt.secret, tt.token)
+		if tt.valid {
+			if err != nil {
+				t.Errorf("%s: Decode error: %v", tt.name, err)
+			} else if c.Value != 10.0 || c.Id != testId.Hex() {
+				t.Errorf("%s: Decode = %+v, want the encoded cursor", tt.name, c)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: Decode should fail", tt.name)
+		}
+	}
+}
+
+func TestDecodeRejectsUnknownDirection(t *testing.T) {
+	ctx := context.Background()
+	token := Encode(ctx, testSecret, &Cursor{Key: "_id", Order: 1, Id: testId.Hex(), Direction: "sideways"})
+	if _, err := Decode(ctx, testSecret, token); err == nil {
+		t.Error("Decode with an unknown direction should fail")
+	}
+}
+
+func TestOpenRejectsOtherSearch(t *testing.T) {
+	ctx := context.Background()
+	p, err := Open(ctx, testSecret, "", "price", 1, "title=sword")
+	if err != nil {
+		t.Fatalf("Open error: %v", err)
+	}
+	token := p.Token(ctx, Next, 10.0, testId.Hex())
+
+	tests := []struct {
+		name    string
+		key     string
+		order   int
+		filters string
+		valid   bool
+	}{
+		{"same search", "price", 1, "title=sword", true},
+		{"other filters", "price", 1, "title=shield", false},
+		{"other key", "title", 1, "title=sword", false},
+		{"other order", "price", -1, "title=sword", false},
+	}
+
+	for _, tt := range tests {
+		_, err := Open(ctx, testSecret, token, tt.key, tt.order, tt.filters)
+		if tt.valid && err != nil {
+			t.Errorf("%s: Open error: %v", tt.name, err)
+		}
+		if !tt.valid && (err == nil || err.Error() != "error: start does not belong to this search") {
+			t.Errorf("%s: Open error = %v, want start does not belong to this search", tt.name, err)
+		}
+	}
+}
+
+func TestFilterAndSortTieBreakOnId(t *testing.T) {
+	ctx := context.Background()
+	first, _ := Open(ctx, testSecret, "", "price", 1, "")
+
+	tests := []struct {
+		name      string
+		key       string
+		order     int
+		direction string
+		op        string
+		sort      int
+	}{
+		{"next ascending", "price", 1, Next, "$gt", 1},
+		{"prev ascending", "price", 1, Prev, "$lt", -1},
+		{"next descending", "price", -1, Next, "$lt", -1},
+		{"prev descending", "price", -1, Prev, "$gt", 1},
+		{"next by id", "_id", 1, Next, "$gt", 1},
+		{"prev by id", "_id", 1, Prev, "$lt", -1},
+	}
+
+	if _, ok := first.Filter(); ok {
+		t.Error("Filter on the first page should not be set")
+	}
+
+	for _, tt := range tests {
+		start, _ := Open(ctx, testSecret, "", tt.key, tt.order, "")
+		p, err := Open(ctx, testSecret, start.Token(ctx, tt.direction, 10.0, testId.Hex()), tt.key, tt.order, "")
+		if err != nil {
+			t.Fatalf("%s: Open error: %v", tt.name, err)
+		}
+
+		filter, ok := p.Filter()
+		if !ok {
+			t.Fatalf("%s: Filter should be set after a cursor", tt.name)
+		}
+
+		var want bson.E
+		var wantSort bson.D
+		if tt.key == "_id" {
+			want = bson.E{Key: "_id", Value: bson.D{{Key: tt.op, Value: testId}}}
+			wantSort = bson.D{{Key: "_id", Value: tt.sort}}
+		} else {
+			want = bson.E{Key: "$or", Value: bson.A{
+				bson.D{{Key: tt.key, Value: bson.D{{Key: tt.op, Value: 10.0}}}},
+				bson.D{{Key: tt.key, Value: 10.0}, {Key: "_id", Value: bson.D{{Key: tt.op, Value: testId}}}},
+			}}
+			wantSort = bson.D{{Key: tt.key, Value: tt.sort}, {Key: "_id", Value: tt.sort}}
+		}
+
+		if !reflect.DeepEqual(filter, want) {
+			t.Errorf("%s: Filter = %v, want %v", tt.name, filter, want)
+		}
+		if got := p.Sort(); !reflect.DeepEqual(got, wantSort) {
+			t.Errorf("%s: Sort = %v, want %v", tt.name, got, wantSort)
+		}
+	}
+}
+
+func TestSliceRestoresListingOrder(t *testing.T) {
+	ctx := context.Background()
+	start, _ := Open(ctx, testSecret, "", "_id", 1, "")
+
+	tests := []struct {
+		name      string
+		direction string
+		results   []int
+		page      []int
+		hasPrev   bool
+		hasNext   bool
+	}{
+		{"first page with more", "", []int{1, 2, 3}, []int{1, 2}, false, true},
+		{"first page only", "", []int{1, 2}, []int{1, 2}, false, false},
+		{"next page last", Next, []int{3, 4}, []int{3, 4}, true, false},
+		{"prev page with more", Prev, []int{4, 3, 2}, []int{3, 4}, true, true},
+		{"prev page first", Prev, []int{2, 1}, []int{1, 2}, false, true},
+	}
+
+	for _, tt := range tests {
+		p := start
+		if tt.direction != "" {
+			p, _ = Open(ctx, testSecret, start.Token(ctx, tt.direction, nil, testId.Hex()), "_id", 1, "")
+		}
+		page, hasPrev, hasNext := Slice(p, tt.results, 2)
+		if !reflect.DeepEqual(page, tt.page) || hasPrev != tt.hasPrev || hasNext != tt.hasNext {
+			t.Errorf("%s: Slice = %v %v %v, want %v %v %v", tt.name, page, hasPrev, hasNext, tt.page, tt.hasPrev, tt.hasNext)
+		}
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/metrics"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/tracing"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
@@ -15,13 +17,13 @@ func DbConn(pctx context.Context, cfg *config.Config) *mongo.Client {
 	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
 	defer cancel()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.Db.Url))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(cfg.Db.Url).SetMonitor(tracing.MongoMonitor(metrics.MongoMonitor())))
 	if err != nil {
-		log.Fatal("Error: Connect to database error: %s", err.Error())
+		log.Fatalf("Error: Connect to database error: %s", err.Error())
 	}
 
 	if err := client.Ping(ctx, readpref.Primary()); err != nil {
-		log.Fatal("Error: Pinging to database error: %s", err.Error())
+		log.Fatalf("Error: Pinging to database error: %s", err.Error())
 	}
 
 	return client
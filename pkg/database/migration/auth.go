@@ -9,6 +9,7 @@ import (
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/database"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func authDbConn(pctx context.Context, cfg *config.Config) *mongo.Database {
@@ -33,6 +34,39 @@ func AuthMigrate(pctx context.Context, cfg *config.Config) {
 		log.Printf("Index: %s", index)
 	}
 
+	// identities
+	col = db.Collection("identities")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "player_id", Value: 1}}},
+	})
+	for _, index := range indexs {
+		log.Printf("Index: %s", index)
+	}
+
+	// oidc sessions
+	col = db.Collection("oidc_sessions")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "state", Value: 1}}},
+		{Keys: bson.D{{Key: "expired_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	for _, index := range indexs {
+		log.Printf("Index: %s", index)
+	}
+
+	// login attempts
+	col = db.Collection("login_attempts")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "expired_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	for _, index := range indexs {
+		log.Printf("Index: %s", index)
+	}
+
 	// roles
 	col = db.Collection("roles")
 
@@ -9,7 +9,9 @@ import (
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/database"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func itemDbConn(pctx context.Context, cfg *config.Config) *mongo.Database {
@@ -26,12 +28,38 @@ func ItemMigrate(pctx context.Context, cfg *config.Config) {
 	indexs, _ := col.Indexes().CreateMany(pctx, []mongo.IndexModel{
 		{Keys: bson.D{{"_id", 1}}},
 		{Keys: bson.D{{"title", 1}}},
+		{Keys: bson.D{{"category", 1}, {"rarity", 1}, {"slot", 1}}},
+		{Keys: bson.D{{"title", "text"}}},
+		// Sort keys of the listing, a $text search only allows the cursor $or when every
+		// clause is indexed
+		{Keys: bson.D{{"price", 1}, {"_id", 1}}},
+		{Keys: bson.D{{"damage", 1}, {"_id", 1}}},
+		{Keys: bson.D{{"sold_count", -1}, {"_id", -1}}},
+		// Imports upsert by sku, most items have none
+		{Keys: bson.D{{"sku", 1}}, Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"sku": bson.M{"$type": "string"}})},
 	})
 
 	for _, index := range indexs {
 		log.Printf("Index: %s", index)
 	}
 
+	// Items created before categories existed are all swords
+	backfill, err := col.UpdateMany(pctx, bson.M{"category": bson.M{"$exists": false}}, bson.M{"$set": bson.M{
+		"category": item.CategoryWeapon,
+		"rarity":   item.RarityCommon,
+		"slot":     "main_hand",
+	}})
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("Backfill item category: %d", backfill.ModifiedCount)
+
+	backfill, err = col.UpdateMany(pctx, bson.M{"sold_count": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"sold_count": 0}})
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("Backfill item sold count: %d", backfill.ModifiedCount)
+
 	documents := func() []any {
 		roles := []*item.Item{
 			{
@@ -40,6 +68,10 @@ func ItemMigrate(pctx context.Context, cfg *config.Config) {
 				ImageUrl:    "https://i.imgur.com/1Y8tQZM.png",
 				UsageStatus: true,
 				Damage:      100,
+				Category:    item.CategoryWeapon,
+				Rarity:      item.RarityLegendary,
+				Slot:        "main_hand",
+				Attributes:  item.ItemAttributes{Durability: 1500, Effects: []string{"sharpness"}},
 				CreatedAt:   utils.LocalTime(),
 				UpdatedAt:   utils.LocalTime(),
 			},
@@ -49,6 +81,10 @@ func ItemMigrate(pctx context.Context, cfg *config.Config) {
 				ImageUrl:    "https://i.imgur.com/1Y8tQZM.png",
 				UsageStatus: true,
 				Damage:      50,
+				Category:    item.CategoryWeapon,
+				Rarity:      item.RarityRare,
+				Slot:        "main_hand",
+				Attributes:  item.ItemAttributes{Durability: 800},
 				CreatedAt:   utils.LocalTime(),
 				UpdatedAt:   utils.LocalTime(),
 			},
@@ -58,6 +94,10 @@ func ItemMigrate(pctx context.Context, cfg *config.Config) {
 				ImageUrl:    "https://i.imgur.com/1Y8tQZM.png",
 				UsageStatus: true,
 				Damage:      20,
+				Category:    item.CategoryWeapon,
+				Rarity:      item.RarityCommon,
+				Slot:        "main_hand",
+				Attributes:  item.ItemAttributes{Durability: 200},
 				CreatedAt:   utils.LocalTime(),
 				UpdatedAt:   utils.LocalTime(),
 			},
@@ -76,4 +116,76 @@ func ItemMigrate(pctx context.Context, cfg *config.Config) {
 	}
 	log.Println("Migrate item completed: ", results)
 
+	// item_price_history
+	col = db.Collection("item_price_history")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{"item_id", 1}, {"_id", -1}}},
+	})
+	for _, index := range indexs {
+		log.Printf("Index: %s", index)
+	}
+
+	histories := make([]any, 0)
+	for i, itemId := range results.InsertedIDs {
+		price := documents[i].(*item.Item).Price
+		histories = append(histories, &item.ItemPriceHistory{
+			ItemId:         itemId.(primitive.ObjectID),
+			Price:          price,
+			EffectivePrice: price,
+			Reason:         item.PriceReasonCreate,
+			CreatedAt:      utils.LocalTime(),
+		})
+	}
+
+	historyResults, err := col.InsertMany(pctx, histories, nil)
+	if err != nil {
+		panic(err)
+	}
+	log.Println("Migrate item price history completed: ", historyResults)
+
+	// item_change_logs
+	col = db.Collection("item_change_logs")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{"item_id", 1}, {"_id", -1}}},
+	})
+	for _, index := range indexs {
+		log.Printf("Index: %s", index)
+	}
+
+	// item_price_schedules
+	col = db.Collection("item_price_schedules")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{"item_id", 1}, {"start_at", -1}}},
+		// Due schedules and running sales
+		{Keys: bson.D{{"kind", 1}, {"status", 1}, {"start_at", 1}}},
+		{Keys: bson.D{{"kind", 1}, {"status", 1}, {"end_at", 1}}},
+	})
+	for _, index := range indexs {
+		log.Printf("Index: %s", index)
+	}
+
+	// item_bundles
+	col = db.Collection("item_bundles")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{"title", 1}}},
+		{Keys: bson.D{{"usage_status", 1}}},
+	})
+	for _, index := range indexs {
+		log.Printf("Index: %s", index)
+	}
+
+	// item_player_purchases, one count per player and item
+	col = db.Collection("item_player_purchases")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{"item_id", 1}, {"player_id", 1}}, Options: options.Index().SetUnique(true)},
+	})
+	for _, index := range indexs {
+		log.Printf("Index: %s", index)
+	}
+
 }
@@ -11,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -42,6 +43,17 @@ func PlayerMigrate(pctx context.Context, cfg *config.Config) {
 
 	log.Printf("Indexs: %s", indexs)
 
+	// indexs
+	col = db.Collection("player_action_tokens")
+
+	indexs, _ = col.Indexes().CreateMany(pctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "player_id", Value: 1}, {Key: "action", Value: 1}}},
+		{Keys: bson.D{{Key: "expired_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+
+	log.Printf("Indexs: %s", indexs)
+
 	// roles data
 	documents := func() []any {
 		roles := []*player.Player{
@@ -0,0 +1,60 @@
+package deadline
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+)
+
+// timeoutInstant holds the timeouts of the service, set once on start up. The defaults are
+// used until then, for example by scripts that never start a server.
+type timeoutInstant struct {
+	mu  sync.RWMutex
+	cfg config.Timeout
+}
+
+var instant = &timeoutInstant{
+	cfg: config.Timeout{
+		Request: 30,
+		Db:      10,
+		Grpc:    10,
+		Kafka:   10,
+	},
+}
+
+func Set(cfg *config.Timeout) {
+	instant.mu.Lock()
+	defer instant.mu.Unlock()
+
+	instant.cfg = *cfg
+}
+
+func seconds(pick func(cfg *config.Timeout) int64) time.Duration {
+	instant.mu.RLock()
+	defer instant.mu.RUnlock()
+
+	return time.Duration(pick(&instant.cfg)) * time.Second
+}
+
+// Request is the timeout of a whole http request.
+func Request() time.Duration {
+	return seconds(func(cfg *config.Timeout) int64 { return cfg.Request })
+}
+
+// Db bounds a single mongo operation. Like the other helpers it never extends pctx,
+// a request that is about to time out keeps its own shorter deadline.
+func Db(pctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(pctx, seconds(func(cfg *config.Timeout) int64 { return cfg.Db }))
+}
+
+// Grpc bounds a single call to another service.
+func Grpc(pctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(pctx, seconds(func(cfg *config.Timeout) int64 { return cfg.Grpc }))
+}
+
+// Kafka bounds the wait for the reply of one saga step.
+func Kafka(pctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(pctx, seconds(func(cfg *config.Timeout) int64 { return cfg.Kafka }))
+}
@@ -0,0 +1,42 @@
+package grpccon
+
+// rpcAllowList maps every gRPC method to the services that may call it. The caller is
+// taken from the service claim of its api key, methods that are not listed are denied.
+var rpcAllowList = map[string][]string{
+	// Every service validates access tokens in its http middleware
+	"/AuthGrpcService/AccessTokenSearch": {"auth", "player", "item", "inventory", "payment"},
+	// A password reset signs the player out everywhere
+	"/AuthGrpcService/RevokePlayerCredentials": {"player"},
+
+	"/PlayerGrpcService/CredentialSearch":              {"auth"},
+	"/PlayerGrpcService/FindOnePlayerProfileToRefresh": {"auth"},
+	"/PlayerGrpcService/ProvisionPlayer":               {"auth"},
+	"/PlayerGrpcService/VerifyMfaCode":                 {"auth"},
+	"/PlayerGrpcService/GetPlayerSavingAccount":        {"payment"},
+
+	"/ItemGrpcService/FindItemsInIds":    {"inventory", "payment"},
+	"/ItemGrpcService/IncreaseSoldCount": {"payment"},
+	"/ItemGrpcService/ReserveStock":      {"payment"},
+	"/ItemGrpcService/ReleaseStock":      {"payment"},
+
+	"/InventoryGrpcService/IsAvaliableToSell": {"payment"},
+}
+
+// publicRpcs need no api key, the health check stream is opened by the grpc client itself
+// and reflection is only registered outside prod for tools such as grpcurl.
+var publicRpcs = map[string]bool{
+	"/grpc.health.v1.Health/Check": true,
+	"/grpc.health.v1.Health/Watch": true,
+
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      true,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": true,
+}
+
+func isAllowed(fullMethod, service string) bool {
+	for _, s := range rpcAllowList[fullMethod] {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package grpccon
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// Error is a domain error that carries the status it is sent with, so its code does not
+	// depend on its wording. Modules declare their errors with NewError, ToStatus finds them
+	// with errors.As.
+	Error struct {
+		code   codes.Code
+		reason string
+		msg    string
+		// kind is the declared error this one was made from by With
+		kind *Error
+	}
+)
+
+func NewError(code codes.Code, reason, msg string) *Error {
+	return &Error{code: code, reason: reason, msg: msg}
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+// With is the same error with a message that names what it is about, errors.Is still
+// matches it with the declared error.
+func (e *Error) With(msg string) *Error {
+	kind := e
+	if e.kind != nil {
+		kind = e.kind
+	}
+	return &Error{code: e.code, reason: e.reason, msg: msg, kind: kind}
+}
+
+func (e *Error) Is(target error) bool {
+	return e.kind != nil && e.kind == target
+}
+
+// errorRules map the wording of errors that are not declared with NewError, e.g. one from a
+// library, to status codes. They are the last resort, the first matching rule wins.
+var errorRules = []struct {
+	contains string
+	code     codes.Code
+	reason   string
+}{
+	{"permission denied", codes.PermissionDenied, "PERMISSION_DENIED"},
+	{"not allowed", codes.PermissionDenied, "PERMISSION_DENIED"},
+	{"not found", codes.NotFound, "NOT_FOUND"},
+	{"out of stock", codes.FailedPrecondition, "OUT_OF_STOCK"},
+	{"limit reached", codes.FailedPrecondition, "PURCHASE_LIMIT_REACHED"},
+	{"already exist", codes.AlreadyExists, "ALREADY_EXISTS"},
+	{"already", codes.FailedPrecondition, "FAILED_PRECONDITION"},
+	{"not enabled", codes.FailedPrecondition, "FAILED_PRECONDITION"},
+	{"not enough", codes.FailedPrecondition, "FAILED_PRECONDITION"},
+	{"expired", codes.FailedPrecondition, "EXPIRED"},
+	{"incorrect", codes.Unauthenticated, "UNAUTHENTICATED"},
+	{"unavailable", codes.Unavailable, "UNAVAILABLE"},
+	{"connection failed", codes.Unavailable, "UNAVAILABLE"},
+	{"invalid", codes.InvalidArgument, "INVALID_ARGUMENT"},
+	{"required", codes.InvalidArgument, "INVALID_ARGUMENT"},
+}
+
+// ToStatus converts a domain error into a gRPC status error carrying an ErrorInfo detail.
+// Errors that already are status errors are returned unchanged.
+func ToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var domainErr *Error
+	code, reason := codes.Internal, "INTERNAL"
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		code, reason = codes.DeadlineExceeded, "DEADLINE_EXCEEDED"
+	case errors.Is(err, context.Canceled):
+		code, reason = codes.Canceled, "CANCELED"
+	case errors.As(err, &domainErr):
+		code, reason = domainErr.code, domainErr.reason
+	default:
+		msg := strings.ToLower(err.Error())
+		for _, rule := range errorRules {
+			if strings.Contains(msg, rule.contains) {
+				code, reason = rule.code, rule.reason
+				break
+			}
+		}
+	}
+
+	return statusWithReason(code, reason, err.Error())
+}
+
+func statusWithReason(code codes.Code, reason, msg string) error {
+	st := status.New(code, msg)
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: "bonxshop.com",
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return withDetails.Err()
+}
+
+// ErrorReason returns the ErrorInfo reason of a status error, or an empty string.
+func ErrorReason(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
@@ -2,7 +2,6 @@ package grpccon
 
 import (
 	"context"
-	"errors"
 	"log"
 	"net"
 
@@ -13,9 +12,16 @@ import (
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
 
 	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 type (
@@ -35,35 +41,72 @@ type (
 	}
 )
 
-func (g *grpcAuth) unaryAuthorization(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+// authorize checks the api key in the "auth" metadata against the rpc allow-list.
+func (g *grpcAuth) authorize(ctx context.Context, fullMethod string) error {
+	if publicRpcs[fullMethod] {
+		return nil
+	}
+
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		log.Printf("Error: metadata not found")
-		return nil, errors.New("error: metadata not found")
+		return status.Error(codes.Unauthenticated, "error: metadata not found")
 	}
 
 	authHeader, ok := md["auth"]
 	if !ok {
 		log.Printf("Error: auth header not found")
-		return nil, errors.New("error: auth header not found")
+		return status.Error(codes.Unauthenticated, "error: auth header not found")
 	}
 
 	if len(authHeader) == 0 {
 		log.Printf("Error: auth header is empty")
-		return nil, errors.New("error: auth header is empty")
+		return status.Error(codes.Unauthenticated, "error: auth header is empty")
 	}
 
 	claims, err := jwtauth.ParseToken(g.secretKey, string(authHeader[0]))
 	if err != nil {
 		log.Printf("Error: Parse token failed: %s", err.Error())
-		return nil, errors.New("error: token is invalid")
+		return status.Error(codes.Unauthenticated, "error: token is invalid")
+	}
+
+	if claims.Subject != "api-key" || claims.Claims == nil || claims.Service == "" {
+		log.Printf("Error: %s called without a service api key", fullMethod)
+		return status.Error(codes.Unauthenticated, "error: token is invalid")
 	}
 
-	log.Printf("claims: %v", claims)
+	// With mtls the client certificate must belong to the same service as the api key
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(tlsInfo.State.VerifiedChains) > 0 {
+			if commonName := tlsInfo.State.VerifiedChains[0][0].Subject.CommonName; commonName != claims.Service {
+				log.Printf("Error: certificate %s does not match api key service %s", commonName, claims.Service)
+				return status.Error(codes.PermissionDenied, "error: permission denied")
+			}
+		}
+	}
+
+	if !isAllowed(fullMethod, claims.Service) {
+		log.Printf("Error: service %s is not allowed to call %s", claims.Service, fullMethod)
+		return status.Error(codes.PermissionDenied, "error: permission denied")
+	}
+
+	return nil
+}
 
+func (g *grpcAuth) unaryAuthorization(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := g.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
 	return handler(ctx, req)
 }
 
+func (g *grpcAuth) streamAuthorization(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := g.authorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
 func (g *grpcClientFactory) Auth() authPb.AuthGrpcServiceClient {
 	return authPb.NewAuthGrpcServiceClient(g.client)
 }
@@ -79,14 +122,9 @@ func (g *grpcClientFactory) Inventory() inventoryPb.InventoryGrpcServiceClient {
 }
 
 func NewGrpcClient(host string) (GrpcClientFactoryHandler, error) {
-	opts := make([]grpc.DialOption, 0)
-
-	opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
-
-	clientConn, err := grpc.Dial(host, opts...)
+	clientConn, err := registry.conn(host)
 	if err != nil {
-		log.Printf("Error: Grpc client connection failed: %s", err.Error())
-		return nil, errors.New("error: grpc client connection failed")
+		return nil, err
 	}
 
 	return &grpcClientFactory{
@@ -94,20 +132,57 @@ func NewGrpcClient(host string) (GrpcClientFactoryHandler, error) {
 	}, nil
 }
 
-func NewGrpcServer(cfg *config.Jwt, host string) (*grpc.Server, net.Listener) {
+// NewGrpcServer builds a server with the interceptor chain and the standard health service.
+// The health status follows the probe, a nil probe always reports serving.
+func NewGrpcServer(cfg *config.Jwt, host string, probe HealthProbe) (*grpc.Server, net.Listener) {
 	opts := make([]grpc.ServerOption, 0)
 
 	grpcAuth := &grpcAuth{
 		secretKey: cfg.ApiSecretKey,
 	}
 
-	opts = append(opts, grpc.UnaryInterceptor(grpcAuth.unaryAuthorization))
+	// Outermost first: a panic anywhere below is recovered, every call is logged and
+	// measured with its final status code, and handler errors are mapped to status codes.
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(
+			unaryRecovery,
+			unaryLogging,
+			unaryMetrics,
+			unaryDeadline,
+			grpcAuth.unaryAuthorization,
+			unaryValidation,
+			unaryErrorMapping,
+		),
+		grpc.ChainStreamInterceptor(
+			streamRecovery,
+			streamLogging,
+			streamMetrics,
+			streamDeadline,
+			grpcAuth.streamAuthorization,
+			streamValidation,
+			streamErrorMapping,
+		),
+	)
+
+	// Spans continue the trace of the caller, health checks are too frequent to be worth one
+	opts = append(opts, grpc.StatsHandler(otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))))
+
+	if creds, ok := tlsInstant.serverOption(); ok {
+		opts = append(opts, creds)
+	}
 
 	grpcServer := grpc.NewServer(opts...)
 
-	lis, err := net.Listen("tcp", host)
+	// Clients balance and fail over on the standard health-checking protocol
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	if probe != nil {
+		go watchHealth(grpcServer, healthServer, probe)
+	}
+
+	lis, err := net.Listen("tcp", listenAddress(host))
 	if err != nil {
-		log.Fatal("Error: Failed to listen: %v", err)
+		log.Fatalf("Error: Failed to listen: %v", err)
 	}
 
 	return grpcServer, lis
@@ -0,0 +1,63 @@
+package grpccon
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const healthInterval = 10 * time.Second
+
+// HealthProbe reports whether the dependencies of the service, such as Mongo and Kafka, are reachable.
+type HealthProbe func(pctx context.Context) bool
+
+// watchHealth polls the probe for the lifetime of the process and sets the status of the
+// whole server ("") and of every registered service.
+func watchHealth(grpcServer *grpc.Server, healthServer *health.Server, probe HealthProbe) {
+	current := healthpb.HealthCheckResponse_SERVING
+
+	for {
+		next := healthpb.HealthCheckResponse_NOT_SERVING
+		if probe(context.Background()) {
+			next = healthpb.HealthCheckResponse_SERVING
+		}
+
+		if next != current {
+			log.Printf("gRPC health status changed from %s to %s", current, next)
+			current = next
+		}
+
+		healthServer.SetServingStatus("", current)
+		for service := range grpcServer.GetServiceInfo() {
+			if service != healthpb.Health_ServiceDesc.ServiceName {
+				healthServer.SetServingStatus(service, current)
+			}
+		}
+
+		time.Sleep(healthInterval)
+	}
+}
+
+// CheckHealth asks a downstream server for its overall status over the shared connection.
+func CheckHealth(pctx context.Context, target string) error {
+	conn, err := registry.conn(target)
+	if err != nil {
+		return err
+	}
+
+	res, err := healthpb.NewHealthClient(conn).Check(pctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		log.Printf("Error: gRPC health check %s failed: %s", target, err.Error())
+		return errors.New("error: grpc health check failed")
+	}
+
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		return errors.New("error: grpc service is not serving")
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package grpccon
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// calls without a deadline from the client get this one
+const defaultDeadline = 30 * time.Second
+
+const healthWatchMethod = "/grpc.health.v1.Health/Watch"
+
+type (
+	// validator is implemented by request messages that can check their own fields
+	validator interface {
+		Validate() error
+	}
+
+	// serverStream lets stream interceptors replace the context and inspect received messages
+	serverStream struct {
+		grpc.ServerStream
+		ctx context.Context
+	}
+)
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if v, ok := m.(validator); ok {
+		if err := v.Validate(); err != nil {
+			return statusWithReason(codes.InvalidArgument, "INVALID_ARGUMENT", err.Error())
+		}
+	}
+	return nil
+}
+
+func wrapStream(ss grpc.ServerStream, ctx context.Context) *serverStream {
+	if s, ok := ss.(*serverStream); ok {
+		return &serverStream{ServerStream: s.ServerStream, ctx: ctx}
+	}
+	return &serverStream{ServerStream: ss, ctx: ctx}
+}
+
+// Recovery
+
+func unaryRecovery(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error: gRPC %s panic: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "error: internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func streamRecovery(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Error: gRPC %s panic: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "error: internal server error")
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// Logging
+
+func logCall(method string, start time.Time, err error) {
+	if err != nil {
+		log.Printf("Error: gRPC %s %s %s: %s", method, status.Code(err), time.Since(start), err.Error())
+		return
+	}
+	log.Printf("gRPC %s %s %s", method, codes.OK, time.Since(start))
+}
+
+func unaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	logCall(info.FullMethod, start, err)
+	return res, err
+}
+
+func streamLogging(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	logCall(info.FullMethod, start, err)
+	return err
+}
+
+// Metrics
+
+func observeCall(method string, start time.Time, err error) {
+	metrics.GrpcServerHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.GrpcServerHandlingSeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func unaryMetrics(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	res, err := handler(ctx, req)
+	observeCall(info.FullMethod, start, err)
+	return res, err
+}
+
+func streamMetrics(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	observeCall(info.FullMethod, start, err)
+	return err
+}
+
+func unaryClientMetrics(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	metrics.GrpcClientHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+	metrics.GrpcClientHandlingSeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// Deadlines
+
+func withDeadline(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if err := ctx.Err(); err != nil {
+		return ctx, func() {}, status.FromContextError(err).Err()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, defaultDeadline)
+	return ctx, cancel, nil
+}
+
+func unaryDeadline(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, cancel, err := withDeadline(ctx)
+	defer cancel()
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func streamDeadline(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	// The health watch stream stays open for the lifetime of the client connection
+	if info.FullMethod == healthWatchMethod {
+		return handler(srv, ss)
+	}
+
+	ctx, cancel, err := withDeadline(ss.Context())
+	defer cancel()
+	if err != nil {
+		return err
+	}
+	return handler(srv, wrapStream(ss, ctx))
+}
+
+// Validation
+
+func unaryValidation(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if v, ok := req.(validator); ok {
+		if err := v.Validate(); err != nil {
+			log.Printf("Error: gRPC %s invalid request: %s", info.FullMethod, err.Error())
+			return nil, statusWithReason(codes.InvalidArgument, "INVALID_ARGUMENT", err.Error())
+		}
+	}
+	return handler(ctx, req)
+}
+
+// streamValidation validates every received message through serverStream.RecvMsg.
+func streamValidation(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, wrapStream(ss, ss.Context()))
+}
+
+// Error mapping
+
+func unaryErrorMapping(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	res, err := handler(ctx, req)
+	return res, ToStatus(err)
+}
+
+func streamErrorMapping(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return ToStatus(handler(srv, ss))
+}
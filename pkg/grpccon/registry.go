@@ -0,0 +1,158 @@
+package grpccon
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health"
+	"google.golang.org/grpc/resolver"
+)
+
+// Targets with several addresses ("host1:1523,host2:1523") are resolved by the static
+// resolver, a single address goes through dns so a name with many records is balanced too.
+const staticScheme = "static"
+
+type (
+	// clientRegistry keeps one connection per target for the lifetime of the service.
+	// A grpc.ClientConn reconnects by itself, so it is never dialed again per request.
+	clientRegistry struct {
+		mu    sync.Mutex
+		conns map[string]*grpc.ClientConn
+	}
+
+	staticResolverBuilder struct{}
+
+	staticResolver struct{}
+)
+
+// idempotentRpcs are retried on UNAVAILABLE. ProvisionPlayer and VerifyMfaCode are left out
+// on purpose, a retry could create a player twice or burn a recovery code.
+var idempotentRpcs = map[string][]string{
+	"AuthGrpcService":      {"AccessTokenSearch", "RevokePlayerCredentials"},
+	"PlayerGrpcService":    {"CredentialSearch", "FindOnePlayerProfileToRefresh", "GetPlayerSavingAccount"},
+	"ItemGrpcService":      {"FindItemsInIds"},
+	"InventoryGrpcService": {"IsAvaliableToSell"},
+}
+
+var registry = &clientRegistry{
+	conns: make(map[string]*grpc.ClientConn),
+}
+
+func (r *clientRegistry) conn(target string) (*grpc.ClientConn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if conn, ok := r.conns[target]; ok {
+		return conn, nil
+	}
+
+	serviceConfig, err := clientServiceConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.DialOption{
+		tlsInstant.dialOption(),
+		grpc.WithResolvers(staticResolverBuilder{}),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		grpc.WithChainUnaryInterceptor(unaryClientMetrics),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))),
+	}
+
+	conn, err := grpc.NewClient(dialTarget(target), opts...)
+	if err != nil {
+		log.Printf("Error: Grpc client connection failed: %s", err.Error())
+		return nil, errors.New("error: grpc client connection failed")
+	}
+	r.conns[target] = conn
+
+	return conn, nil
+}
+
+// CloseClients closes every shared client connection, called on server shutdown.
+func CloseClients() {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for target, conn := range registry.conns {
+		if err := conn.Close(); err != nil {
+			log.Printf("Error: Close grpc connection %s failed: %s", target, err.Error())
+		}
+		delete(registry.conns, target)
+	}
+}
+
+func dialTarget(target string) string {
+	if strings.Contains(target, ",") {
+		return staticScheme + ":///" + target
+	}
+	return target
+}
+
+// listenAddress is the address a server binds to when its url lists several instances.
+func listenAddress(target string) string {
+	return strings.TrimSpace(strings.Split(target, ",")[0])
+}
+
+// clientServiceConfig balances round robin across the healthy addresses of a target, using
+// the standard grpc.health.v1 protocol, and retries idempotent rpcs.
+func clientServiceConfig() (string, error) {
+	names := make([]map[string]string, 0)
+	for service, methods := range idempotentRpcs {
+		for _, method := range methods {
+			names = append(names, map[string]string{"service": service, "method": method})
+		}
+	}
+
+	serviceConfig := map[string]any{
+		"loadBalancingConfig": []map[string]any{{"round_robin": map[string]any{}}},
+		"healthCheckConfig":   map[string]string{"serviceName": ""},
+		"methodConfig": []map[string]any{
+			{
+				"name": names,
+				"retryPolicy": map[string]any{
+					"maxAttempts":          3,
+					"initialBackoff":       "0.1s",
+					"maxBackoff":           "1s",
+					"backoffMultiplier":    2,
+					"retryableStatusCodes": []string{"UNAVAILABLE"},
+				},
+			},
+		},
+	}
+
+	b, err := json.Marshal(serviceConfig)
+	if err != nil {
+		log.Printf("Error: Marshal grpc service config failed: %s", err.Error())
+		return "", errors.New("error: grpc service config is invalid")
+	}
+	return string(b), nil
+}
+
+func (staticResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	addresses := make([]resolver.Address, 0)
+	for _, addr := range strings.Split(target.Endpoint(), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addresses = append(addresses, resolver.Address{Addr: addr})
+		}
+	}
+
+	if err := cc.UpdateState(resolver.State{Addresses: addresses}); err != nil {
+		return nil, err
+	}
+	return staticResolver{}, nil
+}
+
+func (staticResolverBuilder) Scheme() string {
+	return staticScheme
+}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (staticResolver) Close() {}
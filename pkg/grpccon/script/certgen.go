@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Generates a development CA and one certificate per service for gRPC mTLS.
+// The common name of every certificate is the service name, which must match APP_NAME.
+//
+// go run ./pkg/grpccon/script/certgen.go ./certs auth player item inventory payment
+func main() {
+	if len(os.Args) < 3 {
+		log.Fatal("Error: usage: certgen <dir> <service>...")
+	}
+	dir := os.Args[1]
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatalf("Error: create dir failed: %s", err.Error())
+	}
+
+	caCert, caKey := loadOrCreateCa(dir)
+
+	for _, service := range os.Args[2:] {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			log.Fatalf("Error: generate key failed: %s", err.Error())
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: serialNumber(),
+			Subject:      pkix.Name{CommonName: service, Organization: []string{"Bonx Shop Dev"}},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().AddDate(1, 0, 0),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			// Every service is a gRPC server and a client of the others
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+			DNSNames:    []string{service, "localhost"},
+			IPAddresses: []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("0.0.0.0"), net.IPv6loopback},
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			log.Fatalf("Error: create certificate failed: %s", err.Error())
+		}
+
+		writePem(filepath.Join(dir, service+".crt"), "CERTIFICATE", der, 0o644)
+		writeKey(filepath.Join(dir, service+".key"), key)
+		log.Printf("Certificate for %s written to %s", service, dir)
+	}
+}
+
+func loadOrCreateCa(dir string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	certFile := filepath.Join(dir, "ca.crt")
+	keyFile := filepath.Join(dir, "ca.key")
+
+	certPem, certErr := os.ReadFile(certFile)
+	keyPem, keyErr := os.ReadFile(keyFile)
+	if certErr == nil && keyErr == nil {
+		certBlock, _ := pem.Decode(certPem)
+		keyBlock, _ := pem.Decode(keyPem)
+		if certBlock == nil || keyBlock == nil {
+			log.Fatal("Error: ca files are invalid")
+		}
+		cert, err := x509.ParseCertificate(certBlock.Bytes)
+		if err != nil {
+			log.Fatalf("Error: parse ca failed: %s", err.Error())
+		}
+		key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+		if err != nil {
+			log.Fatalf("Error: parse ca key failed: %s", err.Error())
+		}
+		log.Printf("Using existing CA from %s", dir)
+		return cert, key
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Fatalf("Error: generate ca key failed: %s", err.Error())
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: "Bonx Shop Dev CA", Organization: []string{"Bonx Shop Dev"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		log.Fatalf("Error: create ca failed: %s", err.Error())
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		log.Fatalf("Error: parse ca failed: %s", err.Error())
+	}
+
+	writePem(certFile, "CERTIFICATE", der, 0o644)
+	writeKey(keyFile, key)
+	log.Printf("CA written to %s", dir)
+
+	return cert, key
+}
+
+func serialNumber() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		log.Fatalf("Error: generate serial number failed: %s", err.Error())
+	}
+	return serial
+}
+
+func writeKey(path string, key *ecdsa.PrivateKey) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		log.Fatalf("Error: marshal key failed: %s", err.Error())
+	}
+	writePem(path, "EC PRIVATE KEY", der, 0o600)
+}
+
+func writePem(path, blockType string, der []byte, perm os.FileMode) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), perm); err != nil {
+		log.Fatalf("Error: write %s failed: %s", path, err.Error())
+	}
+}
@@ -0,0 +1,218 @@
+package grpccon
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Tls modes
+const (
+	TlsDisabled = "disabled"
+	TlsServer   = "tls"
+	TlsMutual   = "mtls"
+)
+
+// certificates are checked for changes on disk at most this often
+const reloadInterval = 10 * time.Second
+
+type (
+	tlsSetting struct {
+		mode       string
+		serverName string
+		reloader   *certReloader
+	}
+
+	// certReloader keeps the key pair and ca pool in memory and re-reads them
+	// when the files change, so certificates can be rotated without a restart.
+	certReloader struct {
+		certFile  string
+		keyFile   string
+		caFile    string
+		mu        sync.RWMutex
+		cert      *tls.Certificate
+		pool      *x509.CertPool
+		modTime   time.Time
+		checkedAt time.Time
+	}
+)
+
+var tlsInstant = &tlsSetting{mode: TlsDisabled}
+
+// SetTls configures transport security for every gRPC server and client of this process.
+func SetTls(cfg *config.Grpc) error {
+	mode := cfg.TlsMode
+	if mode == "" {
+		mode = TlsDisabled
+	}
+
+	switch mode {
+	case TlsDisabled:
+		tlsInstant = &tlsSetting{mode: TlsDisabled}
+		return nil
+	case TlsServer, TlsMutual:
+	default:
+		return fmt.Errorf("error: grpc tls mode %s is invalid", mode)
+	}
+
+	reloader := &certReloader{
+		certFile: cfg.TlsCertFile,
+		keyFile:  cfg.TlsKeyFile,
+		caFile:   cfg.TlsCaFile,
+	}
+	if err := reloader.load(); err != nil {
+		return err
+	}
+
+	tlsInstant = &tlsSetting{
+		mode:       mode,
+		serverName: cfg.TlsServerName,
+		reloader:   reloader,
+	}
+	log.Printf("Info: gRPC transport security: %s", mode)
+
+	return nil
+}
+
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		log.Printf("Error: Load certificate failed: %s", err.Error())
+		return errors.New("error: load certificate failed")
+	}
+
+	caPem, err := os.ReadFile(r.caFile)
+	if err != nil {
+		log.Printf("Error: Load ca failed: %s", err.Error())
+		return errors.New("error: load ca failed")
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPem) {
+		return errors.New("error: ca file has no certificate")
+	}
+
+	r.cert = &cert
+	r.pool = pool
+	r.modTime = r.latestModTime()
+	r.checkedAt = time.Now()
+
+	return nil
+}
+
+func (r *certReloader) latestModTime() time.Time {
+	latest := time.Time{}
+	for _, f := range []string{r.certFile, r.keyFile, r.caFile} {
+		if info, err := os.Stat(f); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// reload keeps the old certificate when the new files cannot be loaded, e.g. while
+// they are half way through being replaced.
+func (r *certReloader) reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < reloadInterval {
+		return
+	}
+	r.checkedAt = time.Now()
+
+	if !r.latestModTime().After(r.modTime) {
+		return
+	}
+
+	if err := r.load(); err != nil {
+		log.Printf("Error: Reload certificate failed, keep the current one: %s", err.Error())
+		return
+	}
+	log.Printf("Info: gRPC certificate reloaded from %s", r.certFile)
+}
+
+func (r *certReloader) certificate() *tls.Certificate {
+	r.reload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert
+}
+
+func (r *certReloader) certPool() *x509.CertPool {
+	r.reload()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.pool
+}
+
+func (s *tlsSetting) serverOption() (grpc.ServerOption, bool) {
+	if s.mode == TlsDisabled {
+		return nil, false
+	}
+
+	return grpc.Creds(credentials.NewTLS(&tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*s.reloader.certificate()},
+			}
+			if s.mode == TlsMutual {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+				cfg.ClientCAs = s.reloader.certPool()
+			}
+			return cfg, nil
+		},
+	})), true
+}
+
+func (s *tlsSetting) dialOption() grpc.DialOption {
+	if s.mode == TlsDisabled {
+		return grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: s.serverName,
+		// The chain is verified in VerifyConnection against the current ca pool,
+		// the standard verification would pin the pool loaded at dial time.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("error: server did not present a certificate")
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         s.reloader.certPool(),
+				Intermediates: intermediates,
+				DNSName:       cs.ServerName,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+			return err
+		},
+	}
+	if s.mode == TlsMutual {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return s.reloader.certificate(), nil
+		}
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(cfg))
+}
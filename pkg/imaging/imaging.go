@@ -0,0 +1,121 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+const (
+	// maxPixels bounds the decoded size, a small file can hold a huge image
+	maxPixels = 4096 * 4096
+
+	thumbnailQuality = 85
+)
+
+// formats are the accepted content types and the extension an image is stored with.
+var formats = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// Detect sniffs the content type from the data itself, the type sent by the client is not
+// trusted. The image header is checked as well, so a renamed file is refused.
+func Detect(data []byte) (contentType, ext string, err error) {
+	contentType = http.DetectContentType(data)
+	ext, ok := formats[contentType]
+	if !ok {
+		return "", "", errors.New("error: image must be jpeg, png or gif")
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", "", errors.New("error: image is invalid")
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return "", "", errors.New("error: image is larger than 4096x4096")
+	}
+
+	return contentType, ext, nil
+}
+
+// Thumbnail scales the image down to fit in size x size and encodes it as jpeg, transparent
+// parts become white. Smaller images keep their size.
+func Thumbnail(data []byte, size int) ([]byte, error) {
+	if _, _, err := Detect(data); err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("error: image is invalid")
+	}
+
+	bounds := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	width, height := fit(bounds.Dx(), bounds.Dy(), size)
+	dst := scale(rgba, width, height)
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, errors.New("error: encode thumbnail failed")
+	}
+	return buf.Bytes(), nil
+}
+
+func fit(width, height, size int) (int, int) {
+	if width <= size && height <= size {
+		return width, height
+	}
+	if width >= height {
+		return size, max(1, height*size/width)
+	}
+	return max(1, width*size/height), size
+}
+
+// scale averages the source pixels covered by each pixel of the result (a box filter),
+// which is good enough for shrinking and needs nothing outside the standard library.
+func scale(src *image.RGBA, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	srcWidth, srcHeight := src.Bounds().Dx(), src.Bounds().Dy()
+
+	for y := 0; y < height; y++ {
+		y0 := y * srcHeight / height
+		y1 := max(y0+1, (y+1)*srcHeight/height)
+
+		for x := 0; x < width; x++ {
+			x0 := x * srcWidth / width
+			x1 := max(x0+1, (x+1)*srcWidth/width)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+
+			// The colours are premultiplied, adding the missing alpha puts them on white
+			white := 255 - a/n
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r/n + white)
+			dst.Pix[i+1] = uint8(g/n + white)
+			dst.Pix[i+2] = uint8(b/n + white)
+			dst.Pix[i+3] = 255
+		}
+	}
+	return dst
+}
@@ -18,8 +18,12 @@ type (
 	}
 
 	Claims struct {
-		PlayerId string `json:"player_id"`
-		RoleCode int    `json:"role_code"`
+		PlayerId    string   `json:"player_id"`
+		Roles       []string `json:"roles"`
+		Permissions []string `json:"permissions"`
+		Mfa         bool     `json:"mfa,omitempty"`
+		// Service identifies the calling service in api keys
+		Service string `json:"service,omitempty"`
 	}
 
 	AuthMapClaims struct {
@@ -34,6 +38,8 @@ type (
 
 	accessToken  struct{ *authConcrete }
 	refreshToken struct{ *authConcrete }
+	mfaChallenge struct{ *authConcrete }
+	actionToken  struct{ *authConcrete }
 	apiKey       struct{ *authConcrete }
 )
 
@@ -94,6 +100,49 @@ func NewRefreshToken(secret string, expiredAt int64, claims *Claims) AuthFactory
 	}
 }
 
+// NewMfaChallengeToken is handed out after the password step and can only be exchanged
+// for an access/refresh pair together with a valid second factor.
+func NewMfaChallengeToken(secret string, expiredAt int64, claims *Claims) AuthFactory {
+	return &mfaChallenge{
+		authConcrete: &authConcrete{
+			Secret: []byte(secret),
+			Claims: &AuthMapClaims{
+				Claims: claims,
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    "bonxshop.com",
+					Subject:   "mfa-challenge",
+					Audience:  []string{"bonxshop.com"},
+					ExpiresAt: jwtTimeDurationCal(expiredAt),
+					NotBefore: jwt.NewNumericDate(now()),
+					IssuedAt:  jwt.NewNumericDate(now()),
+				},
+			},
+		},
+	}
+}
+
+// NewActionToken signs a one-off token such as an email verification or password reset link.
+// The action is the subject and tokenId lets the issuer mark the token as used.
+func NewActionToken(secret string, expiredAt int64, action, tokenId string, claims *Claims) AuthFactory {
+	return &actionToken{
+		authConcrete: &authConcrete{
+			Secret: []byte(secret),
+			Claims: &AuthMapClaims{
+				Claims: claims,
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        tokenId,
+					Issuer:    "bonxshop.com",
+					Subject:   action,
+					Audience:  []string{"bonxshop.com"},
+					ExpiresAt: jwtTimeDurationCal(expiredAt),
+					NotBefore: jwt.NewNumericDate(now()),
+					IssuedAt:  jwt.NewNumericDate(now()),
+				},
+			},
+		},
+	}
+}
+
 func ReloadToken(secret string, expiredAt int64, claims *Claims) string {
 	obj := &refreshToken{
 		authConcrete: &authConcrete{
@@ -115,17 +164,19 @@ func ReloadToken(secret string, expiredAt int64, claims *Claims) string {
 	return obj.SignToken()
 }
 
-func NewApiKey(secret string) AuthFactory {
+func NewApiKey(secret, service string, expiredAt int64) AuthFactory {
 	return &apiKey{
 		authConcrete: &authConcrete{
 			Secret: []byte(secret),
 			Claims: &AuthMapClaims{
-				Claims: &Claims{},
+				Claims: &Claims{
+					Service: service,
+				},
 				RegisteredClaims: jwt.RegisteredClaims{
 					Issuer:    "bonxshop.com",
 					Subject:   "api-key",
 					Audience:  []string{"bonxshop.com"},
-					ExpiresAt: jwtTimeDurationCal(31536000),
+					ExpiresAt: jwtTimeDurationCal(expiredAt),
 					NotBefore: jwt.NewNumericDate(now()),
 					IssuedAt:  jwt.NewNumericDate(now()),
 				},
@@ -160,15 +211,50 @@ func ParseToken(secret string, tokenString string) (*AuthMapClaims, error) {
 }
 
 // Apikey generator
-var apiKeyInstant string
-var one sync.Once
+type apiKeyGenerator struct {
+	mu        sync.Mutex
+	secret    string
+	service   string
+	duration  int64
+	key       string
+	renewAt   time.Time
+	expiredAt time.Time
+}
 
-func SetApiKey(secret string) {
-	one.Do(func() {
-		apiKeyInstant = NewApiKey(secret).SignToken()
-	})
+var apiKeyInstant = new(apiKeyGenerator)
+
+// SetApiKey configures the api key this service presents to other services.
+func SetApiKey(secret, service string, duration int64) {
+	apiKeyInstant.mu.Lock()
+	defer apiKeyInstant.mu.Unlock()
+
+	apiKeyInstant.secret = secret
+	apiKeyInstant.service = service
+	apiKeyInstant.duration = duration
+	apiKeyInstant.sign()
+}
+
+// sign mints a new key and schedules its renewal when a fifth of its lifetime is left.
+func (g *apiKeyGenerator) sign() {
+	g.key = NewApiKey(g.secret, g.service, g.duration).SignToken()
+	lifetime := time.Duration(g.duration) * time.Second
+	g.expiredAt = now().Add(lifetime)
+	g.renewAt = now().Add(lifetime * 4 / 5)
+}
+
+// ApiKey returns the current api key, renewing it before it expires.
+func ApiKey() string {
+	apiKeyInstant.mu.Lock()
+	defer apiKeyInstant.mu.Unlock()
+
+	if apiKeyInstant.key != "" && now().After(apiKeyInstant.renewAt) {
+		apiKeyInstant.sign()
+		log.Printf("Info: api key renewed, expires at %s", apiKeyInstant.expiredAt.String())
+	}
+
+	return apiKeyInstant.key
 }
 
 func SetApiKeyInContext(pctx *context.Context) {
-	*pctx = metadata.NewOutgoingContext(*pctx, metadata.Pairs("auth", apiKeyInstant))
+	*pctx = metadata.NewOutgoingContext(*pctx, metadata.Pairs("auth", ApiKey()))
 }
@@ -0,0 +1,25 @@
+package locale
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	AcceptLanguageHeader  = "Accept-Language"
+	ContentLanguageHeader = "Content-Language"
+)
+
+// EchoMiddleware negotiates the locale of the request from Accept-Language and puts it into
+// the request context. The response says which locale it is in, and that it varies by it.
+func EchoMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		l := Negotiate(c.Request().Header.Get(AcceptLanguageHeader))
+		c.Response().Header().Set(ContentLanguageHeader, l)
+		c.Response().Header().Add(echo.HeaderVary, AcceptLanguageHeader)
+
+		ctx := WithLocale(c.Request().Context(), l)
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		return next(c)
+	}
+}
@@ -0,0 +1,125 @@
+package locale
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+)
+
+type contextKey int
+
+const localeKey contextKey = iota
+
+// localeInstant holds the locales of the service, set once on start up. Until then only
+// English is known, like the defaults of the config.
+type localeInstant struct {
+	mu  sync.RWMutex
+	cfg config.Locale
+}
+
+var instant = &localeInstant{
+	cfg: config.Locale{Default: "en"},
+}
+
+func Set(cfg *config.Locale) {
+	instant.mu.Lock()
+	defer instant.mu.Unlock()
+
+	instant.cfg = config.Locale{Default: cfg.Default, Supported: []string{cfg.Default}}
+	for _, l := range cfg.Supported {
+		if l != cfg.Default {
+			instant.cfg.Supported = append(instant.cfg.Supported, l)
+		}
+	}
+}
+
+// Default is the locale of the plain title and description of an item.
+func Default() string {
+	instant.mu.RLock()
+	defer instant.mu.RUnlock()
+
+	return instant.cfg.Default
+}
+
+func IsSupported(l string) bool {
+	instant.mu.RLock()
+	defer instant.mu.RUnlock()
+
+	for _, supported := range instant.cfg.Supported {
+		if l == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate picks the supported locale an Accept-Language header likes best, a single tag
+// like "th-TH" is a header too. A region falls back to its language, and a header that
+// names nothing supported gets the default locale.
+func Negotiate(header string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	tags := make([]weighted, 0)
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, t := range tags {
+		if t.tag == "*" {
+			break
+		}
+		if IsSupported(t.tag) {
+			return t.tag
+		}
+		if language, _, ok := strings.Cut(t.tag, "-"); ok && IsSupported(language) {
+			return language
+		}
+	}
+	return Default()
+}
+
+func WithLocale(pctx context.Context, l string) context.Context {
+	return context.WithValue(pctx, localeKey, l)
+}
+
+// FromContext is the locale negotiated for the request, the default locale when there
+// was none.
+func FromContext(ctx context.Context) string {
+	if l, ok := ctx.Value(localeKey).(string); ok && l != "" {
+		return l
+	}
+	return Default()
+}
+
+// Translate picks the text of l from translations, the default text when there is none.
+func Translate(l, text string, translations map[string]string) string {
+	if translated, ok := translations[l]; ok && translated != "" {
+		return translated
+	}
+	return text
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// stdWriter turns lines of the std log package into records of the "std" module. The level
+// follows the "Error: " and "Info: " prefixes used across the code base.
+type stdWriter struct{}
+
+func bridgeStdLog() {
+	log.SetFlags(0)
+	log.SetOutput(stdWriter{})
+}
+
+func (stdWriter) Write(p []byte) (int, error) {
+	msg := strings.TrimSpace(string(p))
+
+	level := slog.LevelInfo
+	switch {
+	case strings.HasPrefix(msg, "Error:"):
+		level = slog.LevelError
+		msg = strings.TrimSpace(strings.TrimPrefix(msg, "Error:"))
+	case strings.HasPrefix(msg, "Info:"):
+		msg = strings.TrimSpace(strings.TrimPrefix(msg, "Info:"))
+	}
+
+	if !enabled("std", level) {
+		return len(p), nil
+	}
+
+	r := slog.NewRecord(time.Now(), level, msg, 0)
+	r.AddAttrs(slog.String("module", "std"))
+	if err := slog.Default().Handler().Handle(context.Background(), r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
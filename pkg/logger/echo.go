@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const RequestIdHeader = "X-Request-Id"
+
+// EchoMiddleware keeps the request id sent by the caller, or makes a new one, and puts it
+// into the request context so every log line of the request carries it.
+func EchoMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		requestId := c.Request().Header.Get(RequestIdHeader)
+		if requestId == "" {
+			requestId = uuid.NewString()
+		}
+		c.Response().Header().Set(RequestIdHeader, requestId)
+
+		ctx := WithRequestId(c.Request().Context(), requestId)
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		return next(c)
+	}
+}
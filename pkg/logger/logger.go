@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// Logger writes structured records for one module. The request, player and trace ids
+	// are read from the context of every call, so they never have to be passed by hand.
+	Logger struct {
+		module string
+	}
+
+	contextKey int
+
+	// contextHandler adds the ids carried by the context to every record.
+	contextHandler struct {
+		slog.Handler
+	}
+
+	levelSetting struct {
+		mu      sync.RWMutex
+		level   slog.Level
+		modules map[string]slog.Level
+	}
+)
+
+const (
+	requestIdKey contextKey = iota
+	playerIdKey
+)
+
+var levels = &levelSetting{
+	level:   slog.LevelInfo,
+	modules: make(map[string]slog.Level),
+}
+
+// Init replaces the default logger. Lines still written with the std log package go through
+// the same handler, so every line of the service is structured and redacted.
+func Init(cfg *config.Log, service string) {
+	opts := &slog.HandlerOptions{
+		// Levels are checked per module by Logger before a record is built
+		Level:       slog.LevelDebug,
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if cfg.Format == "text" {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	} else {
+		handler = slog.NewJSONHandler(os.Stdout, opts)
+	}
+	handler = &contextHandler{Handler: handler.WithAttrs([]slog.Attr{slog.String("service", service)})}
+
+	levels.mu.Lock()
+	levels.level = parseLevel(cfg.Level, slog.LevelInfo)
+	for module, level := range cfg.ModuleLevels {
+		levels.modules[module] = parseLevel(level, levels.level)
+	}
+	levels.mu.Unlock()
+
+	slog.SetDefault(slog.New(handler))
+	bridgeStdLog()
+}
+
+func New(module string) *Logger {
+	return &Logger{module: module}
+}
+
+func (l *Logger) Debug(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelDebug, msg, args...)
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelInfo, msg, args...)
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelWarn, msg, args...)
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, args ...any) {
+	l.log(ctx, slog.LevelError, msg, args...)
+}
+
+func (l *Logger) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !enabled(l.module, level) {
+		return
+	}
+
+	r := slog.NewRecord(time.Now(), level, msg, 0)
+	r.AddAttrs(slog.String("module", l.module))
+	r.Add(args...)
+	_ = slog.Default().Handler().Handle(ctx, r)
+}
+
+func enabled(module string, level slog.Level) bool {
+	levels.mu.RLock()
+	defer levels.mu.RUnlock()
+
+	if moduleLevel, ok := levels.modules[module]; ok {
+		return level >= moduleLevel
+	}
+	return level >= levels.level
+}
+
+func parseLevel(value string, fallback slog.Level) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return fallback
+	}
+	return level
+}
+
+func WithRequestId(pctx context.Context, requestId string) context.Context {
+	return context.WithValue(pctx, requestIdKey, requestId)
+}
+
+func RequestId(ctx context.Context) string {
+	requestId, _ := ctx.Value(requestIdKey).(string)
+	return requestId
+}
+
+func WithPlayerId(pctx context.Context, playerId string) context.Context {
+	return context.WithValue(pctx, playerIdKey, playerId)
+}
+
+// PlayerId is the player of an authorized request, empty for anonymous ones.
+func PlayerId(ctx context.Context) string {
+	playerId, _ := ctx.Value(playerIdKey).(string)
+	return playerId
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestId := RequestId(ctx); requestId != "" {
+		r.AddAttrs(slog.String("request_id", requestId))
+	}
+	if playerId := PlayerId(ctx); playerId != "" {
+		r.AddAttrs(slog.String("player_id", playerId))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		r.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are compared after lower casing and dropping "_" and "-", so "access_token",
+// "accessToken" and "Access-Token" all match "accesstoken".
+var sensitiveKeys = map[string]bool{
+	"password":       true,
+	"newpassword":    true,
+	"token":          true,
+	"accesstoken":    true,
+	"refreshtoken":   true,
+	"challengetoken": true,
+	"idtoken":        true,
+	"apikey":         true,
+	"auth":           true,
+	"authorization":  true,
+	"secret":         true,
+	"clientsecret":   true,
+	"code":           true,
+	"codeverifier":   true,
+	"recoverycodes":  true,
+}
+
+func isSensitive(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return sensitiveKeys[key]
+}
+
+// redactAttr hides sensitive attributes, and sensitive fields of structs and maps logged as
+// a whole, such as a request body.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+
+	v := a.Value.Any()
+	if err, ok := v.(error); ok {
+		return slog.String(a.Key, err.Error())
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice:
+		return slog.Any(a.Key, redactValue(v))
+	}
+	return a
+}
+
+// redactValue goes through the json form of v so the field names match the json tags.
+func redactValue(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return redacted
+	}
+
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return redacted
+	}
+	return redactGeneric(generic)
+}
+
+func redactGeneric(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for key, value := range t {
+			if isSensitive(key) {
+				t[key] = redacted
+				continue
+			}
+			t[key] = redactGeneric(value)
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = redactGeneric(t[i])
+		}
+		return t
+	}
+	return v
+}
@@ -0,0 +1,111 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+)
+
+type (
+	MailerService interface {
+		Send(pctx context.Context, msg *Message) error
+	}
+
+	Message struct {
+		To      string
+		Subject string
+		Body    string
+	}
+
+	smtpMailer struct {
+		cfg *config.Mail
+	}
+
+	// fileMailer writes every message to an .eml file instead of delivering it,
+	// so the links can be picked up by hand or by tests.
+	fileMailer struct {
+		cfg *config.Mail
+	}
+)
+
+// NewMailer picks the implementation from MAIL_DRIVER, "smtp" or "file" (default).
+func NewMailer(cfg *config.Mail) MailerService {
+	switch cfg.Driver {
+	case "smtp":
+		return &smtpMailer{cfg: cfg}
+	default:
+		return &fileMailer{cfg: cfg}
+	}
+}
+
+func (m *Message) build(from string) []byte {
+	headers := []string{
+		"From: " + from,
+		"To: " + m.To,
+		"Subject: " + m.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+	}
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + m.Body)
+}
+
+func (m *smtpMailer) Send(pctx context.Context, msg *Message) error {
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("error: mail header is invalid")
+	}
+
+	addr := net.JoinHostPort(m.cfg.Host, m.cfg.Port)
+
+	var auth smtp.Auth
+	if m.cfg.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- smtp.SendMail(addr, auth, m.cfg.From, []string{msg.To}, msg.build(m.cfg.From))
+	}()
+
+	ctx, cancel := context.WithTimeout(pctx, 30*time.Second)
+	defer cancel()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			log.Printf("Error: Send mail to %s failed: %s", msg.To, err.Error())
+			return errors.New("error: send mail failed")
+		}
+	case <-ctx.Done():
+		log.Printf("Error: Send mail to %s failed: %s", msg.To, ctx.Err().Error())
+		return errors.New("error: send mail failed")
+	}
+
+	return nil
+}
+
+func (m *fileMailer) Send(pctx context.Context, msg *Message) error {
+	if err := os.MkdirAll(m.cfg.Dir, 0o755); err != nil {
+		log.Printf("Error: Create mail dir failed: %s", err.Error())
+		return errors.New("error: send mail failed")
+	}
+
+	fileName := filepath.Join(m.cfg.Dir, fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To)))
+	if err := os.WriteFile(fileName, msg.build(m.cfg.From), 0o644); err != nil {
+		log.Printf("Error: Write mail file failed: %s", err.Error())
+		return errors.New("error: send mail failed")
+	}
+
+	log.Printf("Info: Mail to %s written to %s", msg.To, fileName)
+
+	return nil
+}
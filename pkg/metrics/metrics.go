@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// Every metric lives in the default registry, which also carries the go runtime and process collectors.
+var (
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	GrpcServerHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "gRPC calls completed by the server by method and status code.",
+	}, []string{"method", "code"})
+
+	GrpcServerHandlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "gRPC server handling latency by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	GrpcClientHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_handled_total",
+		Help: "gRPC calls completed by the client by method and status code.",
+	}, []string{"method", "code"})
+
+	GrpcClientHandlingSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_client_handling_seconds",
+		Help:    "gRPC client call latency by method, retries included.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	KafkaProduced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_produced_messages_total",
+		Help: "Kafka messages produced by topic, key and result.",
+	}, []string{"topic", "key", "result"})
+
+	KafkaConsumed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumed_messages_total",
+		Help: "Kafka messages consumed by topic and key.",
+	}, []string{"topic", "key"})
+
+	KafkaConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages between the last consumed offset and the high water mark of the partition.",
+	}, []string{"topic", "partition"})
+
+	MongoCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongo_command_duration_seconds",
+		Help:    "Mongo command latency by command name and result.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"command", "result"})
+
+	Purchases = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shop_purchases_total",
+		Help: "Buy item requests by result.",
+	}, []string{"result"})
+
+	Sales = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shop_sales_total",
+		Help: "Sell item requests by result.",
+	}, []string{"result"})
+
+	Rollbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "shop_rollbacks_total",
+		Help: "Payment sagas compensated by operation and step.",
+	}, []string{"operation", "step"})
+
+	FailedLogins = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_failed_logins_total",
+		Help: "Rejected logins by reason.",
+	}, []string{"reason"})
+)
+
+// Result is the label value for business counters.
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+func Handler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.Handler())
+}
+
+// HttpMiddleware observes every request under its route template, so ids in the path do not
+// create a series each.
+func HttpMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		start := time.Now()
+		err := next(c)
+
+		status := c.Response().Status
+		if err != nil {
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				status = httpErr.Code
+			} else if !c.Response().Committed {
+				status = 500
+			}
+		}
+
+		route := c.Path()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		HttpRequestDuration.WithLabelValues(c.Request().Method, route, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// ObserveConsumed counts a consumed message and updates the lag of its partition.
+func ObserveConsumed(msg *sarama.ConsumerMessage, highWaterMark int64) {
+	KafkaConsumed.WithLabelValues(msg.Topic, string(msg.Key)).Inc()
+
+	lag := highWaterMark - msg.Offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	KafkaConsumerLag.WithLabelValues(msg.Topic, strconv.Itoa(int(msg.Partition))).Set(float64(lag))
+}
+
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			MongoCommandDuration.WithLabelValues(e.CommandName, "ok").Observe(e.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			MongoCommandDuration.WithLabelValues(e.CommandName, "error").Observe(e.Duration.Seconds())
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package oidc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type (
+	// mockIdentityProvider is a tiny in-memory OpenID provider for local development.
+	// Every authorization request is approved straight away for the email given
+	// in login_hint (or a default one), so the full code + PKCE flow can be exercised
+	// without a real Google or Discord account.
+	mockIdentityProvider struct {
+		issuer   string
+		clientId string
+		mu       sync.Mutex
+		codes    map[string]*mockGrant
+		tokens   map[string]*mockGrant
+	}
+
+	mockGrant struct {
+		email         string
+		nonce         string
+		codeChallenge string
+		redirectUri   string
+		expiredAt     time.Time
+	}
+)
+
+func NewMockIdentityProvider(issuer, clientId string) http.Handler {
+	m := &mockIdentityProvider{
+		issuer:   strings.TrimSuffix(issuer, "/"),
+		clientId: clientId,
+		codes:    make(map[string]*mockGrant),
+		tokens:   make(map[string]*mockGrant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/userinfo", m.userinfo)
+	return mux
+}
+
+func (m *mockIdentityProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, &Discovery{
+		Issuer:                m.issuer,
+		AuthorizationEndpoint: m.issuer + "/authorize",
+		TokenEndpoint:         m.issuer + "/token",
+		UserinfoEndpoint:      m.issuer + "/userinfo",
+	})
+}
+
+func (m *mockIdentityProvider) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != m.clientId || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	email := q.Get("login_hint")
+	if email == "" {
+		email = "mockplayer@bonx.com"
+	}
+
+	code, err := RandomString(24)
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	m.mu.Lock()
+	m.codes[code] = &mockGrant{
+		email:         email,
+		nonce:         q.Get("nonce"),
+		codeChallenge: q.Get("code_challenge"),
+		redirectUri:   q.Get("redirect_uri"),
+		expiredAt:     time.Now().Add(time.Minute),
+	}
+	m.mu.Unlock()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *mockIdentityProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	m.mu.Lock()
+	grant, ok := m.codes[r.PostForm.Get("code")]
+	delete(m.codes, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	if !ok || grant.expiredAt.Before(time.Now()) ||
+		r.PostForm.Get("client_id") != m.clientId ||
+		r.PostForm.Get("redirect_uri") != grant.redirectUri ||
+		CodeChallenge(r.PostForm.Get("code_verifier")) != grant.codeChallenge {
+		writeJson(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	accessToken, err := RandomString(24)
+	if err != nil {
+		writeJson(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	m.mu.Lock()
+	m.tokens[accessToken] = grant
+	m.mu.Unlock()
+
+	idToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, &idTokenClaims{
+		Email:             grant.email,
+		EmailVerified:     true,
+		PreferredUsername: strings.Split(grant.email, "@")[0],
+		Nonce:             grant.nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   "mock|" + grant.email,
+			Audience:  []string{m.clientId},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}).SignedString([]byte("mockidp"))
+
+	writeJson(w, http.StatusOK, &TokenRes{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		IdToken:     idToken,
+		ExpiresIn:   3600,
+	})
+}
+
+func (m *mockIdentityProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	grant, ok := m.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	m.mu.Unlock()
+
+	if !ok {
+		writeJson(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"sub":                "mock|" + grant.email,
+		"email":              grant.email,
+		"email_verified":     true,
+		"preferred_username": strings.Split(grant.email, "@")[0],
+	})
+}
+
+func writeJson(w http.ResponseWriter, statusCode int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
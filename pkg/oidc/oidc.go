@@ -0,0 +1,357 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type (
+	ProviderService interface {
+		Name() string
+		AuthCodeUrl(pctx context.Context, state, nonce, codeVerifier string) (string, error)
+		Exchange(pctx context.Context, code, codeVerifier, nonce string) (*Identity, error)
+	}
+
+	provider struct {
+		cfg       *config.OidcProvider
+		client    *http.Client
+		mu        sync.Mutex
+		discovery *Discovery
+	}
+
+	Discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserinfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+
+	TokenRes struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		IdToken     string `json:"id_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+
+	Identity struct {
+		Provider      string
+		Subject       string
+		Email         string
+		EmailVerified bool
+		Username      string
+	}
+
+	idTokenClaims struct {
+		Email             string `json:"email"`
+		EmailVerified     any    `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+		Nonce             string `json:"nonce"`
+		jwt.RegisteredClaims
+	}
+)
+
+var oidcLog = logger.New("oidc")
+
+func NewProvider(cfg *config.OidcProvider) ProviderService {
+	return &provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *provider) Name() string {
+	return p.cfg.Name
+}
+
+// endpoints uses the explicitly configured urls when present (for providers such as Discord
+// that do not publish a discovery document) and falls back to the issuer's discovery document.
+func (p *provider) endpoints(pctx context.Context) (*Discovery, error) {
+	if p.cfg.AuthUrl != "" && p.cfg.TokenUrl != "" {
+		return &Discovery{
+			Issuer:                p.cfg.Issuer,
+			AuthorizationEndpoint: p.cfg.AuthUrl,
+			TokenEndpoint:         p.cfg.TokenUrl,
+			UserinfoEndpoint:      p.cfg.UserinfoUrl,
+		}, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		oidcLog.Error(ctx, "OIDC discovery request failed", "error", err)
+		return nil, errors.New("error: oidc discovery failed")
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		oidcLog.Error(ctx, "OIDC discovery failed", "error", err)
+		return nil, errors.New("error: oidc discovery failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		oidcLog.Error(ctx, "OIDC discovery failed", "status", res.StatusCode)
+		return nil, errors.New("error: oidc discovery failed")
+	}
+
+	discovery := new(Discovery)
+	if err := json.NewDecoder(res.Body).Decode(discovery); err != nil {
+		oidcLog.Error(ctx, "OIDC discovery decode failed", "error", err)
+		return nil, errors.New("error: oidc discovery failed")
+	}
+	p.discovery = discovery
+
+	return discovery, nil
+}
+
+func (p *provider) AuthCodeUrl(pctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	discovery, err := p.endpoints(pctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientId)
+	params.Set("redirect_uri", p.cfg.RedirectUrl)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", CodeChallenge(codeVerifier))
+	params.Set("code_challenge_method", "S256")
+
+	return fmt.Sprintf("%s?%s", discovery.AuthorizationEndpoint, params.Encode()), nil
+}
+
+func (p *provider) Exchange(pctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	discovery, err := p.endpoints(pctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.token(pctx, discovery, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{Provider: p.cfg.Name}
+
+	if token.IdToken != "" {
+		if err := p.readIdToken(pctx, discovery, token.IdToken, nonce, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	if discovery.UserinfoEndpoint != "" {
+		if err := p.readUserinfo(pctx, discovery, token.AccessToken, identity); err != nil {
+			return nil, err
+		}
+	}
+
+	if identity.Subject == "" {
+		oidcLog.Error(pctx, "OIDC identity has no subject", "provider", p.cfg.Name)
+		return nil, errors.New("error: oidc identity is invalid")
+	}
+
+	return identity, nil
+}
+
+func (p *provider) token(pctx context.Context, discovery *Discovery, code, codeVerifier string) (*TokenRes, error) {
+	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	defer cancel()
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectUrl)
+	form.Set("client_id", p.cfg.ClientId)
+	form.Set("code_verifier", codeVerifier)
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		oidcLog.Error(ctx, "OIDC token request failed", "error", err)
+		return nil, errors.New("error: oidc token exchange failed")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		oidcLog.Error(ctx, "OIDC token exchange failed", "error", err)
+		return nil, errors.New("error: oidc token exchange failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		oidcLog.Error(ctx, "OIDC token exchange failed", "status", res.StatusCode, "body", string(body))
+		return nil, errors.New("error: oidc token exchange failed")
+	}
+
+	token := new(TokenRes)
+	if err := json.NewDecoder(res.Body).Decode(token); err != nil {
+		oidcLog.Error(ctx, "OIDC token decode failed", "error", err)
+		return nil, errors.New("error: oidc token exchange failed")
+	}
+
+	return token, nil
+}
+
+// readIdToken validates the id token claims. The token comes straight from the token endpoint
+// over TLS, so the issuer is trusted through the server certificate (OIDC Core 3.1.3.7).
+func (p *provider) readIdToken(pctx context.Context, discovery *Discovery, idToken, nonce string, identity *Identity) error {
+	claims := new(idTokenClaims)
+	if _, _, err := jwt.NewParser().ParseUnverified(idToken, claims); err != nil {
+		oidcLog.Error(pctx, "OIDC id token parse failed", "error", err)
+		return errors.New("error: oidc id token is invalid")
+	}
+
+	if discovery.Issuer != "" && claims.Issuer != discovery.Issuer {
+		oidcLog.Error(pctx, "OIDC id token issuer mismatch", "issuer", claims.Issuer)
+		return errors.New("error: oidc id token is invalid")
+	}
+
+	audienceOk := false
+	for _, aud := range claims.Audience {
+		if aud == p.cfg.ClientId {
+			audienceOk = true
+		}
+	}
+	if !audienceOk {
+		oidcLog.Error(pctx, "OIDC id token audience mismatch", "audience", claims.Audience)
+		return errors.New("error: oidc id token is invalid")
+	}
+
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Before(time.Now()) {
+		oidcLog.Error(pctx, "OIDC id token is expired")
+		return errors.New("error: oidc id token is expired")
+	}
+
+	if claims.Nonce != nonce {
+		oidcLog.Error(pctx, "OIDC id token nonce mismatch")
+		return errors.New("error: oidc id token is invalid")
+	}
+
+	identity.Subject = claims.Subject
+	identity.Email = claims.Email
+	identity.EmailVerified = isTrue(claims.EmailVerified)
+	identity.Username = firstNonEmpty(claims.PreferredUsername, claims.Name)
+
+	return nil
+}
+
+func (p *provider) readUserinfo(pctx context.Context, discovery *Discovery, accessToken string, identity *Identity) error {
+	ctx, cancel := context.WithTimeout(pctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discovery.UserinfoEndpoint, nil)
+	if err != nil {
+		oidcLog.Error(ctx, "OIDC userinfo request failed", "error", err)
+		return errors.New("error: oidc userinfo failed")
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		oidcLog.Error(ctx, "OIDC userinfo failed", "error", err)
+		return errors.New("error: oidc userinfo failed")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		oidcLog.Error(ctx, "OIDC userinfo failed", "status", res.StatusCode)
+		return errors.New("error: oidc userinfo failed")
+	}
+
+	info := make(map[string]any)
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		oidcLog.Error(ctx, "OIDC userinfo decode failed", "error", err)
+		return errors.New("error: oidc userinfo failed")
+	}
+
+	// Discord style user objects use "id", "username" and "verified" instead of the standard claims.
+	subject := firstNonEmpty(stringOf(info["sub"]), stringOf(info["id"]))
+	if identity.Subject != "" && subject != "" && subject != identity.Subject {
+		oidcLog.Error(ctx, "OIDC userinfo subject mismatch")
+		return errors.New("error: oidc identity is invalid")
+	}
+	identity.Subject = firstNonEmpty(identity.Subject, subject)
+	identity.Email = firstNonEmpty(identity.Email, stringOf(info["email"]))
+	identity.EmailVerified = identity.EmailVerified || isTrue(info["email_verified"]) || isTrue(info["verified"])
+	identity.Username = firstNonEmpty(identity.Username, stringOf(info["preferred_username"]), stringOf(info["username"]), stringOf(info["name"]))
+
+	return nil
+}
+
+// RandomString returns a url safe random string, used for state, nonce and pkce verifiers.
+// They are only as good as the randomness, so a failed read is an error, never a zero value.
+func RandomString(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func stringOf(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return fmt.Sprintf("%.0f", t)
+	}
+	return ""
+}
+
+func isTrue(v any) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case string:
+		return t == "true"
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/oidc"
+)
+
+// Local OpenID provider for testing social login:
+// go run ./pkg/oidc/script/mockidp.go 0.0.0.0:9400 http://localhost:9400 bonx-shop
+func main() {
+	if len(os.Args) < 4 {
+		log.Fatal("Error: usage: mockidp <listen address> <issuer url> <client id>")
+	}
+
+	addr, issuer, clientId := os.Args[1], os.Args[2], os.Args[3]
+
+	log.Printf("Mock identity provider %s listening on %s", issuer, addr)
+	if err := http.ListenAndServe(addr, oidc.NewMockIdentityProvider(issuer, clientId)); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
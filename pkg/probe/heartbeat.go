@@ -0,0 +1,86 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Long running workers beat at HeartbeatInterval, a worker that missed three beats is stuck.
+const (
+	HeartbeatInterval = 10 * time.Second
+	heartbeatTimeout  = 3 * HeartbeatInterval
+)
+
+type (
+	// Heartbeat lets readiness tell a kafka consumer that exited or hangs from one that is idle.
+	Heartbeat struct {
+		name       string
+		mu         sync.Mutex
+		lastBeatAt time.Time
+		stopped    bool
+	}
+
+	heartbeatRegistry struct {
+		mu   sync.Mutex
+		list []*Heartbeat
+	}
+)
+
+var heartbeats = new(heartbeatRegistry)
+
+// NewHeartbeat registers a worker, call it before the worker connects so a failed start is reported.
+func NewHeartbeat(name string) *Heartbeat {
+	h := &Heartbeat{
+		name:       name,
+		lastBeatAt: time.Now(),
+	}
+
+	heartbeats.mu.Lock()
+	heartbeats.list = append(heartbeats.list, h)
+	heartbeats.mu.Unlock()
+
+	return h
+}
+
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastBeatAt = time.Now()
+}
+
+// Stop marks the worker as exited, it is deferred by the worker itself.
+func (h *Heartbeat) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopped = true
+}
+
+func (h *Heartbeat) check(pctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.stopped {
+		return errors.New("error: worker has exited")
+	}
+	if time.Since(h.lastBeatAt) > heartbeatTimeout {
+		return errors.New("error: worker heartbeat is stale")
+	}
+	return nil
+}
+
+// Heartbeats returns one check per registered worker.
+func Heartbeats() []Check {
+	heartbeats.mu.Lock()
+	defer heartbeats.mu.Unlock()
+
+	checks := make([]Check, 0, len(heartbeats.list))
+	for _, h := range heartbeats.list {
+		checks = append(checks, Check{
+			Name: "consumer:" + h.name,
+			Run:  h.check,
+		})
+	}
+	return checks
+}
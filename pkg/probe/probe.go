@@ -0,0 +1,121 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/bonxatiwat/bonx-shop-tutorial/config"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/queue"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+
+	// every check gets at most this long, a hanging dependency counts as down
+	checkTimeout = 5 * time.Second
+)
+
+type (
+	// Check is one dependency the service needs to serve traffic.
+	Check struct {
+		Name string
+		Run  func(pctx context.Context) error
+	}
+
+	Result struct {
+		Name      string `json:"name"`
+		Status    string `json:"status"`
+		LatencyMs int64  `json:"latency_ms"`
+		Error     string `json:"error,omitempty"`
+	}
+)
+
+// RunAll runs the checks concurrently and reports whether every one of them passed.
+func RunAll(pctx context.Context, checks []Check) ([]*Result, bool) {
+	results := make([]*Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(pctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	healthy := true
+	for _, r := range results {
+		if r.Status != StatusUp {
+			healthy = false
+		}
+	}
+	return results, healthy
+}
+
+func run(pctx context.Context, check Check) *Result {
+	ctx, cancel := context.WithTimeout(pctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := &Result{
+		Name:      check.Name,
+		Status:    StatusUp,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func Mongo(db *mongo.Client) Check {
+	return Check{
+		Name: "mongo",
+		Run: func(pctx context.Context) error {
+			if err := db.Ping(pctx, readpref.Primary()); err != nil {
+				log.Printf("Error: Mongo ping failed: %s", err.Error())
+				return errors.New("error: mongo is unreachable")
+			}
+			return nil
+		},
+	}
+}
+
+// Grpc checks the overall status a downstream server reports through grpc.health.v1.
+func Grpc(name, target string) Check {
+	return Check{
+		Name: "grpc:" + name,
+		Run: func(pctx context.Context) error {
+			return grpccon.CheckHealth(pctx, target)
+		},
+	}
+}
+
+func Kafka(cfg *config.Kafka) Check {
+	return Check{
+		Name: "kafka",
+		Run: func(pctx context.Context) error {
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- queue.Ping([]string{cfg.Url}, cfg.ApiKey, cfg.Secret)
+			}()
+
+			select {
+			case err := <-errCh:
+				return err
+			case <-pctx.Done():
+				return errors.New("error: kafka check timed out")
+			}
+		},
+	}
+}
@@ -1,16 +1,20 @@
 package queue
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"log"
+	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/metrics"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/tracing"
 	"github.com/go-playground/validator/v10"
 )
 
-func ConnectProducer(brokerUrls []string, apiKey, secret string) (sarama.SyncProducer, error) {
+func newConfig(apiKey, secret string) *sarama.Config {
 	config := sarama.NewConfig()
 	if apiKey != "" && secret != "" {
 		config.Net.SASL.Enable = true
@@ -25,6 +29,27 @@ func ConnectProducer(brokerUrls []string, apiKey, secret string) (sarama.SyncPro
 			ClientAuth:         tls.NoClientCert,
 		}
 	}
+	return config
+}
+
+// Ping checks that at least one of the brokers answers a metadata request.
+func Ping(brokerUrls []string, apiKey, secret string) error {
+	config := newConfig(apiKey, secret)
+	config.Net.DialTimeout = 5 * time.Second
+	config.Metadata.Retry.Max = 0
+
+	client, err := sarama.NewClient(brokerUrls, config)
+	if err != nil {
+		log.Printf("Error: Failed to reach kafka: %s", err.Error())
+		return errors.New("error: kafka is unreachable")
+	}
+	defer client.Close()
+
+	return nil
+}
+
+func ConnectProducer(brokerUrls []string, apiKey, secret string) (sarama.SyncProducer, error) {
+	config := newConfig(apiKey, secret)
 	config.Producer.Return.Successes = true
 	config.Producer.RequiredAcks = sarama.WaitForAll
 	config.Producer.Retry.Max = 3
@@ -37,22 +62,36 @@ func ConnectProducer(brokerUrls []string, apiKey, secret string) (sarama.SyncPro
 	return producer, nil
 }
 
-func PushMessageWithKeyToQueue(brokerUrls []string, apiKey, secret, topic, key string, message []byte) error {
+// PushMessageWithKeyToQueue carries the trace of pctx to the consumer in the message headers.
+func PushMessageWithKeyToQueue(pctx context.Context, brokerUrls []string, apiKey, secret, topic, key string, message []byte) error {
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.StringEncoder(message),
+		Key:   sarama.StringEncoder(key),
+	}
+
+	_, span := startPublishSpan(pctx, msg, key)
+	defer span.End()
+
+	// A message sent after the caller has gone away would start work nobody waits for
+	if err := pctx.Err(); err != nil {
+		tracing.RecordError(span, err)
+		log.Printf("Error: Push message to %s cancelled: %s", topic, err.Error())
+		return errors.New("error: push message cancelled")
+	}
+
 	producer, err := ConnectProducer(brokerUrls, apiKey, secret)
 	if err != nil {
+		tracing.RecordError(span, err)
 		log.Printf("Error: Failed to connect to producer: %s", err.Error())
 		return errors.New("error: failed to connect to producer")
 	}
 	defer producer.Close()
 
-	msg := &sarama.ProducerMessage{
-		Topic: topic,
-		Value: sarama.StringEncoder(message),
-		Key:   sarama.StringEncoder(key),
-	}
-
 	partition, offset, err := producer.SendMessage(msg)
+	metrics.KafkaProduced.WithLabelValues(topic, key, metrics.Result(err)).Inc()
 	if err != nil {
+		tracing.RecordError(span, err)
 		log.Printf("Error: Failed to send message: %s", err.Error())
 		return errors.New("error: failed to send message")
 	}
@@ -62,20 +101,7 @@ func PushMessageWithKeyToQueue(brokerUrls []string, apiKey, secret, topic, key s
 }
 
 func ConnectConsumer(brokerUrls []string, apiKey, secret string) (sarama.Consumer, error) {
-	config := sarama.NewConfig()
-	if apiKey != "" && secret != "" {
-		config.Net.SASL.Enable = true
-		config.Net.SASL.User = apiKey
-		config.Net.SASL.Password = secret
-		config.Net.SASL.Mechanism = "PLAIN"
-		config.Net.SASL.Handshake = true
-		config.Net.SASL.Version = sarama.SASLHandshakeV1
-		config.Net.TLS.Enable = true
-		config.Net.TLS.Config = &tls.Config{
-			InsecureSkipVerify: true,
-			ClientAuth:         tls.NoClientCert,
-		}
-	}
+	config := newConfig(apiKey, secret)
 	config.Consumer.Return.Errors = true
 	config.Consumer.Fetch.Max = 3
 
@@ -1,10 +1,113 @@
 package rbac
 
-func IntToBinary(n, length int) []int {
-	binary := make([]int, length)
-	for i := 0; i < length; i++ {
-		binary[i] = n % 2
-		n /= 2
+import "strings"
+
+// Roles
+const (
+	RolePlayer = "player"
+	RoleAdmin  = "admin"
+)
+
+// Permissions
+const (
+	ItemCreate = "item:create"
+	ItemEdit   = "item:edit"
+	ItemToggle = "item:toggle"
+	ItemPrice  = "item:price"
+	ItemImport = "item:import"
+	ItemExport = "item:export"
+	ItemDelete = "item:delete"
+	ItemAudit  = "item:audit"
+
+	PaymentBuy    = "payment:buy"
+	PaymentSell   = "payment:sell"
+	PaymentRefund = "payment:refund"
+
+	AuthUnlock = "auth:unlock"
+)
+
+// adminPermissions can only be used after the player passed multi-factor authentication.
+var adminPermissions = map[string]bool{
+	ItemCreate:    true,
+	ItemEdit:      true,
+	ItemToggle:    true,
+	ItemPrice:     true,
+	ItemImport:    true,
+	ItemExport:    true,
+	ItemDelete:    true,
+	ItemAudit:     true,
+	PaymentRefund: true,
+	AuthUnlock:    true,
+}
+
+var rolePermissions = map[string][]string{
+	RolePlayer: {
+		PaymentBuy,
+		PaymentSell,
+	},
+	RoleAdmin: {
+		ItemCreate,
+		ItemEdit,
+		ItemToggle,
+		ItemPrice,
+		ItemImport,
+		ItemExport,
+		ItemDelete,
+		ItemAudit,
+		PaymentRefund,
+		AuthUnlock,
+	},
+}
+
+// RolePermissions returns the permissions granted to a single role title.
+func RolePermissions(role string) []string {
+	return rolePermissions[strings.ToLower(role)]
+}
+
+// PermissionsOfRoles merges the permissions of every given role without duplicates.
+func PermissionsOfRoles(roles []string) []string {
+	seen := make(map[string]bool)
+	permissions := make([]string, 0)
+	for _, role := range roles {
+		for _, p := range RolePermissions(role) {
+			if !seen[p] {
+				seen[p] = true
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	return permissions
+}
+
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func IsAdminPermission(permission string) bool {
+	return adminPermissions[permission]
+}
+
+func HasAdminPermission(permissions []string) bool {
+	for _, p := range permissions {
+		if IsAdminPermission(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutAdminPermissions strips admin permissions, used until the player completes MFA.
+func WithoutAdminPermissions(permissions []string) []string {
+	results := make([]string, 0)
+	for _, p := range permissions {
+		if !IsAdminPermission(p) {
+			results = append(results, p)
+		}
 	}
-	return binary
+	return results
 }
@@ -0,0 +1,105 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of RFC 6238 Appendix B, "12345678901234567890" in base32
+var rfcSecret = encoding.EncodeToString([]byte("12345678901234567890"))
+
+// The vectors of RFC 6238 Appendix B are 8 digits, a 6 digit code is their last 6 digits.
+func TestCodeRfc6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code(%d) error: %v", tt.unix, err)
+		}
+		if want := tt.code[2:]; got != want {
+			t.Errorf("Code(%d) = %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestCodeLowerCaseSecret(t *testing.T) {
+	got, err := Code(" "+strings.ToLower(rfcSecret)+" ", Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatalf("Code error: %v", err)
+	}
+	if got != "287082" {
+		t.Errorf("Code = %s, want 287082", got)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code with an invalid secret should fail")
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := Step(now)
+
+	tests := []struct {
+		name  string
+		step  int64
+		valid bool
+	}{
+		{"current step", current, true},
+		{"one step behind", current - 1, true},
+		{"one step ahead", current + 1, true},
+		{"two steps behind", current - 2, false},
+		{"two steps ahead", current + 2, false},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, tt.step)
+		if err != nil {
+			t.Fatalf("%s: Code error: %v", tt.name, err)
+		}
+
+		step, ok := Validate(rfcSecret, code, now)
+		if ok != tt.valid {
+			t.Errorf("%s: Validate = %v, want %v", tt.name, ok, tt.valid)
+			continue
+		}
+		if ok && step != tt.step {
+			t.Errorf("%s: Validate step = %d, want %d", tt.name, step, tt.step)
+		}
+	}
+}
+
+func TestValidateStepBoundary(t *testing.T) {
+	// 59 is the last second of step 1, 60 the first of step 2
+	if Step(time.Unix(59, 0)) != 1 || Step(time.Unix(60, 0)) != 2 {
+		t.Fatalf("Step boundary is off, got %d and %d", Step(time.Unix(59, 0)), Step(time.Unix(60, 0)))
+	}
+
+	code, _ := Code(rfcSecret, 1)
+	if _, ok := Validate(rfcSecret, code, time.Unix(60+period, 0)); ok {
+		t.Error("a code two steps old should be rejected")
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	for _, code := range []string{"", "000000", "05047", "0050471", "14050471"} {
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Errorf("Validate(%q) should be rejected", code)
+		}
+	}
+}
@@ -7,7 +7,7 @@ import (
 	authPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/auth/authPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/auth/authRepository"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/auth/authUsecase"
-	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/rbac"
 )
 
 func (s *server) authService() {
@@ -18,7 +18,7 @@ func (s *server) authService() {
 
 	// gRPC
 	go func() {
-		grpcServer, lis := grpccon.NewGrpcServer(&s.cfg.Jwt, s.cfg.Grpc.AuthUrl)
+		grpcServer, lis := s.newGrpcServer(s.cfg.Grpc.AuthUrl)
 
 		authPb.RegisterAuthGrpcServiceServer(grpcServer, grpcHandler)
 
@@ -30,8 +30,14 @@ func (s *server) authService() {
 
 	// Health Check
 	auth.GET("", s.healthCheckService)
+	auth.GET("/live", s.liveness)
+	auth.GET("/ready", s.readiness)
 
 	auth.POST("/auth/login", httpHandler.Login)
+	auth.POST("/auth/login/mfa", httpHandler.LoginMfa)
+	auth.POST("/auth/unlock", httpHandler.UnlockLogin, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.AuthUnlock))
 	auth.POST("/auth/refresh-token", httpHandler.RefreshToken)
 	auth.POST("/auth/logout", httpHandler.Logout)
+	auth.GET("/auth/oidc/:provider/login", httpHandler.OidcLogin)
+	auth.GET("/auth/oidc/:provider/callback", httpHandler.OidcCallback)
 }
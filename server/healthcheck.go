@@ -1,16 +1,27 @@
 package server
 
 import (
+	"context"
+	"log"
 	"net/http"
 
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/probe"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/response"
 	"github.com/labstack/echo/v4"
 )
 
-type healthCheck struct {
-	App    string `json:"app"`
-	Status string `json:"status"`
-}
+type (
+	healthCheck struct {
+		App    string `json:"app"`
+		Status string `json:"status"`
+	}
+
+	readinessRes struct {
+		App    string          `json:"app"`
+		Status string          `json:"status"`
+		Checks []*probe.Result `json:"checks"`
+	}
+)
 
 func (s *server) healthCheckService(c echo.Context) error {
 	return response.SuccessResponse(c, http.StatusOK, &healthCheck{
@@ -18,3 +29,64 @@ func (s *server) healthCheckService(c echo.Context) error {
 		Status: "OK",
 	})
 }
+
+// liveness only tells that the process is up and serving http, it never checks dependencies
+// so an outage of Mongo or Kafka does not get every pod restarted.
+func (s *server) liveness(c echo.Context) error {
+	return response.SuccessResponse(c, http.StatusOK, &healthCheck{
+		App:    s.cfg.App.Name,
+		Status: probe.StatusUp,
+	})
+}
+
+func (s *server) readiness(c echo.Context) error {
+	results, ok := probe.RunAll(c.Request().Context(), s.readinessChecks())
+
+	res := &readinessRes{
+		App:    s.cfg.App.Name,
+		Status: probe.StatusUp,
+		Checks: results,
+	}
+	if !ok {
+		res.Status = probe.StatusDown
+		return response.SuccessResponse(c, http.StatusServiceUnavailable, res)
+	}
+	return response.SuccessResponse(c, http.StatusOK, res)
+}
+
+// readinessChecks adds the kafka consumers and the downstream gRPC servers of the service.
+func (s *server) readinessChecks() []probe.Check {
+	checks := append(s.dependencyChecks(), probe.Heartbeats()...)
+
+	// Every service but auth validates access tokens through the auth gRPC server
+	switch s.cfg.App.Name {
+	case "auth":
+		checks = append(checks, probe.Grpc("player", s.cfg.Grpc.PlayerUrl))
+	case "inventory", "payment":
+		checks = append(checks, probe.Grpc("auth", s.cfg.Grpc.AuthUrl), probe.Grpc("item", s.cfg.Grpc.ItemUrl))
+	default:
+		checks = append(checks, probe.Grpc("auth", s.cfg.Grpc.AuthUrl))
+	}
+	return checks
+}
+
+// dependencyChecks are the connections every service needs to serve traffic.
+func (s *server) dependencyChecks() []probe.Check {
+	checks := []probe.Check{probe.Mongo(s.db)}
+	if s.cfg.Kafka.Url != "" {
+		checks = append(checks, probe.Kafka(&s.cfg.Kafka))
+	}
+	return checks
+}
+
+// dependenciesHealthy is the probe behind the gRPC health service. It checks the same Mongo
+// and Kafka connections as /ready, which adds the consumers and the downstream servers.
+func (s *server) dependenciesHealthy(pctx context.Context) bool {
+	results, ok := probe.RunAll(pctx, s.dependencyChecks())
+	for _, r := range results {
+		if r.Status != probe.StatusUp {
+			log.Printf("Error: %s health check failed: %s", r.Name, r.Error)
+		}
+	}
+	return ok
+}
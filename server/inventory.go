@@ -21,5 +21,7 @@ func (s *server) inventoryService() {
 
 	// Health Check
 	inventory.GET("", s.healthCheckService)
+	inventory.GET("/live", s.liveness)
+	inventory.GET("/ready", s.readiness)
 	inventory.GET("/inventory/:player_id", httpHandler.FindPlayerItems, s.middleware.JwtAuthorization, s.middleware.PlayerIdParamValidation)
 }
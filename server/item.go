@@ -7,18 +7,22 @@ import (
 	itemPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemRepository"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/item/itemUsecase"
-	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/blob"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/rbac"
 )
 
 func (s *server) itemService() {
 	repo := itemRepository.NewItemRepository(s.db)
-	usecase := itemUsecase.NewItemUsecase(repo)
+	usecase := itemUsecase.NewItemUsecase(repo, blob.NewBlob(&s.cfg.Blob))
 	httpHandler := itemHandler.NewItemHttpHandler(s.cfg, usecase)
 	grpcHandler := itemHandler.NewItemGrpcHandler(usecase)
+	workerHandler := itemHandler.NewItemWorkerHandler(usecase)
+
+	go workerHandler.ApplyPriceSchedules()
 
 	// gRPC
 	go func() {
-		grpcServer, lis := grpccon.NewGrpcServer(&s.cfg.Jwt, s.cfg.Grpc.ItemUrl)
+		grpcServer, lis := s.newGrpcServer(s.cfg.Grpc.ItemUrl)
 
 		itemPb.RegisterItemGrpcServiceServer(grpcServer, grpcHandler)
 
@@ -32,10 +36,30 @@ func (s *server) itemService() {
 
 	// Health Check
 	item.GET("", s.healthCheckService)
+	item.GET("/live", s.liveness)
+	item.GET("/ready", s.readiness)
+
+	// Uploaded images, s3 serves its own
+	if s.cfg.Blob.Driver != "s3" {
+		item.Static("/images", s.cfg.Blob.LocalDir)
+	}
 
-	item.POST("/item", s.middleware.JwtAuthorization(s.middleware.RbacAuthorization(httpHandler.CreateItem, []int{1, 0})))
+	item.POST("/item/import", httpHandler.ImportItems, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemImport))
+	item.GET("/item/export", httpHandler.ExportItems, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemExport))
+	item.POST("/item", httpHandler.CreateItem, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemCreate))
 	item.GET("/item/:item_id", httpHandler.FindOneItem)
 	item.GET("/item", httpHandler.FindManyItems)
-	item.PATCH("/item/:item_id", s.middleware.JwtAuthorization(s.middleware.RbacAuthorization(httpHandler.EditItem, []int{1, 0})))
-	item.PATCH("/item/:item_id/is-activated", s.middleware.JwtAuthorization(s.middleware.RbacAuthorization(httpHandler.EnableOrDisableItem, []int{1, 0})))
+	item.PATCH("/item/:item_id", httpHandler.EditItem, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemEdit))
+	item.PATCH("/item/:item_id/is-activated", httpHandler.EnableOrDisableItem, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemToggle))
+	item.POST("/bundle", httpHandler.CreateBundle, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemCreate))
+	item.GET("/bundle/:bundle_id", httpHandler.FindOneBundle)
+	item.GET("/bundle", httpHandler.FindManyBundles)
+	item.PATCH("/bundle/:bundle_id/is-activated", httpHandler.EnableOrDisableBundle, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemToggle))
+	item.DELETE("/item/:item_id", httpHandler.DeleteItem, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemDelete))
+	item.POST("/item/:item_id/restore", httpHandler.RestoreItem, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemDelete))
+	item.GET("/item/:item_id/history", httpHandler.FindItemHistory, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemAudit))
+	item.POST("/item/:item_id/price-schedules", httpHandler.SchedulePrice, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemPrice))
+	item.GET("/item/:item_id/price-schedules", httpHandler.FindPriceSchedules, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemPrice))
+	item.DELETE("/item/:item_id/price-schedules/:schedule_id", httpHandler.CancelPriceSchedule, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemPrice))
+	item.GET("/item/:item_id/price-history", httpHandler.FindPriceHistory, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.ItemPrice))
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/payment/paymentHandler"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/payment/paymentRepository"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/payment/paymentUsecase"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/rbac"
 )
 
 func (s *server) paymentService() {
@@ -15,7 +16,9 @@ func (s *server) paymentService() {
 
 	// Health Check
 	payment.GET("", s.healthCheckService)
+	payment.GET("/live", s.liveness)
+	payment.GET("/ready", s.readiness)
 
-	payment.POST("/payment/buy", httpHandler.BuyItem, s.middleware.JwtAuthorization)
-	payment.POST("/payment/sell", httpHandler.SellItem, s.middleware.JwtAuthorization)
+	payment.POST("/payment/buy", httpHandler.BuyItem, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.PaymentBuy))
+	payment.POST("/payment/sell", httpHandler.SellItem, s.middleware.JwtAuthorization, s.middleware.RequirePermission(rbac.PaymentSell))
 }
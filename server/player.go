@@ -7,12 +7,12 @@ import (
 	playerPb "github.com/bonxatiwat/bonx-shop-tutorial/modules/player/playerPb"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player/playerRepository"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/player/playerUsecase"
-	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/mailer"
 )
 
 func (s *server) playerService() {
 	repo := playerRepository.NewPlayerRepository(s.db)
-	usecase := playerUsecase.NewPlayerUsecase(repo)
+	usecase := playerUsecase.NewPlayerUsecase(repo, mailer.NewMailer(&s.cfg.Mail))
 	httpHandler := playerHandler.NewPlayerHttpHandler(s.cfg, usecase)
 	grpcHandler := playerHandler.NewPlayerGrpcHandler(usecase)
 	queueHandler := playerHandler.NewPlayerQueueHandler(s.cfg, usecase)
@@ -23,7 +23,7 @@ func (s *server) playerService() {
 
 	// gRPC
 	go func() {
-		grpcServer, lis := grpccon.NewGrpcServer(&s.cfg.Jwt, s.cfg.Grpc.PlayerUrl)
+		grpcServer, lis := s.newGrpcServer(s.cfg.Grpc.PlayerUrl)
 
 		playerPb.RegisterPlayerGrpcServiceServer(grpcServer, grpcHandler)
 
@@ -35,9 +35,17 @@ func (s *server) playerService() {
 
 	// Health Check
 	player.GET("", s.healthCheckService)
+	player.GET("/live", s.liveness)
+	player.GET("/ready", s.readiness)
 
 	player.POST("/player/register", httpHandler.CreatePlayer)
 	player.POST("/player/add-money", httpHandler.AddPlayerMoney, s.middleware.JwtAuthorization)
 	player.GET("/player/:player_id", httpHandler.FindOnePlayerProfile)
 	player.GET("/player/saving-account/my-account", httpHandler.GetPlayerSavingAccount, s.middleware.JwtAuthorization)
+	player.POST("/player/mfa/enroll", httpHandler.EnrollPlayerMfa, s.middleware.JwtAuthorization)
+	player.POST("/player/mfa/activate", httpHandler.ActivatePlayerMfa, s.middleware.JwtAuthorization)
+	player.POST("/player/email-verification/request", httpHandler.RequestEmailVerification, s.middleware.JwtAuthorization)
+	player.POST("/player/email-verification/confirm", httpHandler.ConfirmEmailVerification)
+	player.POST("/player/password-reset/request", httpHandler.RequestPasswordReset)
+	player.POST("/player/password-reset/confirm", httpHandler.ConfirmPasswordReset)
 }
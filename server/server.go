@@ -2,10 +2,13 @@ package server
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -13,10 +16,18 @@ import (
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/middleware/middlewareHandler"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/middleware/middlewareRepository"
 	"github.com/bonxatiwat/bonx-shop-tutorial/modules/middleware/middlewareUsecase"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/deadline"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/grpccon"
 	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/jwtauth"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/locale"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/logger"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/metrics"
+	"github.com/bonxatiwat/bonx-shop-tutorial/pkg/tracing"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 type (
@@ -34,6 +45,18 @@ func newMiddleware(cfg *config.Config) middlewareHandler.MiddlewareHandlerServic
 	return middlewareHandler.NewMiddlewareHandler(cfg, usecase)
 }
 
+// newGrpcServer reports the Mongo and Kafka connectivity through the gRPC health service
+// and enables server reflection outside prod so the api can be explored with grpcurl.
+func (s *server) newGrpcServer(host string) (*grpc.Server, net.Listener) {
+	grpcServer, lis := grpccon.NewGrpcServer(&s.cfg.Jwt, host, s.dependenciesHealthy)
+
+	if s.cfg.App.Stage != "prod" {
+		reflection.Register(grpcServer)
+	}
+
+	return grpcServer, lis
+}
+
 func (s *server) gracefulShutdown(pctx context.Context, quit <-chan os.Signal) {
 	log.Printf("Start service: %s", s.cfg.App.Name)
 
@@ -46,6 +69,8 @@ func (s *server) gracefulShutdown(pctx context.Context, quit <-chan os.Signal) {
 	if err := s.app.Shutdown(ctx); err != nil {
 		log.Fatalf("Error: %v", err)
 	}
+
+	grpccon.CloseClients()
 }
 
 func (s *server) httpListening() {
@@ -62,14 +87,29 @@ func Start(pctx context.Context, cfg *config.Config, db *mongo.Client) {
 		middleware: newMiddleware(cfg),
 	}
 
-	jwtauth.SetApiKey(cfg.Jwt.ApiSecretKey)
+	jwtauth.SetApiKey(cfg.Jwt.ApiSecretKey, cfg.App.Name, cfg.Jwt.ApiDuration)
+	deadline.Set(&cfg.Timeout)
+	locale.Set(&cfg.Locale)
+
+	if err := grpccon.SetTls(&cfg.Grpc); err != nil {
+		log.Fatalf("Error: %s", err.Error())
+	}
+
+	// Client Ip, a header sent by anyone else than a trusted proxy is ignored
+	ipExtractor, err := newIpExtractor(cfg.App.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Error: %s", err.Error())
+	}
+	s.app.IPExtractor = ipExtractor
 
 	// Basic Middleware
-	// Request Timeout
+	// Request Timeout, an export streams for as long as the catalogue takes
 	s.app.Use(middleware.TimeoutWithConfig(middleware.TimeoutConfig{
-		Skipper:      middleware.DefaultSkipper,
+		Skipper: func(c echo.Context) bool {
+			return strings.HasSuffix(c.Path(), "/export")
+		},
 		ErrorMessage: "Error: Request Timeout",
-		Timeout:      30 * time.Second,
+		Timeout:      deadline.Request(),
 	}))
 
 	// CORS
@@ -82,6 +122,13 @@ func Start(pctx context.Context, cfg *config.Config, db *mongo.Client) {
 	// Body Limit
 	s.app.Use(middleware.BodyLimit("10M"))
 
+	// Metrics, Tracing, Request Id and Locale
+	s.app.Use(metrics.HttpMiddleware)
+	s.app.Use(tracing.EchoMiddleware)
+	s.app.Use(logger.EchoMiddleware)
+	s.app.Use(locale.EchoMiddleware)
+	s.app.GET("/metrics", metrics.Handler())
+
 	switch s.cfg.App.Name {
 	case "auth":
 		s.authService()
@@ -106,3 +153,26 @@ func Start(pctx context.Context, cfg *config.Config, db *mongo.Client) {
 	// Listening
 	s.httpListening()
 }
+
+// newIpExtractor reads the client ip from X-Forwarded-For only when the request came through
+// one of the trusted proxies, otherwise anyone could pick the ip the login lockout counts.
+func newIpExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range trustedProxies {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.New("error: trusted proxy " + cidr + " is not a cidr")
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}